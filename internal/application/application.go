@@ -1,6 +1,8 @@
 package application
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -12,11 +14,18 @@ import (
 
 	"github.com/HardDie/event_tracker/internal/config"
 	"github.com/HardDie/event_tracker/internal/db"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/jwt"
+	"github.com/HardDie/event_tracker/internal/limiter"
+	"github.com/HardDie/event_tracker/internal/mailer"
 	"github.com/HardDie/event_tracker/internal/middleware"
 	"github.com/HardDie/event_tracker/internal/migration"
+	"github.com/HardDie/event_tracker/internal/oidc"
+	"github.com/HardDie/event_tracker/internal/pwdpolicy"
 	"github.com/HardDie/event_tracker/internal/repository"
 	"github.com/HardDie/event_tracker/internal/server"
 	"github.com/HardDie/event_tracker/internal/service"
+	"github.com/HardDie/event_tracker/internal/utils"
 )
 
 type Application struct {
@@ -44,52 +53,175 @@ func Get() (*Application, error) {
 		return nil, err
 	}
 
+	// Client addresses are taken from forwarding headers only behind the trusted proxies
+	err = utils.SetTrustedProxies(app.Cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
 	// Prepare router
 	apiRouter := app.Router.PathPrefix("/api").Subrouter()
 	v1Router := apiRouter.PathPrefix("/v1").Subrouter()
 
+	// Init mailer
+	newMailer, err := mailer.Get(app.Cfg.Mailer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Init OpenID Connect provider
+	var oidcProvider *oidc.Provider
+	if app.Cfg.OIDC.Issuer != "" {
+		oidcProvider = oidc.New(app.Cfg.OIDC)
+	}
+
+	// Init signer of access tokens
+	var jwtSigner *jwt.Signer
+	if app.Cfg.AuthJWT {
+		jwtSigner, err = jwt.New(app.Cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Init repositories
 	userRepository := repository.NewUser()
 	passwordRepository := repository.NewPassword()
 	sessionRepository := repository.NewSession()
+	refreshTokenRepository := repository.NewRefreshToken()
+	accessTokenRepository := repository.NewAccessToken()
+	totpRepository := repository.NewTOTP()
+	recoveryCodeRepository := repository.NewRecoveryCode()
+	loginChallengeRepository := repository.NewLoginChallenge()
 	eventRepository := repository.NewEvent()
 	friendRepository := repository.NewFriend()
+	rateLimitRepository := repository.NewRateLimit()
+	authEventRepository := repository.NewAuthEvent()
+	exportRepository := repository.NewExport()
+	statsRepository := repository.NewStats()
+	identityRepository := repository.NewIdentity()
+	trashRepository := repository.NewTrash()
+	goalRepository := repository.NewGoal()
+	streakRepository := repository.NewStreak()
 
 	// Init services
+	passwordPolicy := pwdpolicy.New(app.Cfg.PwdPolicy)
 	systemService := service.NewSystem()
-	authService := service.NewAuth(app.DB, app.Cfg, userRepository, passwordRepository, sessionRepository)
-	eventService := service.NewEvent(app.DB, eventRepository)
+	authService := service.NewAuth(app.DB, app.Cfg, passwordPolicy, jwtSigner, userRepository, passwordRepository,
+		sessionRepository, refreshTokenRepository, accessTokenRepository, authEventRepository)
+	twoFactorService := service.NewTwoFactor(app.DB, app.Cfg, totpRepository, recoveryCodeRepository,
+		loginChallengeRepository, userRepository, passwordRepository, authEventRepository)
+	emailService := service.NewEmail(app.DB, app.Cfg, authService, newMailer, passwordPolicy, userRepository, passwordRepository,
+		sessionRepository, authEventRepository)
+	oidcService := service.NewOIDC(app.DB, app.Cfg, oidcProvider, identityRepository, userRepository, passwordRepository,
+		authEventRepository)
+	userService := service.NewUser(app.DB, app.Cfg, authService, passwordPolicy, userRepository, passwordRepository,
+		sessionRepository, accessTokenRepository, authEventRepository)
+	eventService := service.NewEvent(app.DB, app.Cfg, eventRepository, userRepository)
 	friendService := service.NewFriend(app.DB, friendRepository, userRepository)
+	adminService := service.NewAdmin(app.DB, app.Cfg, authService, userRepository, passwordRepository, sessionRepository,
+		accessTokenRepository, totpRepository, statsRepository, authEventRepository)
+	exportService := service.NewExport(app.DB, app.Cfg, exportRepository, userRepository, eventRepository,
+		friendRepository, sessionRepository, accessTokenRepository, authEventRepository, identityRepository,
+		goalRepository, streakRepository)
+	trashService := service.NewTrash(app.DB, app.Cfg, trashRepository, eventRepository)
+	goalService := service.NewGoal(app.DB, goalRepository, eventRepository, userRepository)
+	streakService := service.NewStreak(app.DB, streakRepository, eventRepository, userRepository)
 
 	// Init severs
 	systemServer := server.NewSystem(systemService)
-	authServer := server.NewAuth(app.Cfg, authService)
-	userServer := server.NewUser(
-		service.NewUser(app.DB, userRepository, passwordRepository),
-	)
-	eventServer := server.NewEvent(eventService)
+	authServer := server.NewAuth(app.Cfg, authService, twoFactorService, emailService, oidcService)
+	userServer := server.NewUser(userService)
+	eventServer := server.NewEvent(eventService, streakService)
 	friendServer := server.NewFriend(friendService)
+	exportServer := server.NewExport(exportService)
+	adminServer := server.NewAdmin(adminService)
+	trashServer := server.NewTrash(trashService)
+	goalServer := server.NewGoal(goalService)
+
+	// Init rate limit store
+	var rateLimitStore limiter.IStore
+	switch app.Cfg.RateLimitStore {
+	case limiter.StoreMemory:
+		rateLimitStore = limiter.NewMemory()
+	case limiter.StorePostgres:
+		rateLimitStore = limiter.NewPostgres(app.DB, rateLimitRepository)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store: %q", app.Cfg.RateLimitStore)
+	}
 
 	// Middleware
 	authMiddleware := middleware.NewAuthMiddleware(authService)
+	staffMiddleware := middleware.NewRoleMiddleware(adminService, entity.UserRoleModerator, entity.UserRoleAdmin)
 	timeoutMiddleware := middleware.NewTimeoutRequestMiddleware(time.Duration(app.Cfg.RequestTimeout) * time.Second)
+	loginLimit := middleware.RateLimit{IP: app.Cfg.RateLimitLogin, Username: app.Cfg.RateLimitLoginUsername}
+	rateLimitMiddleware := middleware.NewRateLimitMiddleware(rateLimitStore, time.Duration(app.Cfg.RateLimitWindow)*time.Second,
+		map[string]middleware.RateLimit{
+			"/api/v1/auth/register":        {IP: app.Cfg.RateLimitRegister},
+			"/api/v1/auth/login":           loginLimit,
+			"/api/v1/auth/login/2fa":       loginLimit,
+			"/api/v1/auth/refresh":         {IP: app.Cfg.RateLimitLogin},
+			"/api/v1/auth/password/forgot": loginLimit,
+			"/api/v1/auth/password/reset":  loginLimit,
+			"/api/v1/auth/email/verify":    loginLimit,
+			"/api/v1/auth/oidc/authorize":  loginLimit,
+			"/api/v1/auth/oidc/callback":   loginLimit,
+		},
+		middleware.RateLimit{User: app.Cfg.RateLimitAPI},
+	)
 
 	// Register servers
 	systemRouter := v1Router.PathPrefix("/system").Subrouter()
-	systemServer.RegisterPublicRouter(systemRouter, middleware.CorsMiddleware, timeoutMiddleware.RequestMiddleware)
+	systemServer.RegisterPublicRouter(systemRouter, timeoutMiddleware.RequestMiddleware)
 
 	authRouter := v1Router.PathPrefix("/auth").Subrouter()
-	authServer.RegisterPublicRouter(authRouter)
-	authServer.RegisterPrivateRouter(authRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.RequestMiddleware)
+	authServer.RegisterPublicRouter(authRouter, timeoutMiddleware.RequestMiddleware, rateLimitMiddleware.RequestMiddleware)
+	authServer.RegisterPrivateRouter(authRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.RequestMiddleware,
+		rateLimitMiddleware.RequestMiddleware)
+
+	exportRouter := v1Router.PathPrefix("/user/export").Subrouter()
+	exportServer.RegisterPublicRouter(exportRouter, timeoutMiddleware.RequestMiddleware)
+	exportServer.RegisterPrivateRouter(exportRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.RequestMiddleware,
+		rateLimitMiddleware.RequestMiddleware)
 
 	userRouter := v1Router.PathPrefix("/user").Subrouter()
-	userServer.RegisterPrivateRouter(userRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.RequestMiddleware)
+	userServer.RegisterPrivateRouter(userRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.RequestMiddleware,
+		rateLimitMiddleware.RequestMiddleware)
 
 	eventRouter := v1Router.PathPrefix("/events").Subrouter()
-	eventServer.RegisterPrivateRouter(eventRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.RequestMiddleware)
+	eventServer.RegisterPrivateRouter(eventRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.TokenRequestMiddleware,
+		rateLimitMiddleware.RequestMiddleware)
+
+	trashRouter := v1Router.PathPrefix("/trash").Subrouter()
+	trashServer.RegisterPrivateRouter(trashRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.TokenRequestMiddleware,
+		rateLimitMiddleware.RequestMiddleware)
+
+	goalRouter := v1Router.PathPrefix("/goals").Subrouter()
+	goalServer.RegisterPrivateRouter(goalRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.TokenRequestMiddleware,
+		rateLimitMiddleware.RequestMiddleware)
 
 	friendRouter := v1Router.PathPrefix("/friends").Subrouter()
-	friendServer.RegisterPrivateRouter(friendRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.RequestMiddleware)
+	friendServer.RegisterPrivateRouter(friendRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.TokenRequestMiddleware,
+		rateLimitMiddleware.RequestMiddleware)
+
+	adminRouter := v1Router.PathPrefix("/admin").Subrouter()
+	adminServer.RegisterPrivateRouter(adminRouter, timeoutMiddleware.RequestMiddleware, authMiddleware.RequestMiddleware,
+		staffMiddleware.RequestMiddleware, rateLimitMiddleware.RequestMiddleware)
+
+	// Promote the configured admin
+	err = adminService.Bootstrap(context.Background(), app.Cfg.AdminUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	// Background jobs
+	go runPeriodically(time.Duration(app.Cfg.AccountPurgeInterval)*time.Minute, userService.PurgeDeleted)
+	go runPeriodically(time.Duration(app.Cfg.ExportPurgeInterval)*time.Minute, exportService.PurgeExpired)
+	go runPeriodically(time.Duration(app.Cfg.OIDCStateTimeout)*time.Minute, oidcService.PurgeExpired)
+	go runPeriodically(time.Duration(app.Cfg.TrashPurgeInterval)*time.Minute, trashService.Purge)
+	if jwtSigner != nil {
+		go runPeriodically(time.Duration(app.Cfg.JWTRevocationSync)*time.Second, authService.SyncRevocations)
+	}
 
 	return app, nil
 }
@@ -103,8 +235,11 @@ func (app *Application) Run() error {
 		os.Exit(0)
 	}()
 
+	// CORS headers are needed for every route and for preflight requests, which don't match any route
+	corsMiddleware := middleware.NewCorsMiddleware(app.Cfg.CorsOrigins, app.Cfg.CorsCredentials)
+
 	defer app.Stop()
-	return http.ListenAndServe(app.Cfg.Port, app.Router)
+	return http.ListenAndServe(app.Cfg.Port, corsMiddleware.RequestMiddleware(app.Router))
 }
 
 func (app *Application) Stop() {
@@ -112,3 +247,14 @@ func (app *Application) Stop() {
 	app.DB = nil
 	log.Println("Done")
 }
+
+// runPeriodically calls the job with the interval until the process exits, errors are reported by the job itself
+func runPeriodically(interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_ = job(context.Background())
+		<-ticker.C
+	}
+}
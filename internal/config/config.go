@@ -3,19 +3,65 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 
 	"github.com/HardDie/event_tracker/internal/db"
+	"github.com/HardDie/event_tracker/internal/jwt"
+	"github.com/HardDie/event_tracker/internal/limiter"
 	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/mailer"
+	"github.com/HardDie/event_tracker/internal/oidc"
+	"github.com/HardDie/event_tracker/internal/pwdpolicy"
+	"github.com/HardDie/event_tracker/internal/utils"
 )
 
 type Config struct {
-	DB             db.DBConfig
-	Port           string
-	PwdMaxAttempts int
-	PwdBlockTime   int
-	RequestTimeout int
+	DB                     db.DBConfig
+	Port                   string
+	TrustedProxies         []string
+	PwdMaxAttempts         int
+	PwdBlockTime           int
+	PwdBlockDelay          int
+	RequestTimeout         int
+	SessionIdleTimeout     int
+	SessionAbsoluteTimeout int
+	RefreshTokenTimeout    int
+	TOTPIssuer             string
+	LoginChallengeTimeout  int
+	Mailer                 mailer.Config
+	AppURL                 string
+	TokenSecret            string
+	EmailVerifyTimeout     int
+	PwdResetTimeout        int
+	RateLimitStore         string
+	RateLimitWindow        int
+	RateLimitRegister      int
+	RateLimitLogin         int
+	RateLimitLoginUsername int
+	RateLimitAPI           int
+	PwdPolicy              pwdpolicy.Config
+	AccountDeletionGrace   int
+	AccountPurgeInterval   int
+	ExportTimeout          int
+	ExportPurgeInterval    int
+	AdminUsername          string
+	AuthCookie             bool
+	CookieDomain           string
+	CookieSecure           bool
+	CookieSameSite         string
+	CorsOrigins            []string
+	CorsCredentials        bool
+	OIDC                   oidc.Config
+	OIDCAutoProvision      bool
+	OIDCStateTimeout       int
+	AuthJWT                bool
+	JWT                    jwt.Config
+	JWTTimeout             int
+	JWTRevocationSync      int
+	TrashRetention         int
+	TrashPurgeInterval     int
 }
 
 func Get() *Config {
@@ -25,7 +71,7 @@ func Get() *Config {
 		}
 	}
 
-	return &Config{
+	cfg := &Config{
 		DB: db.DBConfig{
 			Host:     getEnv("DB_HOST", "db"),
 			Port:     getEnvAsInt("DB_PORT", 5432),
@@ -33,11 +79,87 @@ func Get() *Config {
 			Password: getEnv("DB_PWD", "event_tracker"),
 			Database: getEnv("DB_DB", "db"),
 		},
-		Port:           getEnv("PORT", ":8080"),
-		PwdMaxAttempts: getEnvAsInt("PWD_MAX_ATTEMPTS", 5),
-		PwdBlockTime:   getEnvAsInt("PWD_BLOCK_TIME", 24),
-		RequestTimeout: getEnvAsInt("REQUEST_TIMEOUT", 3),
+		Port:                   getEnv("PORT", ":8080"),
+		TrustedProxies:         getEnvAsSlice("TRUSTED_PROXIES", nil),
+		PwdMaxAttempts:         getEnvAsInt("PWD_MAX_ATTEMPTS", 5),
+		PwdBlockTime:           getEnvAsInt("PWD_BLOCK_TIME", 24),
+		PwdBlockDelay:          getEnvAsInt("PWD_BLOCK_DELAY", 60),
+		RequestTimeout:         getEnvAsInt("REQUEST_TIMEOUT", 3),
+		SessionIdleTimeout:     getEnvAsInt("SESSION_IDLE_TIMEOUT", 1440),
+		SessionAbsoluteTimeout: getEnvAsInt("SESSION_ABSOLUTE_TIMEOUT", 720),
+		RefreshTokenTimeout:    getEnvAsInt("REFRESH_TOKEN_TIMEOUT", 168),
+		TOTPIssuer:             getEnv("TOTP_ISSUER", "Event Tracker"),
+		LoginChallengeTimeout:  getEnvAsInt("LOGIN_CHALLENGE_TIMEOUT", 5),
+		Mailer: mailer.Config{
+			Driver:   getEnv("MAILER", mailer.DriverLog),
+			Host:     getEnv("SMTP_HOST", "localhost"),
+			Port:     getEnvAsInt("SMTP_PORT", 587),
+			User:     getEnv("SMTP_USER", ""),
+			Password: getEnv("SMTP_PWD", ""),
+			From:     getEnv("SMTP_FROM", "noreply@localhost"),
+			LogFile:  getEnv("MAIL_LOG_FILE", ""),
+		},
+		AppURL:                 getEnv("APP_URL", "http://localhost:8080"),
+		TokenSecret:            getEnv("TOKEN_SECRET", ""),
+		EmailVerifyTimeout:     getEnvAsInt("EMAIL_VERIFY_TIMEOUT", 48),
+		PwdResetTimeout:        getEnvAsInt("PWD_RESET_TIMEOUT", 60),
+		RateLimitStore:         getEnv("RATE_LIMIT_STORE", limiter.StoreMemory),
+		RateLimitWindow:        getEnvAsInt("RATE_LIMIT_WINDOW", 60),
+		RateLimitRegister:      getEnvAsInt("RATE_LIMIT_REGISTER", 5),
+		RateLimitLogin:         getEnvAsInt("RATE_LIMIT_LOGIN", 20),
+		RateLimitLoginUsername: getEnvAsInt("RATE_LIMIT_LOGIN_USERNAME", 5),
+		RateLimitAPI:           getEnvAsInt("RATE_LIMIT_API", 300),
+		PwdPolicy: pwdpolicy.Config{
+			MinLength:      getEnvAsInt("PWD_MIN_LENGTH", 8),
+			RequireLower:   getEnvAsBool("PWD_REQUIRE_LOWER", false),
+			RequireUpper:   getEnvAsBool("PWD_REQUIRE_UPPER", false),
+			RequireDigit:   getEnvAsBool("PWD_REQUIRE_DIGIT", false),
+			RequireSymbol:  getEnvAsBool("PWD_REQUIRE_SYMBOL", false),
+			ForbidUsername: getEnvAsBool("PWD_FORBID_USERNAME", true),
+			ForbidCommon:   getEnvAsBool("PWD_FORBID_COMMON", true),
+			BreachAPI:      getEnv("PWD_BREACH_API", ""),
+		},
+		AccountDeletionGrace: getEnvAsInt("ACCOUNT_DELETION_GRACE", 30),
+		AccountPurgeInterval: getEnvAsInt("ACCOUNT_PURGE_INTERVAL", 60),
+		ExportTimeout:        getEnvAsInt("EXPORT_TIMEOUT", 24),
+		ExportPurgeInterval:  getEnvAsInt("EXPORT_PURGE_INTERVAL", 60),
+		AdminUsername:        getEnv("ADMIN_USERNAME", ""),
+		AuthCookie:           getEnvAsBool("AUTH_COOKIE", false),
+		CookieDomain:         getEnv("COOKIE_DOMAIN", ""),
+		CookieSecure:         getEnvAsBool("COOKIE_SECURE", true),
+		CookieSameSite:       getEnv("COOKIE_SAMESITE", "lax"),
+		CorsOrigins:          getEnvAsSlice("CORS_ORIGINS", []string{"*"}),
+		CorsCredentials:      getEnvAsBool("CORS_CREDENTIALS", false),
+		OIDC: oidc.Config{
+			Issuer:       getEnv("OIDC_ISSUER", ""),
+			ClientID:     getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/oidc/callback"),
+			Scopes:       getEnvAsSlice("OIDC_SCOPES", []string{"openid", "profile", "email"}),
+		},
+		OIDCAutoProvision: getEnvAsBool("OIDC_AUTO_PROVISION", true),
+		OIDCStateTimeout:  getEnvAsInt("OIDC_STATE_TIMEOUT", 10),
+		AuthJWT:           getEnvAsBool("AUTH_JWT", false),
+		JWT: jwt.Config{
+			Algorithm: getEnv("JWT_ALGORITHM", jwt.AlgorithmHS256),
+			Keys:      getEnvAsSlice("JWT_KEYS", nil),
+		},
+		JWTTimeout:         getEnvAsInt("JWT_TIMEOUT", 15),
+		JWTRevocationSync:  getEnvAsInt("JWT_REVOCATION_SYNC", 10),
+		TrashRetention:     getEnvAsInt("TRASH_RETENTION", 30),
+		TrashPurgeInterval: getEnvAsInt("TRASH_PURGE_INTERVAL", 60),
 	}
+
+	if cfg.TokenSecret == "" {
+		logger.Warn.Println("TOKEN_SECRET is not set, links sent by email will stop working after restart")
+		secret, err := utils.GenerateSessionKey()
+		if err != nil {
+			logger.Error.Printf("failed to generate token secret: %s", err)
+		}
+		cfg.TokenSecret = secret
+	}
+
+	return cfg
 }
 
 func getEnv(key string, defaultValue string) string {
@@ -53,3 +175,24 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+func getEnvAsBool(key string, defaultValue bool) bool {
+	value := getEnv(key, "")
+	if v, e := strconv.ParseBool(value); e == nil {
+		return v
+	}
+	return defaultValue
+}
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	var res []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
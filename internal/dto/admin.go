@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+type ListUsersDTO struct {
+	// Part of the username or displayed name
+	Query string `json:"query" validate:"max=100"`
+	Role  string `json:"role" validate:"omitempty,oneof=user moderator admin"`
+	Limit int32  `json:"limit" validate:"gt=0,lte=100"`
+	Page  int32  `json:"page" validate:"gt=0"`
+}
+
+type UpdateRoleDTO struct {
+	Role string `json:"role" validate:"required,oneof=user moderator admin"`
+}
+
+type AdminUserResponseDTO struct {
+	*entity.User
+	FailedAttempts int32 `json:"failedAttempts"`
+	// The password is locked after too many failed attempts until this time
+	BlockedUntil   *time.Time `json:"blockedUntil"`
+	ActiveSessions int32      `json:"activeSessions"`
+	AccessTokens   int32      `json:"accessTokens"`
+	TwoFactor      bool       `json:"twoFactor"`
+}
+
+type StatsResponseDTO struct {
+	Users          int32 `json:"users"`
+	DisabledUsers  int32 `json:"disabledUsers"`
+	DeletedUsers   int32 `json:"deletedUsers"`
+	ActiveSessions int32 `json:"activeSessions"`
+	AccessTokens   int32 `json:"accessTokens"`
+	EventTypes     int32 `json:"eventTypes"`
+	Events         int32 `json:"events"`
+	Friendships    int32 `json:"friendships"`
+	// Number of successful logins during the last 24 hours
+	LoginsLastDay int32 `json:"loginsLastDay"`
+}
@@ -4,9 +4,27 @@ type RegisterDTO struct {
 	Username      string `json:"username" validate:"required"`
 	Password      string `json:"password" validate:"required"`
 	DisplayedName string `json:"displayedName" validate:"required"`
+	UserAgent     string `json:"-"`
+	IP            string `json:"-"`
 }
 
 type LoginDTO struct {
-	Username string `json:"username" validate:"required"`
-	Password string `json:"password" validate:"required"`
+	Username  string `json:"username" validate:"required"`
+	Password  string `json:"password" validate:"required"`
+	UseCookie bool   `json:"useCookie"`
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
+}
+
+type RefreshDTO struct {
+	// Taken from the cookie if omitted by a browser client
+	RefreshToken string `json:"refreshToken" validate:"required"`
+	UserAgent    string `json:"-"`
+	IP           string `json:"-"`
+}
+
+type CreateAccessTokenDTO struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=events:read events:write friends:read friends:write"`
+	ExpiresInDays *int32   `json:"expiresInDays" validate:"omitempty,gt=0"`
 }
@@ -0,0 +1,15 @@
+package dto
+
+type CreateAuthEventDTO struct {
+	UserID    int32
+	Type      string
+	Success   bool
+	Reason    *string
+	IP        string
+	UserAgent string
+}
+
+type ListAuthEventDTO struct {
+	Limit int32 `json:"limit" validate:"gt=0,lte=100"`
+	Page  int32 `json:"page" validate:"gt=0"`
+}
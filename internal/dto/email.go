@@ -0,0 +1,16 @@
+package dto
+
+type VerifyEmailDTO struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordDTO struct {
+	Username string `json:"username" validate:"required"`
+}
+
+type ResetPasswordDTO struct {
+	Token     string `json:"token" validate:"required"`
+	Password  string `json:"password" validate:"required"`
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
+}
@@ -1,35 +1,150 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
 
 type CreateEventTypeDTO struct {
-	Name      string `json:"name" validate:"required"`
-	IsVisible bool   `json:"isVisible"`
+	Name       string                `json:"name" validate:"required"`
+	IsVisible  bool                  `json:"isVisible"`
+	Unit       string                `json:"unit" validate:"max=20"`
+	ShareNotes bool                  `json:"shareNotes"`
+	Fields     []entity.EventField   `json:"fields"`
+	Schedule   *entity.EventSchedule `json:"schedule"`
 }
 
 type EditEventTypeDTO struct {
-	ID        int32  `json:"-" validate:"required,gt=0"`
-	Name      string `json:"name" validate:"required"`
-	IsVisible bool   `json:"isVisible"`
+	ID         int32  `json:"-" validate:"required,gt=0"`
+	Name       string `json:"name" validate:"required"`
+	IsVisible  bool   `json:"isVisible"`
+	Unit       string `json:"unit" validate:"max=20"`
+	ShareNotes bool   `json:"shareNotes"`
+	// Without fields the schema is left as is, an empty list removes all fields
+	Fields   []entity.EventField   `json:"fields"`
+	Schedule *entity.EventSchedule `json:"schedule"`
+}
+
+// Modes of deleting an event type
+const (
+	// DeleteTypeArchive hides the type but keeps its events
+	DeleteTypeArchive = "archive"
+	// DeleteTypeDelete deletes the type together with its events
+	DeleteTypeDelete = "delete"
+	// DeleteTypeReassign moves the events to another type and deletes the type
+	DeleteTypeReassign = "reassign"
+)
+
+type ListEventTypeDTO struct {
+	Archived bool `json:"archived"`
+}
+type DeleteEventTypeDTO struct {
+	ID           int32  `json:"-" validate:"required,gt=0"`
+	Mode         string `json:"mode" validate:"required,oneof=archive delete reassign"`
+	TargetTypeID *int32 `json:"targetTypeId" validate:"omitempty,gt=0"`
 }
 
 type CreateEventDTO struct {
-	EventTypeID int32     `json:"eventTypeId" validate:"required,gt=0"`
-	Date        time.Time `json:"date" validate:"required"`
+	EventTypeID int32                  `json:"eventTypeId" validate:"required,gt=0"`
+	Date        time.Time              `json:"date" validate:"required"`
+	EndedAt     *time.Time             `json:"endedAt"`
+	Value       *float64               `json:"value"`
+	Note        string                 `json:"note" validate:"max=4000"`
+	Fields      map[string]interface{} `json:"fields"`
+}
+type UpdateEventDTO struct {
+	ID          int32                  `json:"-" validate:"required,gt=0"`
+	EventTypeID *int32                 `json:"eventTypeId" validate:"omitempty,gt=0"`
+	Date        *time.Time             `json:"date"`
+	EndedAt     *time.Time             `json:"endedAt"`
+	Value       *float64               `json:"value"`
+	Note        *string                `json:"note" validate:"omitempty,max=4000"`
+	Fields      map[string]interface{} `json:"fields"`
+	// A missing value or note clears it, set for PUT
+	Replace bool `json:"-"`
+}
+type StartTimerDTO struct {
+	EventTypeID int32                  `json:"eventTypeId" validate:"required,gt=0"`
+	Value       *float64               `json:"value"`
+	Note        string                 `json:"note" validate:"max=4000"`
+	Fields      map[string]interface{} `json:"fields"`
 }
 type ListEventDTO struct {
-	UserID     *int32     `json:"userId" validate:"omitempty,gt=0"`
+	UserID     *int32            `json:"userId" validate:"omitempty,gt=0"`
+	TypeID     *int32            `json:"typeId" validate:"omitempty,gt=0"`
+	PeriodType PeriodType        `json:"periodType" validate:"required,gt=0,lt=4"`
+	Date       time.Time         `json:"date" validate:"required"`
+	MinValue   *float64          `json:"minValue"`
+	MaxValue   *float64          `json:"maxValue"`
+	Fields     []*FieldFilterDTO `json:"fields" validate:"max=10,dive"`
+}
+
+// Operators of the filters by custom fields
+const (
+	FieldOpEq  = "eq"
+	FieldOpNe  = "ne"
+	FieldOpGt  = "gt"
+	FieldOpGte = "gte"
+	FieldOpLt  = "lt"
+	FieldOpLte = "lte"
+)
+
+// FieldFilterDTO compares a custom field of the events with the value. Numbers are compared with
+// number and duration fields (in seconds), strings with text and enum fields and booleans with boolean fields,
+// strings and booleans only with eq and ne.
+type FieldFilterDTO struct {
+	Key   string      `json:"key" validate:"required"`
+	Op    string      `json:"op" validate:"required,oneof=eq ne gt gte lt lte"`
+	Value interface{} `json:"value"`
+}
+
+// EventStatsResponseDTO is a summary of events of one type for one day in the time zone of the user.
+// Count includes all events, the other fields are calculated only from the events with a value.
+type EventStatsResponseDTO struct {
+	Date        time.Time `json:"date"`
+	EventTypeID int32     `json:"eventTypeId"`
+	Count       int32     `json:"count"`
+	ValueCount  int32     `json:"valueCount"`
+	Sum         *float64  `json:"sum"`
+	Avg         *float64  `json:"avg"`
+	Min         *float64  `json:"min"`
+	Max         *float64  `json:"max"`
+}
+
+type ListExpectedDTO struct {
 	TypeID     *int32     `json:"typeId" validate:"omitempty,gt=0"`
 	PeriodType PeriodType `json:"periodType" validate:"required,gt=0,lt=4"`
 	Date       time.Time  `json:"date" validate:"required"`
 }
 
+// ExpectedResponseDTO compares the expected and the actual events of a type with a schedule for one day.
+// A day is missed if an event was expected, the day has passed and there are no events.
+type ExpectedResponseDTO struct {
+	Date        string `json:"date"`
+	EventTypeID int32  `json:"eventTypeId"`
+	Expected    bool   `json:"expected"`
+	Count       int32  `json:"count"`
+	Missed      bool   `json:"missed"`
+}
+
+// EventDurationResponseDTO is the total length of the events of one type in the period. Only the part
+// inside the period is counted, running timers are counted up to now.
+type EventDurationResponseDTO struct {
+	EventTypeID int32   `json:"eventTypeId"`
+	Count       int32   `json:"count"`
+	Seconds     float64 `json:"seconds"`
+}
+
+// FeedResponseDTO is an event of a friend, the day is calculated in the time zone of the current user
 type FeedResponseDTO struct {
 	EventID     int32     `json:"eventId"`
 	UserID      int32     `json:"userId"`
 	EventTypeID int32     `json:"eventTypeId"`
 	EventType   string    `json:"eventType"`
 	Date        time.Time `json:"date"`
+	Day         string    `json:"day"`
+	Note        string    `json:"note,omitempty"`
 	CreatedAt   time.Time `json:"createdAt"`
 }
 
@@ -45,10 +160,43 @@ const (
 	PeriodYear             = 3
 )
 
+// Bounds returns the start of the period and the start of the next one in the location.
+// The day is taken from the date as it was written, so 2023-02-10T00:00:00Z is always the 10th.
+func (p PeriodType) Bounds(date time.Time, loc *time.Location) (time.Time, time.Time) {
+	switch p {
+	case PeriodMonth:
+		first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, loc)
+		return first, first.AddDate(0, 1, 0)
+	case PeriodYear:
+		first := time.Date(date.Year(), 1, 1, 0, 0, 0, 0, loc)
+		return first, first.AddDate(1, 0, 0)
+	}
+	first := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	return first, first.AddDate(0, 0, 1)
+}
+
+type ListEventTypeFilter struct {
+	UserID      int32
+	OnlyVisible bool
+	// Nil for all types, true for only archived ones
+	Archived *bool
+}
+
 type ListEventFilter struct {
 	UserID      int32
 	TypeID      *int32
 	OnlyVisible bool
 	PeriodType  PeriodType
 	Date        time.Time
+	MinValue    *float64
+	MaxValue    *float64
+	Fields      []*FieldFilterDTO
+	Location    *time.Location
+	// The user who requests the events of another user, only friends see them and notes are hidden
+	// unless the type shares them
+	ViewerID *int32
+	// Events with a length that overlap the interval, without OverlapTo the interval has no end
+	OverlapFrom *time.Time
+	OverlapTo   *time.Time
+	OnlyRunning bool
 }
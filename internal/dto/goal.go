@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+type CreateGoalDTO struct {
+	EventTypeID int32   `json:"eventTypeId" validate:"required,gt=0"`
+	Period      string  `json:"period" validate:"required,oneof=day week month year"`
+	Metric      string  `json:"metric" validate:"required,oneof=count sum"`
+	Comparison  string  `json:"comparison" validate:"required,oneof=at_least at_most"`
+	Target      float64 `json:"target" validate:"gte=0"`
+}
+type UpdateGoalDTO struct {
+	ID          int32   `json:"-" validate:"required,gt=0"`
+	EventTypeID int32   `json:"eventTypeId" validate:"required,gt=0"`
+	Period      string  `json:"period" validate:"required,oneof=day week month year"`
+	Metric      string  `json:"metric" validate:"required,oneof=count sum"`
+	Comparison  string  `json:"comparison" validate:"required,oneof=at_least at_most"`
+	Target      float64 `json:"target" validate:"gte=0"`
+}
+type GetGoalDTO struct {
+	ID      int32 `json:"-" validate:"required,gt=0"`
+	Periods int32 `json:"-" validate:"gte=0,lte=100"`
+}
+
+// GoalPeriodDTO is the progress of a goal in one period. Percent is the share of the target reached,
+// for at_most goals it shows how much of the limit is used and can be above 100.
+type GoalPeriodDTO struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Value   float64   `json:"value"`
+	Percent float64   `json:"percent"`
+	Status  string    `json:"status"`
+}
+
+// GoalStatusDTO is the goal with its progress in the current period. History has the past periods
+// since the goal was created, the oldest first, and the completion rate is the percent of them completed.
+type GoalStatusDTO struct {
+	Goal           *entity.Goal     `json:"goal"`
+	Current        *GoalPeriodDTO   `json:"current"`
+	History        []*GoalPeriodDTO `json:"history,omitempty"`
+	CompletionRate *float64         `json:"completionRate,omitempty"`
+}
+
+/*
+ * internal
+ */
+
+// GoalTotalDTO is the number and the sum of values of the events in the period starting on the day
+type GoalTotalDTO struct {
+	Day   string
+	Count int32
+	Sum   float64
+}
@@ -0,0 +1,17 @@
+package dto
+
+type OIDCAuthorizeDTO struct {
+	UseCookie bool `json:"useCookie"`
+}
+
+type OIDCAuthorizeResponseDTO struct {
+	// Address of the provider login page, the user has to be redirected there
+	URL string `json:"url"`
+}
+
+type OIDCCallbackDTO struct {
+	Code      string `json:"code" validate:"required"`
+	State     string `json:"state" validate:"required"`
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+type SessionResponseDTO struct {
+	ID         int32     `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -0,0 +1,25 @@
+package dto
+
+type StreakFreezeDTO struct {
+	EventTypeID int32  `json:"-" validate:"required,gt=0"`
+	Date        string `json:"date" validate:"required,datetime=2006-01-02"`
+}
+
+// StreakRunDTO is a run of days on which events were expected and happened. Frozen is the number
+// of frozen days inside the run, they keep the run going but aren't counted in the length.
+type StreakRunDTO struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Length int32  `json:"length"`
+	Frozen int32  `json:"frozen"`
+}
+
+// StreakResponseDTO describes the streaks of a type. The current streak is the last run if no expected
+// day was missed after it, today doesn't break the streak until it is over.
+type StreakResponseDTO struct {
+	EventTypeID int32           `json:"eventTypeId"`
+	Current     int32           `json:"current"`
+	Longest     int32           `json:"longest"`
+	Runs        []*StreakRunDTO `json:"runs"`
+	Freezes     []string        `json:"freezes"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+// Kinds of items in the trash
+const (
+	TrashKindEvents = "events"
+	TrashKindTypes  = "types"
+)
+
+type ListTrashDTO struct {
+	Kind  string `json:"kind" validate:"omitempty,oneof=events types"`
+	Limit int32  `json:"limit" validate:"gt=0,lte=100"`
+	Page  int32  `json:"page" validate:"gt=0"`
+}
+type TrashItemDTO struct {
+	Kind string `json:"kind" validate:"required,oneof=events types"`
+	ID   int32  `json:"id" validate:"required,gt=0"`
+}
+
+type TrashItemResponseDTO struct {
+	Kind string `json:"kind"`
+	ID   int32  `json:"id"`
+	// Name of the type, for events it's the name of the type of the event
+	Name        string     `json:"name"`
+	EventTypeID *int32     `json:"eventTypeId,omitempty"`
+	Date        *time.Time `json:"date,omitempty"`
+	DeletedAt   time.Time  `json:"deletedAt"`
+	// After this time the item is deleted permanently
+	PurgeAt time.Time `json:"purgeAt"`
+}
@@ -0,0 +1,40 @@
+package dto
+
+import "time"
+
+type TwoFactorStatusResponseDTO struct {
+	Enabled bool `json:"enabled"`
+}
+
+type TwoFactorEnrollResponseDTO struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type TwoFactorConfirmDTO struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type TwoFactorConfirmResponseDTO struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type TwoFactorDisableDTO struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type LoginChallengeResponseDTO struct {
+	TwoFactorRequired bool      `json:"twoFactorRequired"`
+	Challenge         string    `json:"challenge"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+}
+
+type LoginTwoFactorDTO struct {
+	Challenge    string `json:"challenge" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recoveryCode" validate:"required_without=Code"`
+	UseCookie    bool   `json:"useCookie"`
+	UserAgent    string `json:"-"`
+	IP           string `json:"-"`
+}
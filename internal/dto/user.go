@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type GetUserDTO struct {
 	ID int32 `json:"id" validate:"gt=0"`
 }
@@ -7,12 +9,27 @@ type GetUserDTO struct {
 type UpdatePasswordDTO struct {
 	OldPassword string `json:"oldPassword" validate:"required"`
 	NewPassword string `json:"newPassword" validate:"required,nefield=OldPassword"`
+	UserAgent   string `json:"-"`
+	IP          string `json:"-"`
 }
 
 type UpdateProfileDTO struct {
 	ID            int32   `json:"-" validate:"gt=0"`
 	DisplayedName string  `json:"displayedName" validate:"required"`
 	Email         *string `json:"email" validate:"omitempty,email"`
+	// IANA time zone, e.g. Europe/Berlin, the current one is kept if it is missing
+	TimeZone *string `json:"timeZone" validate:"omitempty,max=64"`
+}
+
+type DeleteAccountDTO struct {
+	Password  string `json:"password" validate:"required"`
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
+}
+
+type DeleteAccountResponseDTO struct {
+	// The account can be restored by logging in until this time
+	PurgeAt time.Time `json:"purgeAt"`
 }
 
 type UpdateProfileImageDTO struct {
@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+const (
+	// AccessTokenPrefix allows to tell personal access tokens apart from session keys
+	AccessTokenPrefix = "etpat_"
+
+	ScopeEventsRead   = "events:read"
+	ScopeEventsWrite  = "events:write"
+	ScopeFriendsRead  = "friends:read"
+	ScopeFriendsWrite = "friends:write"
+)
+
+type AccessToken struct {
+	ID         int32      `json:"id"`
+	UserID     int32      `json:"userId"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"`
+	TokenHash  string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	DeletedAt  *time.Time `json:"deletedAt"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+const (
+	AuthEventRegister       = "register"
+	AuthEventLogin          = "login"
+	AuthEventTwoFactor      = "two_factor"
+	AuthEventLockout        = "lockout"
+	AuthEventLogout         = "logout"
+	AuthEventSessionRevoke  = "session_revoke"
+	AuthEventRefreshReuse   = "refresh_reuse"
+	AuthEventPasswordChange = "password_change"
+	AuthEventPasswordReset  = "password_reset"
+	AuthEventAccountDelete  = "account_delete"
+	AuthEventAccountRestore = "account_restore"
+	AuthEventAccountDisable = "account_disable"
+	AuthEventAccountEnable  = "account_enable"
+	AuthEventUnblock        = "unblock"
+	AuthEventRoleChange     = "role_change"
+	AuthEventIdentityLink   = "identity_link"
+	AuthEventIdentityUnlink = "identity_unlink"
+)
+
+type AuthEvent struct {
+	ID        int32     `json:"id"`
+	UserID    int32     `json:"userId"`
+	Type      string    `json:"type"`
+	Success   bool      `json:"success"`
+	Reason    *string   `json:"reason"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -3,11 +3,16 @@ package entity
 import "time"
 
 type Event struct {
-	ID        int32      `json:"id"`
-	UserID    int32      `json:"userId"`
-	TypeID    int32      `json:"eventTypeId"`
-	Date      time.Time  `json:"date"`
-	CreatedAt time.Time  `json:"createdAt"`
-	UpdatedAt time.Time  `json:"updatedAt"`
-	DeletedAt *time.Time `json:"deletedAt"`
+	ID        int32                  `json:"id"`
+	UserID    int32                  `json:"userId"`
+	TypeID    int32                  `json:"eventTypeId"`
+	Date      time.Time              `json:"date"`
+	EndedAt   *time.Time             `json:"endedAt"`
+	Running   bool                   `json:"running"`
+	Value     *float64               `json:"value"`
+	Note      string                 `json:"note"`
+	Fields    map[string]interface{} `json:"fields"`
+	CreatedAt time.Time              `json:"createdAt"`
+	UpdatedAt time.Time              `json:"updatedAt"`
+	DeletedAt *time.Time             `json:"deletedAt"`
 }
@@ -0,0 +1,22 @@
+package entity
+
+// Types of custom fields of events
+const (
+	EventFieldNumber = "number"
+	EventFieldText   = "text"
+	EventFieldEnum   = "enum"
+	EventFieldBool   = "boolean"
+	// Duration is stored as a number of seconds
+	EventFieldDuration = "duration"
+)
+
+// EventField describes one custom field of the events of a type
+type EventField struct {
+	// Name of the value in the fields of the event, can't be changed
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	// Allowed values of an enum field
+	Options []string `json:"options,omitempty"`
+}
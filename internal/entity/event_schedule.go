@@ -0,0 +1,20 @@
+package entity
+
+// Kinds of schedules of event types
+const (
+	ScheduleDaily    = "daily"
+	ScheduleWeekdays = "weekdays"
+	// ScheduleInterval expects the event every N days from the start
+	ScheduleInterval = "interval"
+	// ScheduleRRule takes a subset of the iCalendar RRULE, see the recurrence package
+	ScheduleRRule = "rrule"
+)
+
+// EventSchedule describes the days on which events of a type are expected
+type EventSchedule struct {
+	Kind string `json:"kind"`
+	// First day of the schedule as YYYY-MM-DD
+	Start    string `json:"start"`
+	Interval int    `json:"interval,omitempty"`
+	RRule    string `json:"rrule,omitempty"`
+}
@@ -3,11 +3,16 @@ package entity
 import "time"
 
 type EventType struct {
-	ID        int32      `json:"id"`
-	UserID    int32      `json:"userId"`
-	EventType string     `json:"eventType"`
-	IsVisible bool       `json:"isVisible"`
-	CreatedAt time.Time  `json:"createdAt"`
-	UpdatedAt time.Time  `json:"updatedAt"`
-	DeletedAt *time.Time `json:"deletedAt"`
+	ID         int32          `json:"id"`
+	UserID     int32          `json:"userId"`
+	EventType  string         `json:"eventType"`
+	IsVisible  bool           `json:"isVisible"`
+	Unit       string         `json:"unit"`
+	ShareNotes bool           `json:"shareNotes"`
+	Fields     []EventField   `json:"fields"`
+	Schedule   *EventSchedule `json:"schedule"`
+	ArchivedAt *time.Time     `json:"archivedAt,omitempty"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	UpdatedAt  time.Time      `json:"updatedAt"`
+	DeletedAt  *time.Time     `json:"deletedAt"`
 }
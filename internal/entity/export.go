@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+const (
+	ExportStatusPending = "pending"
+	ExportStatusReady   = "ready"
+	ExportStatusFailed  = "failed"
+)
+
+type Export struct {
+	ID          int32      `json:"id"`
+	UserID      int32      `json:"userId"`
+	Status      string     `json:"status"`
+	Archive     []byte     `json:"-"`
+	Size        *int64     `json:"size"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,42 @@
+package entity
+
+import "time"
+
+// Periods of goals
+const (
+	GoalPeriodDay   = "day"
+	GoalPeriodWeek  = "week"
+	GoalPeriodMonth = "month"
+	GoalPeriodYear  = "year"
+)
+
+// Metrics of goals, the number of events or the sum of their values
+const (
+	GoalMetricCount = "count"
+	GoalMetricSum   = "sum"
+)
+
+// Comparisons of the metric with the target
+const (
+	GoalAtLeast = "at_least"
+	GoalAtMost  = "at_most"
+)
+
+// Statuses of a goal in a period
+const (
+	GoalStatusInProgress = "in_progress"
+	GoalStatusCompleted  = "completed"
+	GoalStatusFailed     = "failed"
+)
+
+type Goal struct {
+	ID          int32     `json:"id"`
+	UserID      int32     `json:"userId"`
+	EventTypeID int32     `json:"eventTypeId"`
+	Period      string    `json:"period"`
+	Metric      string    `json:"metric"`
+	Comparison  string    `json:"comparison"`
+	Target      float64   `json:"target"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
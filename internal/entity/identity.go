@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+type Identity struct {
+	ID        int32     `json:"id"`
+	UserID    int32     `json:"userId"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     *string   `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// OIDCState keeps the secrets of the authorization request until the user comes back from the provider,
+// UserID is set when a logged-in user links the identity to the account
+type OIDCState struct {
+	ID           int32      `json:"id"`
+	StateHash    string     `json:"stateHash"`
+	Nonce        string     `json:"-"`
+	CodeVerifier string     `json:"-"`
+	UserID       *int32     `json:"userId"`
+	UseCookie    bool       `json:"useCookie"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	DeletedAt    *time.Time `json:"deletedAt"`
+}
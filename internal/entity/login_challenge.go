@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type LoginChallenge struct {
+	ID             int32      `json:"id"`
+	UserID         int32      `json:"userId"`
+	ChallengeHash  string     `json:"challengeHash"`
+	FailedAttempts int32      `json:"failedAttempts"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	DeletedAt      *time.Time `json:"deletedAt"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type RefreshToken struct {
+	ID        int32      `json:"id"`
+	SessionID int32      `json:"sessionId"`
+	TokenHash string     `json:"tokenHash"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt"`
+}
@@ -3,11 +3,17 @@ package entity
 import "time"
 
 type Session struct {
-	ID          int32      `json:"id"`
-	UserID      int32      `json:"userId"`
-	Session     string     `json:"session,omitempty"`
-	SessionHash string     `json:"sessionHash"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
-	DeletedAt   *time.Time `json:"deletedAt"`
+	ID           int32      `json:"id"`
+	UserID       int32      `json:"userId"`
+	Session      string     `json:"session,omitempty"`
+	RefreshToken string     `json:"refreshToken,omitempty"`
+	AccessToken  string     `json:"accessToken,omitempty"`
+	CSRFToken    string     `json:"csrfToken,omitempty"`
+	SessionHash  string     `json:"sessionHash"`
+	UserAgent    string     `json:"userAgent"`
+	IP           string     `json:"ip"`
+	LastSeenAt   time.Time  `json:"lastSeenAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	DeletedAt    *time.Time `json:"deletedAt"`
 }
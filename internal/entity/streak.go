@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// StreakFreeze is a day on which a missed event doesn't break the streak of the type
+type StreakFreeze struct {
+	ID          int32     `json:"id"`
+	UserID      int32     `json:"userId"`
+	EventTypeID int32     `json:"eventTypeId"`
+	Date        string    `json:"date"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type TOTP struct {
+	ID           int32      `json:"id"`
+	UserID       int32      `json:"userId"`
+	Secret       string     `json:"-"`
+	LastUsedStep int64      `json:"lastUsedStep"`
+	EnabledAt    *time.Time `json:"enabledAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	DeletedAt    *time.Time `json:"deletedAt"`
+}
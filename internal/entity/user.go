@@ -2,13 +2,23 @@ package entity
 
 import "time"
 
+const (
+	UserRoleUser      = "user"
+	UserRoleModerator = "moderator"
+	UserRoleAdmin     = "admin"
+)
+
 type User struct {
-	ID            int32      `json:"id"`
-	Username      string     `json:"username"`
-	DisplayedName string     `json:"displayedName"`
-	Email         *string    `json:"email"`
-	ProfileImage  *string    `json:"profileImage"`
-	CreatedAt     time.Time  `json:"createdAt"`
-	UpdatedAt     time.Time  `json:"updatedAt"`
-	DeletedAt     *time.Time `json:"deletedAt"`
+	ID              int32      `json:"id"`
+	Username        string     `json:"username"`
+	DisplayedName   string     `json:"displayedName"`
+	Email           *string    `json:"email"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	ProfileImage    *string    `json:"profileImage"`
+	Role            string     `json:"role,omitempty"`
+	TimeZone        string     `json:"timeZone,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	DeletedAt       *time.Time `json:"deletedAt"`
+	DisabledAt      *time.Time `json:"disabledAt,omitempty"`
 }
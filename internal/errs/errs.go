@@ -1,6 +1,7 @@
 package errs
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -12,12 +13,14 @@ var (
 	BadRequest     = NewError("bad request", http.StatusBadRequest)
 	UserBlocked    = NewError("user is blocked", http.StatusUnauthorized)
 	SessionInvalid = NewError("session invalid", http.StatusUnauthorized)
+	Forbidden      = NewError("forbidden", http.StatusForbidden)
 )
 
 type Err struct {
-	Message string `json:"message"`
-	Code    int    `json:"code"`
-	Err     error  `json:"err"`
+	Message string   `json:"message"`
+	Code    int      `json:"code"`
+	Details []string `json:"details,omitempty"`
+	Err     error    `json:"err"`
 }
 
 func NewError(message string, code ...int) *Err {
@@ -53,6 +56,15 @@ func (e *Err) AddMessage(message string) *Err {
 	}
 }
 
+func (e *Err) AddDetails(details ...string) *Err {
+	return &Err{
+		Message: e.Message,
+		Code:    e.Code,
+		Details: details,
+		Err:     e,
+	}
+}
+
 func (e *Err) GetCode() int       { return e.Code }
 func (e *Err) GetMessage() string { return e.Message }
 
@@ -66,5 +78,23 @@ func HttpError(w http.ResponseWriter, err error) {
 		http.Error(w, "Unknown error", http.StatusInternalServerError)
 		return
 	}
-	http.Error(w, val.Message, val.Code)
+	if len(val.Details) == 0 {
+		http.Error(w, val.Message, val.Code)
+		return
+	}
+
+	// Errors with details are returned as JSON, so the client can show each of them
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(val.Code)
+	err = json.NewEncoder(w).Encode(struct {
+		Message string   `json:"message"`
+		Details []string `json:"details"`
+	}{
+		Message: val.Message,
+		Details: val.Details,
+	})
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
 }
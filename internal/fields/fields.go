@@ -0,0 +1,215 @@
+// Package fields checks schemas of custom fields of event types and the values of events against them.
+//
+// Values are stored as they were written, so a schema can change without touching the old events:
+// fields can be added, removed, renamed or made required, and enum options can be changed. Only the type
+// of a key is fixed, otherwise old values would have a different type than new ones. A removed key can't be
+// added back while stored events have its values.
+package fields
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+const (
+	MaxFields      = 50
+	MaxOptions     = 50
+	MaxNameLength  = 100
+	MaxTextLength  = 1000
+	maxOptionChars = 100
+)
+
+var keyRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// CheckKey returns an error if the key can't be used as a field key
+func CheckKey(key string) error {
+	if !keyRegexp.MatchString(key) {
+		return fmt.Errorf("field key %q must start with a lowercase letter and contain only lowercase letters, digits and underscores", key)
+	}
+	return nil
+}
+
+// CheckSchema returns an error if the schema is malformed
+func CheckSchema(schema []entity.EventField) error {
+	if len(schema) > MaxFields {
+		return fmt.Errorf("a type can't have more than %d fields", MaxFields)
+	}
+
+	keys := make(map[string]struct{}, len(schema))
+	for _, field := range schema {
+		err := CheckKey(field.Key)
+		if err != nil {
+			return err
+		}
+		if _, ok := keys[field.Key]; ok {
+			return fmt.Errorf("field %q is duplicated", field.Key)
+		}
+		keys[field.Key] = struct{}{}
+
+		if field.Name == "" || len([]rune(field.Name)) > MaxNameLength {
+			return fmt.Errorf("field %q: name must be from 1 to %d characters long", field.Key, MaxNameLength)
+		}
+
+		switch field.Type {
+		case entity.EventFieldNumber, entity.EventFieldText, entity.EventFieldBool, entity.EventFieldDuration:
+			if len(field.Options) > 0 {
+				return fmt.Errorf("field %q: only enum fields have options", field.Key)
+			}
+		case entity.EventFieldEnum:
+			err = checkOptions(field)
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("field %q: unknown type %q", field.Key, field.Type)
+		}
+	}
+	return nil
+}
+
+// CheckEvolution returns an error if the new schema changes the type of an existing key or adds back
+// a removed key. Used are the keys which stored events have, the schema of their old values isn't known.
+func CheckEvolution(old, new []entity.EventField, used []string) error {
+	oldTypes := make(map[string]string, len(old))
+	for _, field := range old {
+		oldTypes[field.Key] = field.Type
+	}
+	usedKeys := make(map[string]struct{}, len(used))
+	for _, key := range used {
+		usedKeys[key] = struct{}{}
+	}
+	for _, field := range new {
+		oldType, ok := oldTypes[field.Key]
+		if ok && oldType != field.Type {
+			return fmt.Errorf("field %q: type can't be changed from %s to %s, add a field with another key", field.Key,
+				oldType, field.Type)
+		}
+		if _, inUse := usedKeys[field.Key]; !ok && inUse {
+			return fmt.Errorf("field %q was removed, but events still have its values, add a field with another key",
+				field.Key)
+		}
+	}
+	return nil
+}
+
+// AddedKeys returns the keys of the new schema which the old one doesn't have
+func AddedKeys(old, new []entity.EventField) []string {
+	oldKeys := make(map[string]struct{}, len(old))
+	for _, field := range old {
+		oldKeys[field.Key] = struct{}{}
+	}
+	var res []string
+	for _, field := range new {
+		if _, ok := oldKeys[field.Key]; !ok {
+			res = append(res, field.Key)
+		}
+	}
+	return res
+}
+
+// Validate checks the values against the schema and returns them in the stored form
+func Validate(schema []entity.EventField, values map[string]interface{}) (map[string]interface{}, error) {
+	res := make(map[string]interface{}, len(values))
+
+	known := make(map[string]struct{}, len(schema))
+	for _, field := range schema {
+		known[field.Key] = struct{}{}
+
+		value, ok := values[field.Key]
+		if !ok || value == nil {
+			if field.Required {
+				return nil, fmt.Errorf("field %q is required", field.Key)
+			}
+			continue
+		}
+
+		value, err := validateValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+		res[field.Key] = value
+	}
+
+	for key := range values {
+		if _, ok := known[key]; !ok {
+			return nil, fmt.Errorf("field %q is not defined for the event type", key)
+		}
+	}
+	return res, nil
+}
+
+func checkOptions(field entity.EventField) error {
+	if len(field.Options) == 0 || len(field.Options) > MaxOptions {
+		return fmt.Errorf("field %q: enum must have from 1 to %d options", field.Key, MaxOptions)
+	}
+	options := make(map[string]struct{}, len(field.Options))
+	for _, option := range field.Options {
+		if option == "" || len([]rune(option)) > maxOptionChars {
+			return fmt.Errorf("field %q: option must be from 1 to %d characters long", field.Key, maxOptionChars)
+		}
+		if _, ok := options[option]; ok {
+			return fmt.Errorf("field %q: option %q is duplicated", field.Key, option)
+		}
+		options[option] = struct{}{}
+	}
+	return nil
+}
+func validateValue(field entity.EventField, value interface{}) (interface{}, error) {
+	switch field.Type {
+	case entity.EventFieldNumber:
+		number, ok := value.(float64)
+		if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fmt.Errorf("field %q must be a number", field.Key)
+		}
+		return number, nil
+	case entity.EventFieldText:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("field %q must be a string", field.Key)
+		}
+		if len([]rune(text)) > MaxTextLength {
+			return nil, fmt.Errorf("field %q must be at most %d characters long", field.Key, MaxTextLength)
+		}
+		return text, nil
+	case entity.EventFieldEnum:
+		option, ok := value.(string)
+		if ok {
+			for _, allowed := range field.Options {
+				if option == allowed {
+					return option, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("field %q must be one of %v", field.Key, field.Options)
+	case entity.EventFieldBool:
+		flag, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("field %q must be a boolean", field.Key)
+		}
+		return flag, nil
+	case entity.EventFieldDuration:
+		// Either seconds or a string like 1h30m
+		var seconds float64
+		switch v := value.(type) {
+		case float64:
+			seconds = v
+		case string:
+			duration, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("field %q must be a number of seconds or a duration like 1h30m", field.Key)
+			}
+			seconds = duration.Seconds()
+		default:
+			return nil, fmt.Errorf("field %q must be a number of seconds or a duration like 1h30m", field.Key)
+		}
+		if seconds < 0 || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+			return nil, fmt.Errorf("field %q must not be negative", field.Key)
+		}
+		return seconds, nil
+	}
+	return nil, fmt.Errorf("field %q: unknown type %q", field.Key, field.Type)
+}
@@ -0,0 +1,136 @@
+package fields
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  []entity.EventField
+		wantErr string
+	}{
+		{"empty", nil, ""},
+		{"valid", []entity.EventField{
+			{Key: "mood", Name: "Mood", Type: entity.EventFieldEnum, Options: []string{"good", "bad"}},
+			{Key: "distance_km", Name: "Distance", Type: entity.EventFieldNumber, Required: true},
+		}, ""},
+		{"bad key", []entity.EventField{{Key: "Mood", Name: "Mood", Type: entity.EventFieldText}}, "must start"},
+		{"duplicated key", []entity.EventField{
+			{Key: "a", Name: "A", Type: entity.EventFieldText},
+			{Key: "a", Name: "B", Type: entity.EventFieldText},
+		}, "duplicated"},
+		{"empty name", []entity.EventField{{Key: "a", Type: entity.EventFieldText}}, "name must be"},
+		{"unknown type", []entity.EventField{{Key: "a", Name: "A", Type: "date"}}, "unknown type"},
+		{"options of a text", []entity.EventField{
+			{Key: "a", Name: "A", Type: entity.EventFieldText, Options: []string{"x"}},
+		}, "only enum"},
+		{"enum without options", []entity.EventField{{Key: "a", Name: "A", Type: entity.EventFieldEnum}}, "enum must have"},
+		{"duplicated option", []entity.EventField{
+			{Key: "a", Name: "A", Type: entity.EventFieldEnum, Options: []string{"x", "x"}},
+		}, "duplicated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkError(t, CheckSchema(tt.schema), tt.wantErr)
+		})
+	}
+}
+func TestCheckEvolution(t *testing.T) {
+	old := []entity.EventField{
+		{Key: "x", Name: "X", Type: entity.EventFieldNumber},
+		{Key: "y", Name: "Y", Type: entity.EventFieldText},
+	}
+
+	tests := []struct {
+		name    string
+		new     []entity.EventField
+		used    []string
+		wantErr string
+	}{
+		{"same", old, nil, ""},
+		{"renamed and required", []entity.EventField{
+			{Key: "x", Name: "Distance", Type: entity.EventFieldNumber, Required: true},
+		}, nil, ""},
+		{"type changed", []entity.EventField{{Key: "x", Name: "X", Type: entity.EventFieldText}}, nil,
+			"type can't be changed"},
+		{"new key", []entity.EventField{{Key: "z", Name: "Z", Type: entity.EventFieldBool}}, nil, ""},
+		{"removed key added back with another type", []entity.EventField{
+			{Key: "z", Name: "Z", Type: entity.EventFieldText},
+		}, []string{"z"}, "was removed"},
+		{"existing key used by events", old, []string{"x", "y"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkError(t, CheckEvolution(old, tt.new, tt.used), tt.wantErr)
+		})
+	}
+}
+func TestAddedKeys(t *testing.T) {
+	old := []entity.EventField{{Key: "a"}, {Key: "b"}}
+	new := []entity.EventField{{Key: "b"}, {Key: "c"}, {Key: "d"}}
+	if got := AddedKeys(old, new); !reflect.DeepEqual(got, []string{"c", "d"}) {
+		t.Errorf("AddedKeys() = %v", got)
+	}
+}
+func TestValidate(t *testing.T) {
+	schema := []entity.EventField{
+		{Key: "distance", Name: "Distance", Type: entity.EventFieldNumber, Required: true},
+		{Key: "note", Name: "Note", Type: entity.EventFieldText},
+		{Key: "mood", Name: "Mood", Type: entity.EventFieldEnum, Options: []string{"good", "bad"}},
+		{Key: "done", Name: "Done", Type: entity.EventFieldBool},
+		{Key: "time", Name: "Time", Type: entity.EventFieldDuration},
+	}
+
+	tests := []struct {
+		name    string
+		values  map[string]interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{"all fields", map[string]interface{}{
+			"distance": 5.5, "note": "park", "mood": "good", "done": true, "time": "1h30m",
+		}, map[string]interface{}{
+			"distance": 5.5, "note": "park", "mood": "good", "done": true, "time": 5400.0,
+		}, ""},
+		{"duration in seconds", map[string]interface{}{"distance": 1.0, "time": 60.0},
+			map[string]interface{}{"distance": 1.0, "time": 60.0}, ""},
+		{"null optional", map[string]interface{}{"distance": 1.0, "note": nil},
+			map[string]interface{}{"distance": 1.0}, ""},
+		{"missing required", map[string]interface{}{"note": "park"}, nil, "is required"},
+		{"unknown key", map[string]interface{}{"distance": 1.0, "speed": 2.0}, nil, "not defined"},
+		{"string for number", map[string]interface{}{"distance": "5"}, nil, "must be a number"},
+		{"unknown option", map[string]interface{}{"distance": 1.0, "mood": "ok"}, nil, "must be one of"},
+		{"number for bool", map[string]interface{}{"distance": 1.0, "done": 1.0}, nil, "must be a boolean"},
+		{"negative duration", map[string]interface{}{"distance": 1.0, "time": -1.0}, nil, "must not be negative"},
+		{"bad duration", map[string]interface{}{"distance": 1.0, "time": "soon"}, nil, "duration like"},
+		{"long text", map[string]interface{}{"distance": 1.0, "note": strings.Repeat("a", MaxTextLength+1)}, nil,
+			"at most"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Validate(schema, tt.values)
+			checkError(t, err, tt.wantErr)
+			if tt.wantErr == "" && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func checkError(t *testing.T, err error, want string) {
+	t.Helper()
+	if want == "" {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v, want it to contain %q", err, want)
+	}
+}
@@ -0,0 +1,184 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Config struct {
+	Algorithm string
+	// Keys in the "kid:base64 key" format, the first one signs new tokens and all of them verify,
+	// so a new key can be put first while tokens signed with the old one are still alive.
+	// HS256 takes a secret of at least 32 bytes, EdDSA takes the 32 byte seed of an Ed25519 key.
+	Keys []string
+}
+
+type Claims struct {
+	// ID of the user
+	Subject int32 `json:"sub"`
+	// ID of the login session the token is issued for
+	SessionID int32 `json:"sid"`
+	// Empty for login sessions, they have full access
+	Scopes    []string `json:"scp,omitempty"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+type key struct {
+	secret     []byte
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// Signer issues and verifies JWT with a set of rotating keys
+type Signer struct {
+	algorithm string
+	signKid   string
+	keys      map[string]*key
+}
+
+func New(cfg Config) (*Signer, error) {
+	if cfg.Algorithm != AlgorithmHS256 && cfg.Algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unknown jwt algorithm: %q", cfg.Algorithm)
+	}
+	if len(cfg.Keys) == 0 {
+		return nil, fmt.Errorf("no jwt keys")
+	}
+
+	s := &Signer{
+		algorithm: cfg.Algorithm,
+		keys:      make(map[string]*key, len(cfg.Keys)),
+	}
+	for i, item := range cfg.Keys {
+		kid, value, ok := strings.Cut(item, ":")
+		if !ok || kid == "" {
+			return nil, fmt.Errorf("jwt key #%d: expected kid:key", i+1)
+		}
+		raw, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kid, err)
+		}
+
+		k := &key{}
+		switch cfg.Algorithm {
+		case AlgorithmHS256:
+			if len(raw) < 32 {
+				return nil, fmt.Errorf("jwt key %q: secret must be at least 32 bytes", kid)
+			}
+			k.secret = raw
+		case AlgorithmEdDSA:
+			if len(raw) != ed25519.SeedSize {
+				return nil, fmt.Errorf("jwt key %q: seed must be %d bytes", kid, ed25519.SeedSize)
+			}
+			k.privateKey = ed25519.NewKeyFromSeed(raw)
+			k.publicKey = k.privateKey.Public().(ed25519.PublicKey)
+		}
+		if _, ok := s.keys[kid]; ok {
+			return nil, fmt.Errorf("jwt key %q is duplicated", kid)
+		}
+		s.keys[kid] = k
+		if i == 0 {
+			s.signKid = kid
+		}
+	}
+	return s, nil
+}
+
+func (s *Signer) Sign(claims *Claims) (string, error) {
+	headerData, err := json.Marshal(header{
+		Alg: s.algorithm,
+		Typ: "JWT",
+		Kid: s.signKid,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal header: %w", err)
+	}
+	claimsData, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("marshal claims: %w", err)
+	}
+
+	input := base64.RawURLEncoding.EncodeToString(headerData) + "." + base64.RawURLEncoding.EncodeToString(claimsData)
+	signature := s.sign(s.keys[s.signKid], input)
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Parse verifies the signature and the expiration time of the token
+func (s *Signer) Parse(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	h := header{}
+	err := decodeSegment(parts[0], &h)
+	if err != nil || h.Alg != s.algorithm {
+		return nil, ErrInvalidToken
+	}
+	k, ok := s.keys[h.Kid]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !s.verify(k, parts[0]+"."+parts[1], signature) {
+		return nil, ErrInvalidToken
+	}
+
+	claims := &Claims{}
+	err = decodeSegment(parts[1], claims)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// Looks reports whether the bearer is a JWT and not a session key or an access token
+func Looks(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+func (s *Signer) sign(k *key, input string) []byte {
+	if s.algorithm == AlgorithmEdDSA {
+		return ed25519.Sign(k.privateKey, []byte(input))
+	}
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
+func (s *Signer) verify(k *key, input string, signature []byte) bool {
+	if s.algorithm == AlgorithmEdDSA {
+		return ed25519.Verify(k.publicKey, []byte(input), signature)
+	}
+	return hmac.Equal(signature, s.sign(k, input))
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
@@ -0,0 +1,130 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testKey(b byte, size int) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string([]byte{b}), size)))
+}
+func testClaims() *Claims {
+	now := time.Now()
+	return &Claims{
+		Subject:   1,
+		SessionID: 2,
+		Scopes:    []string{"events:read"},
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Minute).Unix(),
+	}
+}
+
+func TestSignParse(t *testing.T) {
+	for _, cfg := range []Config{
+		{Algorithm: AlgorithmHS256, Keys: []string{"k1:" + testKey('a', 32)}},
+		{Algorithm: AlgorithmEdDSA, Keys: []string{"k1:" + testKey('a', 32)}},
+	} {
+		t.Run(cfg.Algorithm, func(t *testing.T) {
+			signer, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := signer.Sign(testClaims())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Looks(token) {
+				t.Errorf("Looks(%q) = false", token)
+			}
+
+			claims, err := signer.Parse(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != 1 || claims.SessionID != 2 || len(claims.Scopes) != 1 {
+				t.Errorf("unexpected claims: %+v", claims)
+			}
+
+			// Any change of the token breaks the signature
+			parts := strings.Split(token, ".")
+			forged := *claims
+			forged.Subject = 100
+			other, _ := signer.Sign(&forged)
+			if _, err = signer.Parse(parts[0] + "." + strings.Split(other, ".")[1] + "." + parts[2]); err != ErrInvalidToken {
+				t.Errorf("forged claims: error = %v", err)
+			}
+		})
+	}
+}
+func TestParseExpired(t *testing.T) {
+	signer, err := New(Config{Algorithm: AlgorithmHS256, Keys: []string{"k1:" + testKey('a', 32)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := testClaims()
+	claims.ExpiresAt = time.Now().Add(-time.Second).Unix()
+	token, _ := signer.Sign(claims)
+	if _, err = signer.Parse(token); err != ErrInvalidToken {
+		t.Errorf("expired token: error = %v", err)
+	}
+}
+func TestKeyRotation(t *testing.T) {
+	oldKey := "old:" + testKey('a', 32)
+	newKey := "new:" + testKey('b', 32)
+
+	oldSigner, _ := New(Config{Algorithm: AlgorithmHS256, Keys: []string{oldKey}})
+	oldToken, _ := oldSigner.Sign(testClaims())
+
+	// The new key signs, the old one still verifies
+	rotated, err := New(Config{Algorithm: AlgorithmHS256, Keys: []string{newKey, oldKey}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = rotated.Parse(oldToken); err != nil {
+		t.Errorf("token of the old key: %v", err)
+	}
+	newToken, _ := rotated.Sign(testClaims())
+	if _, err = oldSigner.Parse(newToken); err != ErrInvalidToken {
+		t.Errorf("unknown kid: error = %v", err)
+	}
+
+	// The old key is removed
+	dropped, _ := New(Config{Algorithm: AlgorithmHS256, Keys: []string{newKey}})
+	if _, err = dropped.Parse(oldToken); err != ErrInvalidToken {
+		t.Errorf("token of the removed key: error = %v", err)
+	}
+	if _, err = dropped.Parse(newToken); err != nil {
+		t.Errorf("token of the new key: %v", err)
+	}
+}
+func TestAlgorithmMismatch(t *testing.T) {
+	hs, _ := New(Config{Algorithm: AlgorithmHS256, Keys: []string{"k1:" + testKey('a', 32)}})
+	ed, _ := New(Config{Algorithm: AlgorithmEdDSA, Keys: []string{"k1:" + testKey('a', 32)}})
+	token, _ := hs.Sign(testClaims())
+	if _, err := ed.Parse(token); err != ErrInvalidToken {
+		t.Errorf("error = %v", err)
+	}
+}
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"unknown algorithm", Config{Algorithm: "RS256", Keys: []string{"k1:" + testKey('a', 32)}}},
+		{"no keys", Config{Algorithm: AlgorithmHS256}},
+		{"no kid", Config{Algorithm: AlgorithmHS256, Keys: []string{testKey('a', 32)}}},
+		{"bad base64", Config{Algorithm: AlgorithmHS256, Keys: []string{"k1:%%%"}}},
+		{"short secret", Config{Algorithm: AlgorithmHS256, Keys: []string{"k1:" + testKey('a', 16)}}},
+		{"bad seed", Config{Algorithm: AlgorithmEdDSA, Keys: []string{"k1:" + testKey('a', 31)}}},
+		{"duplicated kid", Config{Algorithm: AlgorithmHS256, Keys: []string{"k1:" + testKey('a', 32), "k1:" + testKey('b', 32)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+// RevocationList keeps the sessions closed while tokens issued for them can still be alive.
+// It's filled from the database periodically, so logout on another instance is picked up as well.
+type RevocationList struct {
+	mu       sync.RWMutex
+	ttl      time.Duration
+	sessions map[int32]struct{}
+	// Sessions closed on this instance and the time they were added, a snapshot from the database
+	// can be taken before they are committed, so they are kept until tokens issued for them expire
+	added map[int32]time.Time
+	now   func() time.Time
+}
+
+// NewRevocationList creates a list for tokens which live for the ttl
+func NewRevocationList(ttl time.Duration) *RevocationList {
+	return &RevocationList{
+		ttl:      ttl,
+		sessions: make(map[int32]struct{}),
+		added:    make(map[int32]time.Time),
+		now:      time.Now,
+	}
+}
+
+func (l *RevocationList) Add(sessionIDs ...int32) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for _, id := range sessionIDs {
+		l.sessions[id] = struct{}{}
+		l.added[id] = now
+	}
+}
+func (l *RevocationList) IsRevoked(sessionID int32) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, ok := l.sessions[sessionID]
+	return ok
+}
+
+// Replace swaps the list with a snapshot from the database, sessions closed long ago are dropped this way.
+// Sessions added on this instance are kept until tokens issued for them expire, even if the snapshot misses them.
+func (l *RevocationList) Replace(sessionIDs []int32) {
+	sessions := make(map[int32]struct{}, len(sessionIDs))
+	for _, id := range sessionIDs {
+		sessions[id] = struct{}{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for id, addedAt := range l.added {
+		if now.Sub(addedAt) >= l.ttl {
+			delete(l.added, id)
+			continue
+		}
+		sessions[id] = struct{}{}
+	}
+	l.sessions = sessions
+}
@@ -0,0 +1,34 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRevocationList(t *testing.T) {
+	now := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+	list := NewRevocationList(time.Minute * 15)
+	list.now = func() time.Time { return now }
+
+	list.Replace([]int32{1, 2})
+	if !list.IsRevoked(1) || !list.IsRevoked(2) || list.IsRevoked(3) {
+		t.Fatal("snapshot is not applied")
+	}
+
+	// A local logout races with a snapshot taken before it was committed
+	list.Add(3)
+	list.Replace([]int32{2})
+	if list.IsRevoked(1) {
+		t.Error("session 1 is not in the snapshot and must be dropped")
+	}
+	if !list.IsRevoked(3) {
+		t.Error("session 3 revoked locally must survive the snapshot")
+	}
+
+	// Tokens of the session have expired, so the snapshot decides
+	now = now.Add(time.Minute * 15)
+	list.Replace(nil)
+	if list.IsRevoked(3) || list.IsRevoked(2) {
+		t.Error("expired local revocations must be dropped")
+	}
+}
@@ -0,0 +1,51 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+
+	// How often counters of finished windows are removed from the store
+	cleanupInterval = time.Minute
+)
+
+// IStore counts requests with a sliding window: the counter of the previous fixed window
+// is taken with a weight equal to the part of it which still overlaps the sliding window
+type IStore interface {
+	// Hit registers a request for the key and reports whether it fits into the limit.
+	// If it doesn't, the second value is the time after which the next request will fit.
+	Hit(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error)
+}
+
+// decide checks the weighted number of requests in the sliding window, curr includes the current request
+func decide(prev, curr int, elapsed, window time.Duration, limit int) (bool, time.Duration) {
+	weight := 1 - float64(elapsed)/float64(window)
+	if float64(prev)*weight+float64(curr) <= float64(limit) {
+		return true, 0
+	}
+
+	// Find the moment when one more request fits into the window
+	var wait time.Duration
+	if curr < limit {
+		// The previous window still has to slide out partially
+		at := float64(window) * (1 - float64(limit-curr-1)/float64(prev))
+		wait = time.Duration(at) - elapsed
+	} else {
+		// The current window is full, the wait lasts until the next one and part of it
+		at := float64(window) * (1 - float64(limit-1)/float64(curr))
+		wait = window - elapsed + time.Duration(at)
+	}
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return false, wait
+}
+
+func windowKey(key string, window time.Duration) string {
+	return fmt.Sprintf("%s:%d", key, window/time.Second)
+}
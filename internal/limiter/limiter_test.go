@@ -0,0 +1,91 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name    string
+		prev    int
+		curr    int
+		elapsed time.Duration
+		limit   int
+		allowed bool
+		wait    time.Duration
+	}{
+		{name: "empty", curr: 1, limit: 5, allowed: true},
+		{name: "limit is inclusive", curr: 5, limit: 5, allowed: true},
+		{name: "previous window is weighted", prev: 10, curr: 5, elapsed: 30 * time.Second, limit: 10, allowed: true},
+		// 10 * 0.3 + 7 fits at 42s
+		{name: "previous window slides out", prev: 10, curr: 6, elapsed: 30 * time.Second, limit: 10, wait: 12 * time.Second},
+		// 5 * 0.4 + 1 fits at 24s of the next window
+		{name: "current window is full", curr: 5, elapsed: 30 * time.Second, limit: 4, wait: 54 * time.Second},
+		{name: "wait is at least a second", prev: 1000, curr: 1, elapsed: 10 * time.Millisecond, limit: 1000, wait: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, wait := decide(tt.prev, tt.curr, tt.elapsed, time.Minute, tt.limit)
+			if allowed != tt.allowed {
+				t.Errorf("allowed = %v, want %v", allowed, tt.allowed)
+			}
+			// Float math may lose a nanosecond
+			if diff := wait - tt.wait; diff < -time.Millisecond || diff > time.Millisecond {
+				t.Errorf("wait = %v, want %v", wait, tt.wait)
+			}
+		})
+	}
+}
+func TestMemory(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemory()
+	store.now = func() time.Time { return now }
+	store.lastCleanup = now
+
+	hits := []struct {
+		at      string
+		key     string
+		allowed bool
+	}{
+		{"12:00:10", "a", true},
+		{"12:00:20", "a", true},
+		{"12:00:30", "a", false},
+		{"12:00:30", "b", true},
+		// Three requests of the previous window weigh 3 * 1/6
+		{"12:01:50", "a", true},
+		// 3 * 1/12 + 2
+		{"12:01:55", "a", false},
+		// The previous window is empty
+		{"12:03:00", "a", true},
+		{"12:03:01", "a", true},
+		{"12:03:02", "a", false},
+	}
+	for _, hit := range hits {
+		at, _ := time.Parse("15:04:05", hit.at)
+		now = time.Date(2023, 1, 1, at.Hour(), at.Minute(), at.Second(), 0, time.UTC)
+		allowed, wait, err := store.Hit(context.Background(), hit.key, 2, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed != hit.allowed {
+			t.Errorf("%s %s: allowed = %v, want %v", hit.at, hit.key, allowed, hit.allowed)
+		}
+		if !allowed && wait < time.Second {
+			t.Errorf("%s %s: wait = %v", hit.at, hit.key, wait)
+		}
+	}
+
+	// Windows of the same key are counted separately
+	allowed, _, _ := store.Hit(context.Background(), "a", 2, time.Hour)
+	if !allowed {
+		t.Error("another window must have its own counter")
+	}
+
+	now = now.Add(time.Hour * 3)
+	_, _, _ = store.Hit(context.Background(), "c", 2, time.Minute)
+	if len(store.counters) != 1 {
+		t.Errorf("finished counters are not removed: %d left", len(store.counters))
+	}
+}
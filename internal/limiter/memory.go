@@ -0,0 +1,78 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type counter struct {
+	window time.Duration
+	start  time.Time
+	prev   int
+	curr   int
+}
+
+// Memory keeps counters in the process, so every instance of the service has its own limits
+type Memory struct {
+	counters    map[string]*counter
+	lastCleanup time.Time
+	m           sync.Mutex
+
+	now func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		counters:    make(map[string]*counter),
+		lastCleanup: time.Now(),
+		now:         time.Now,
+	}
+}
+
+func (s *Memory) Hit(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	now := s.now()
+	start := now.Truncate(window)
+	key = windowKey(key, window)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok {
+		c = &counter{
+			window: window,
+			start:  start,
+		}
+		s.counters[key] = c
+	}
+
+	// Move to the current fixed window
+	if !c.start.Equal(start) {
+		if start.Sub(c.start) == window {
+			c.prev = c.curr
+		} else {
+			c.prev = 0
+		}
+		c.curr = 0
+		c.start = start
+	}
+	c.curr++
+
+	allowed, wait := decide(c.prev, c.curr, now.Sub(start), window, limit)
+
+	if now.Sub(s.lastCleanup) > cleanupInterval {
+		s.cleanup(now)
+		s.lastCleanup = now
+	}
+	return allowed, wait, nil
+}
+
+// cleanup removes counters which don't affect the sliding window anymore
+func (s *Memory) cleanup(now time.Time) {
+	for key, c := range s.counters {
+		if now.Sub(c.start) > 2*c.window {
+			delete(s.counters, key)
+		}
+	}
+}
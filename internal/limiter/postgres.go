@@ -0,0 +1,68 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/HardDie/event_tracker/internal/db"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/repository"
+)
+
+// Postgres keeps counters in the database, so the limits are shared between all instances of the service
+type Postgres struct {
+	rateLimitRepository repository.IRateLimit
+
+	lastCleanup time.Time
+	m           sync.Mutex
+
+	db *db.DB
+}
+
+func NewPostgres(db *db.DB, rateLimit repository.IRateLimit) *Postgres {
+	return &Postgres{
+		db:                  db,
+		rateLimitRepository: rateLimit,
+		lastCleanup:         time.Now(),
+	}
+}
+
+func (s *Postgres) Hit(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	// Windows are aligned to the epoch, so all instances agree on them
+	now := time.Now().UTC()
+	start := now.Truncate(window)
+	key = windowKey(key, window)
+
+	curr, err := s.rateLimitRepository.Increase(s.db.DB, ctx, key, start, start.Add(2*window))
+	if err != nil {
+		return false, 0, err
+	}
+	prev, err := s.rateLimitRepository.GetCount(s.db.DB, ctx, key, start.Add(-window))
+	if err != nil {
+		return false, 0, err
+	}
+
+	allowed, wait := decide(int(prev), int(curr), now.Sub(start), window, limit)
+
+	s.cleanup(now)
+	return allowed, wait, nil
+}
+
+// cleanup removes finished windows from time to time
+func (s *Postgres) cleanup(now time.Time) {
+	s.m.Lock()
+	if now.Sub(s.lastCleanup) <= cleanupInterval {
+		s.m.Unlock()
+		return
+	}
+	s.lastCleanup = now
+	s.m.Unlock()
+
+	go func() {
+		_, err := s.rateLimitRepository.DeleteExpired(s.db.DB, context.Background(), now)
+		if err != nil {
+			logger.Error.Printf("error deleting expired rate limits: %v", err.Error())
+		}
+	}()
+}
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/HardDie/event_tracker/internal/logger"
+)
+
+// Log is a development mailer, which doesn't send anything and writes emails to a file or to the log
+type Log struct {
+	file string
+	m    sync.Mutex
+}
+
+func NewLog(file string) *Log {
+	return &Log{
+		file: file,
+	}
+}
+
+func (m *Log) Send(ctx context.Context, msg *Message) error {
+	if m.file == "" {
+		logger.Info.Printf("email to %s\nSubject: %s\n\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	file, err := os.OpenFile(m.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open mail log file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z),
+		msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return fmt.Errorf("write mail log file: %w", err)
+	}
+	return nil
+}
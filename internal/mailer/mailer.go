@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/HardDie/event_tracker/internal/logger"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverLog  = "log"
+)
+
+type Config struct {
+	Driver   string
+	Host     string
+	Port     int
+	User     string
+	Password string
+	From     string
+	// File where the log driver writes emails, stdout is used if empty
+	LogFile string
+}
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type IMailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+func Get(cfg Config) (IMailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP:
+		return NewSMTP(cfg), nil
+	case DriverLog:
+		if cfg.LogFile == "" {
+			logger.Warn.Println("emails are not sent, they are written to the log")
+		}
+		return NewLog(cfg.LogFile), nil
+	}
+	return nil, fmt.Errorf("unknown mailer driver: %q", cfg.Driver)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/HardDie/event_tracker/internal/entity"
 	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/jwt"
 	"github.com/HardDie/event_tracker/internal/service"
 	"github.com/HardDie/event_tracker/internal/utils"
 )
@@ -19,30 +22,126 @@ func NewAuthMiddleware(authService service.IAuth) *AuthMiddleware {
 		authService: authService,
 	}
 }
+
+// RequestMiddleware allows only requests with a login session
 func (m *AuthMiddleware) RequestMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		bearer := utils.GetBearer(r)
+		bearer, ok := getCredentials(w, r)
+		if !ok {
+			return
+		}
+
+		// Access tokens can't be used to manage the account
+		if strings.HasPrefix(bearer, entity.AccessTokenPrefix) {
+			http.Error(w, "Access tokens are not allowed for this endpoint", http.StatusForbidden)
+			return
+		}
 
-		// If we got no session
-		if bearer == "" {
-			http.Error(w, "Invalid session token", http.StatusBadRequest)
+		m.serveSession(w, r, next, bearer)
+	})
+}
+
+// TokenRequestMiddleware allows requests with a login session or with a personal access token
+func (m *AuthMiddleware) TokenRequestMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, ok := getCredentials(w, r)
+		if !ok {
 			return
 		}
 
-		// Validate if session is active
+		if !strings.HasPrefix(bearer, entity.AccessTokenPrefix) {
+			m.serveSession(w, r, next, bearer)
+			return
+		}
+
+		// Validate if access token is active
 		ctx := r.Context()
-		session, err := m.authService.ValidateCookie(ctx, bearer)
+		token, err := m.authService.ValidateAccessToken(ctx, bearer)
 		if err != nil {
-			if errors.Is(err, errs.SessionInvalid) {
-				http.Error(w, err.Error(), http.StatusUnauthorized)
-			} else {
-				http.Error(w, "Internal error", http.StatusInternalServerError)
-			}
+			writeAuthError(w, err)
 			return
 		}
 
-		ctx = context.WithValue(ctx, "userID", session.UserID)
-		ctx = context.WithValue(ctx, "session", session)
+		ctx = context.WithValue(ctx, "userID", token.UserID)
+		ctx = context.WithValue(ctx, "scopes", token.Scopes)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+func (m *AuthMiddleware) serveSession(w http.ResponseWriter, r *http.Request, next http.Handler, bearer string) {
+	if jwt.Looks(bearer) {
+		m.serveJWT(w, r, next, bearer)
+		return
+	}
+
+	// Validate if session is active
+	ctx := r.Context()
+	session, err := m.authService.ValidateCookie(ctx, bearer)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+
+	ctx = context.WithValue(ctx, "userID", session.UserID)
+	ctx = context.WithValue(ctx, "session", session)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// getCredentials returns the bearer token or the session from the cookie. Browsers send cookies
+// with cross-site requests too, so a request that changes anything has to pass the CSRF check.
+func getCredentials(w http.ResponseWriter, r *http.Request) (string, bool) {
+	bearer := utils.GetBearer(r)
+	if bearer != "" {
+		return bearer, true
+	}
+
+	session := utils.GetCookie(r, utils.SessionCookieName)
+
+	// If we got no session
+	if session == "" {
+		http.Error(w, "Invalid session token", http.StatusBadRequest)
+		return "", false
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if !utils.CheckCSRF(r) {
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return "", false
+		}
+	}
+	return session, true
+}
+
+func writeAuthError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errs.SessionInvalid) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	} else {
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+	}
+}
+
+// serveJWT passes the signed access token without a database query. Only the IDs are known here,
+// so the session in the context has the address and the user agent of the current request.
+func (m *AuthMiddleware) serveJWT(w http.ResponseWriter, r *http.Request, next http.Handler, bearer string) {
+	ctx := r.Context()
+	claims, err := m.authService.ValidateJWT(ctx, bearer)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+
+	session := &entity.Session{
+		ID:        claims.SessionID,
+		UserID:    claims.Subject,
+		UserAgent: r.UserAgent(),
+		IP:        utils.GetClientIP(r),
+	}
+	ctx = context.WithValue(ctx, "userID", claims.Subject)
+	ctx = context.WithValue(ctx, "session", session)
+	if len(claims.Scopes) > 0 {
+		ctx = context.WithValue(ctx, "scopes", claims.Scopes)
+	}
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
@@ -1,13 +1,64 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
 
-// CorsMiddleware CORS Headers middleware
-func CorsMiddleware(next http.Handler) http.Handler {
+	"github.com/HardDie/event_tracker/internal/logger"
+)
+
+type CorsMiddleware struct {
+	origins     map[string]struct{}
+	anyOrigin   bool
+	credentials bool
+}
+
+func NewCorsMiddleware(origins []string, credentials bool) *CorsMiddleware {
+	m := &CorsMiddleware{
+		origins:     make(map[string]struct{}, len(origins)),
+		credentials: credentials,
+	}
+	for _, origin := range origins {
+		if origin == "*" {
+			m.anyOrigin = true
+			continue
+		}
+		m.origins[origin] = struct{}{}
+	}
+
+	// Any site would be able to make requests with the cookies of the user
+	if m.anyOrigin && m.credentials {
+		logger.Warn.Println("CORS credentials can't be used with any origin, credentials are disabled")
+		m.credentials = false
+	}
+	return m
+}
+
+// RequestMiddleware CORS Headers middleware, it answers preflight requests itself, so it must wrap the whole router
+func (m *CorsMiddleware) RequestMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PATCH,DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		origin := r.Header.Get("Origin")
+		if origin != "" {
+			_, allowed := m.origins[origin]
+			switch {
+			case allowed:
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+				if m.credentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			case m.anyOrigin:
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization,Content-Type,X-CSRF-Token")
+			w.Header().Set("Access-Control-Expose-Headers", "Retry-After")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/HardDie/event_tracker/internal/limiter"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+// Requests bigger than this are not inspected for a username
+const rateLimitMaxBodySize = 1 << 20
+
+// RateLimit is a number of requests allowed per window, zero means no limit
+type RateLimit struct {
+	// Requests from a single client IP
+	IP int
+	// Requests for a single username taken from the JSON body
+	Username int
+	// Requests from a single authenticated user
+	User int
+}
+
+type RateLimitMiddleware struct {
+	store  limiter.IStore
+	window time.Duration
+	// Limits for route templates, e.g. "/api/v1/auth/login"
+	routes       map[string]RateLimit
+	defaultLimit RateLimit
+}
+
+func NewRateLimitMiddleware(store limiter.IStore, window time.Duration, routes map[string]RateLimit,
+	defaultLimit RateLimit) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		store:        store,
+		window:       window,
+		routes:       routes,
+		defaultLimit: defaultLimit,
+	}
+}
+
+// RequestMiddleware limits requests by client IP, username and user for the matched route.
+// For the user limit it must run after the auth middleware.
+func (m *RateLimitMiddleware) RequestMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		limit, ok := m.routes[route]
+		if !ok {
+			limit = m.defaultLimit
+		}
+
+		if limit.IP > 0 {
+			if !m.allow(w, r, fmt.Sprintf("ip:%s:%s", route, utils.GetClientIP(r)), limit.IP) {
+				return
+			}
+		}
+		if limit.Username > 0 {
+			if username := peekUsername(r); username != "" {
+				if !m.allow(w, r, fmt.Sprintf("username:%s:%s", route, username), limit.Username) {
+					return
+				}
+			}
+		}
+		if limit.User > 0 {
+			if userID, ok := r.Context().Value("userID").(int32); ok {
+				if !m.allow(w, r, fmt.Sprintf("user:%s:%d", route, userID), limit.User) {
+					return
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow registers the request and writes 429 response if the limit is exceeded
+func (m *RateLimitMiddleware) allow(w http.ResponseWriter, r *http.Request, key string, limit int) bool {
+	allowed, wait, err := m.store.Hit(r.Context(), key, limit, m.window)
+	if err != nil {
+		// Don't turn a broken store into an outage
+		logger.Error.Printf("error checking rate limit: %v", err.Error())
+		return true
+	}
+	if allowed {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	http.Error(w, "Too many requests", http.StatusTooManyRequests)
+	return false
+}
+
+// peekUsername reads the username from the JSON body and puts the body back for the handler
+func peekUsername(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, rateLimitMaxBodySize))
+	if err != nil {
+		return ""
+	}
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+
+	req := struct {
+		Username string `json:"username"`
+	}{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return strings.ToLower(req.Username)
+}
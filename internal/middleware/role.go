@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/service"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+type RoleMiddleware struct {
+	adminService service.IAdmin
+	roles        []string
+}
+
+func NewRoleMiddleware(adminService service.IAdmin, roles ...string) *RoleMiddleware {
+	return &RoleMiddleware{
+		adminService: adminService,
+		roles:        roles,
+	}
+}
+
+// RequestMiddleware allows only users with one of the roles, it must be used after the auth middleware
+func (m *RoleMiddleware) RequestMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		role, err := m.adminService.GetRole(ctx, utils.GetUserIDFromContext(ctx))
+		if err != nil {
+			if errors.Is(err, errs.Forbidden) {
+				http.Error(w, "Not enough permissions", http.StatusForbidden)
+			} else {
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		allowed := false
+		for _, allowedRole := range m.roles {
+			if allowedRole == role {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			http.Error(w, "Not enough permissions", http.StatusForbidden)
+			return
+		}
+
+		ctx = context.WithValue(ctx, "role", role)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,125 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Allowed difference between the clocks of the provider and the service
+const clockSkew = time.Minute
+
+type Claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	ExpiresAt         int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     bool     `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+}
+
+// audience can be a single string or a list of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Verify checks the signature and the claims of the ID token
+func (p *Provider) Verify(ctx context.Context, rawToken, nonce string) (*Claims, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return nil, fmt.Errorf("bad header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+
+	key, err := p.getKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("bad signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	claims := &Claims{}
+	err = decodeSegment(parts[1], claims)
+	if err != nil {
+		return nil, fmt.Errorf("bad claims: %w", err)
+	}
+
+	if claims.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("issuer %q doesn't match", claims.Issuer)
+	}
+	if !claims.hasAudience(p.cfg.ClientID) {
+		return nil, fmt.Errorf("token is issued for another client")
+	}
+	now := time.Now()
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)) {
+		return nil, fmt.Errorf("token has expired")
+	}
+	if claims.IssuedAt != 0 && now.Add(clockSkew).Before(time.Unix(claims.IssuedAt, 0)) {
+		return nil, fmt.Errorf("token is issued in the future")
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("nonce doesn't match")
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("no subject")
+	}
+	return claims, nil
+}
+
+func (c *Claims) hasAudience(clientID string) bool {
+	for _, aud := range c.Audience {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
@@ -0,0 +1,99 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+type keySet struct {
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// getKey returns the signing key with the ID, the key set is fetched again
+// if the key is unknown, because the provider could have rotated its keys
+func (p *Provider) getKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys != nil && time.Since(p.keys.fetched) < cacheTimeout {
+		if key, ok := p.keys.keys[kid]; ok {
+			return key, nil
+		}
+	}
+	// Don't let tokens with random key IDs flood the provider
+	if p.keys != nil && time.Since(p.keys.fetched) < time.Minute {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, doc.JWKSURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build jwks request: %w", err)
+	}
+	var resp struct {
+		Keys []jwk `json:"keys"`
+	}
+	err = p.do(req, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	set := &keySet{
+		keys:    make(map[string]*rsa.PublicKey, len(resp.Keys)),
+		fetched: time.Now(),
+	}
+	for _, key := range resp.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		publicKey, err := parseRSAKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: key %q: %w", key.Kid, err)
+		}
+		set.keys[key.Kid] = publicKey
+	}
+	p.keys = set
+
+	key, ok := set.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return key, nil
+}
+
+func parseRSAKey(key jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, fmt.Errorf("bad modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, fmt.Errorf("bad exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31 {
+		return nil, fmt.Errorf("bad exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
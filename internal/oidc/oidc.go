@@ -0,0 +1,172 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long the discovery document and the key set are cached
+const cacheTimeout = time.Hour
+
+type Config struct {
+	// Issuer URL of the provider, OIDC login is disabled if empty
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// The page of the frontend which receives the code and passes it to the API
+	RedirectURL string
+	Scopes      []string
+}
+
+type Token struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+}
+
+// Provider implements the authorization code flow with PKCE against a single OpenID Connect provider
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu         sync.Mutex
+	discovery  *discovery
+	keys       *keySet
+	discovered time.Time
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func New(cfg Config) *Provider {
+	return &Provider{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (p *Provider) Issuer() string {
+	return p.cfg.Issuer
+}
+
+// AuthURL returns the address of the provider login page
+func (p *Provider) AuthURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	values := url.Values{}
+	values.Set("response_type", "code")
+	values.Set("client_id", p.cfg.ClientID)
+	values.Set("redirect_uri", p.cfg.RedirectURL)
+	values.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	values.Set("state", state)
+	values.Set("nonce", nonce)
+	values.Set("code_challenge", codeChallenge)
+	values.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return doc.AuthorizationEndpoint + separator + values.Encode(), nil
+}
+
+// Exchange trades the authorization code for tokens
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Set("grant_type", "authorization_code")
+	values.Set("code", code)
+	values.Set("redirect_uri", p.cfg.RedirectURL)
+	values.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	token := &Token{}
+	err = p.do(req, token)
+	if err != nil {
+		return nil, fmt.Errorf("exchange code: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("exchange code: no id_token in response")
+	}
+	return token, nil
+}
+
+func (p *Provider) getDiscovery(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil && time.Since(p.discovered) < cacheTimeout {
+		return p.discovery, nil
+	}
+
+	wellKnown := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build discovery request: %w", err)
+	}
+
+	doc := &discovery{}
+	err = p.do(req, doc)
+	if err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	if doc.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery: issuer %q doesn't match %q", doc.Issuer, p.cfg.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery: required endpoints are missing")
+	}
+
+	p.discovery = doc
+	p.discovered = time.Now()
+	return doc, nil
+}
+
+func (p *Provider) do(req *http.Request, resp interface{}) error {
+	httpResp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", httpResp.StatusCode, body)
+	}
+
+	err = json.Unmarshal(body, resp)
+	if err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// CodeChallenge returns the S256 challenge for the PKCE code verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,57 @@
+package pwdpolicy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Breached checks the password against the range API of breached passwords.
+// Only the first 5 characters of the SHA-1 hash leave the server, the API
+// returns the suffixes of all known hashes with that prefix.
+func (p *Policy) Breached(ctx context.Context, password string) (bool, error) {
+	if p.cfg.BreachAPI == "" {
+		return false, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.cfg.BreachAPI, "/")+"/"+prefix, nil)
+	if err != nil {
+		return false, fmt.Errorf("build breach request: %w", err)
+	}
+	// Hide the real number of suffixes from anyone watching the traffic
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("breach request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("breach request: unexpected status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		hashSuffix, count, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(hashSuffix, suffix) {
+			continue
+		}
+		// Padding entries have zero count
+		n, err := strconv.Atoi(count)
+		return err == nil && n > 0, nil
+	}
+	if err = scanner.Err(); err != nil {
+		return false, fmt.Errorf("read breach response: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,103 @@
+package pwdpolicy
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//go:embed common_passwords.txt
+var commonPasswords []byte
+
+type Config struct {
+	MinLength     int
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// Forbid passwords which contain the username
+	ForbidUsername bool
+	// Forbid passwords from the bundled list of common passwords
+	ForbidCommon bool
+	// Range API of breached passwords (k-anonymity), empty disables the check
+	BreachAPI string
+}
+
+type Policy struct {
+	cfg    Config
+	common map[string]struct{}
+	client *http.Client
+}
+
+func New(cfg Config) *Policy {
+	p := &Policy{
+		cfg:    cfg,
+		common: make(map[string]struct{}),
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+	if cfg.ForbidCommon {
+		scanner := bufio.NewScanner(bytes.NewReader(commonPasswords))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" {
+				p.common[strings.ToLower(line)] = struct{}{}
+			}
+		}
+	}
+	return p
+}
+
+// Check returns the descriptions of the rules the password breaks, nil if it's acceptable
+func (p *Policy) Check(password, username string) []string {
+	var res []string
+
+	if len([]rune(password)) < p.cfg.MinLength {
+		res = append(res, fmt.Sprintf("password must be at least %d characters long", p.cfg.MinLength))
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.cfg.RequireLower && !hasLower {
+		res = append(res, "password must contain a lowercase letter")
+	}
+	if p.cfg.RequireUpper && !hasUpper {
+		res = append(res, "password must contain an uppercase letter")
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		res = append(res, "password must contain a digit")
+	}
+	if p.cfg.RequireSymbol && !hasSymbol {
+		res = append(res, "password must contain a symbol")
+	}
+
+	if p.cfg.ForbidUsername && username != "" &&
+		strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		res = append(res, "password must not contain the username")
+	}
+
+	if p.cfg.ForbidCommon {
+		if _, ok := p.common[strings.ToLower(password)]; ok {
+			res = append(res, "password is too common")
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,86 @@
+package pwdpolicy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		password string
+		username string
+		want     []string
+	}{
+		{"ok", Config{MinLength: 8}, "correct horse", "", nil},
+		{"too short", Config{MinLength: 8}, "abc", "", []string{"password must be at least 8 characters long"}},
+		{"length in runes", Config{MinLength: 4}, "пароль", "", nil},
+		{"classes ok", Config{RequireLower: true, RequireUpper: true, RequireDigit: true, RequireSymbol: true}, "aB3$", "", nil},
+		{"classes missing", Config{RequireLower: true, RequireUpper: true, RequireDigit: true, RequireSymbol: true}, "    ", "", []string{
+			"password must contain a lowercase letter",
+			"password must contain an uppercase letter",
+			"password must contain a digit",
+			"password must contain a symbol",
+		}},
+		{"username", Config{ForbidUsername: true}, "my-Alice-pass", "alice", []string{"password must not contain the username"}},
+		{"username allowed", Config{}, "my-Alice-pass", "alice", nil},
+		{"common", Config{ForbidCommon: true}, "Password", "", []string{"password is too common"}},
+		{"common allowed", Config{}, "password", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(tt.cfg).Check(tt.password, tt.username)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+func TestBreached(t *testing.T) {
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/range/5BAA6" {
+			fmt.Fprint(w, "0000000000000000000000000000000000A:3\r\n")
+			return
+		}
+		fmt.Fprint(w, "003D68EB55068C33ACE09247EE4C639306B:3\r\n")
+		fmt.Fprint(w, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n")
+		// Padding entry of another password
+		fmt.Fprint(w, "0018A45C4D1DEF81644B54AB7F969B88D65:0\r\n")
+	}))
+	defer server.Close()
+
+	policy := New(Config{BreachAPI: server.URL + "/range/"})
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"password", true},
+		{"correct horse battery staple", false},
+	}
+	for _, tt := range tests {
+		got, err := policy.Breached(context.Background(), tt.password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Breached(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+
+	// Disabled check never calls the API
+	got, err := New(Config{}).Breached(context.Background(), "password")
+	if err != nil || got {
+		t.Errorf("disabled check: %v, %v", got, err)
+	}
+
+	server.Close()
+	if _, err = policy.Breached(context.Background(), "password"); err == nil {
+		t.Error("expected an error for an unreachable API")
+	}
+}
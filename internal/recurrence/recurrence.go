@@ -0,0 +1,251 @@
+// Package recurrence expands schedules of event types into the days on which events are expected.
+//
+// Days are calendar dates without a time zone, they are represented as midnight UTC. Rules take a subset
+// of the iCalendar RRULE (RFC 5545): FREQ of DAILY, WEEKLY or MONTHLY, INTERVAL, BYDAY without ordinals,
+// BYMONTHDAY including negative days counted from the end of the month, COUNT and UNTIL as YYYYMMDD.
+package recurrence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+const DateLayout = "2006-01-02"
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// maxInterval limits INTERVAL, a larger one never repeats in practice
+const maxInterval = 1000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+type Rule struct {
+	Start      time.Time
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+}
+
+// FromSchedule builds the rule of the schedule, it returns an error if the schedule is malformed
+func FromSchedule(schedule *entity.EventSchedule) (*Rule, error) {
+	start, err := ParseDay(schedule.Start)
+	if err != nil {
+		return nil, fmt.Errorf("schedule start must be a date like 2023-01-31")
+	}
+
+	switch schedule.Kind {
+	case entity.ScheduleDaily:
+		return &Rule{Start: start, Freq: FreqDaily, Interval: 1}, nil
+	case entity.ScheduleWeekdays:
+		return &Rule{
+			Start:    start,
+			Freq:     FreqWeekly,
+			Interval: 1,
+			ByDay:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		}, nil
+	case entity.ScheduleInterval:
+		if schedule.Interval < 1 || schedule.Interval > maxInterval {
+			return nil, fmt.Errorf("schedule interval must be from 1 to %d days", maxInterval)
+		}
+		return &Rule{Start: start, Freq: FreqDaily, Interval: schedule.Interval}, nil
+	case entity.ScheduleRRule:
+		return Parse(schedule.RRule, start)
+	}
+	return nil, fmt.Errorf("unknown schedule kind %q", schedule.Kind)
+}
+
+// Parse reads a rule like FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH, an optional RRULE: prefix is skipped
+func Parse(rrule string, start time.Time) (*Rule, error) {
+	rule := &Rule{
+		Start:    Day(start),
+		Interval: 1,
+	}
+
+	rrule = strings.TrimPrefix(strings.TrimSpace(rrule), "RRULE:")
+	if rrule == "" {
+		return nil, fmt.Errorf("rrule is empty")
+	}
+	for _, part := range strings.Split(rrule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("rrule: bad part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(value)
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+			if err == nil && (rule.Interval < 1 || rule.Interval > maxInterval) {
+				err = fmt.Errorf("must be from 1 to %d", maxInterval)
+			}
+		case "BYDAY":
+			for _, name := range strings.Split(strings.ToUpper(value), ",") {
+				weekday, ok := weekdays[name]
+				if !ok {
+					err = fmt.Errorf("unknown day %q", name)
+					break
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				var monthDay int
+				monthDay, err = strconv.Atoi(day)
+				if err == nil && (monthDay == 0 || monthDay < -31 || monthDay > 31) {
+					err = fmt.Errorf("day %d is out of range", monthDay)
+				}
+				if err != nil {
+					break
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, monthDay)
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+			if err == nil && rule.Count < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "UNTIL":
+			// A time after the date, like 20230131T000000Z, is ignored
+			if len(value) > 8 {
+				value = value[:8]
+			}
+			var until time.Time
+			until, err = time.Parse("20060102", value)
+			rule.Until = &until
+		default:
+			err = fmt.Errorf("not supported")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("rrule: %s: %v", strings.ToUpper(name), err)
+		}
+	}
+
+	switch rule.Freq {
+	case FreqDaily:
+		if len(rule.ByDay) > 0 || len(rule.ByMonthDay) > 0 {
+			return nil, fmt.Errorf("rrule: BYDAY and BYMONTHDAY are not supported with FREQ=DAILY")
+		}
+	case FreqWeekly:
+		if len(rule.ByMonthDay) > 0 {
+			return nil, fmt.Errorf("rrule: BYMONTHDAY is not supported with FREQ=WEEKLY")
+		}
+	case FreqMonthly:
+		if len(rule.ByDay) > 0 {
+			return nil, fmt.Errorf("rrule: BYDAY is not supported with FREQ=MONTHLY")
+		}
+	case "":
+		return nil, fmt.Errorf("rrule: FREQ is required")
+	default:
+		return nil, fmt.Errorf("rrule: FREQ=%s is not supported", rule.Freq)
+	}
+	return rule, nil
+}
+
+// Between returns the days of the occurrences from the day of from to the day before to
+func (r *Rule) Between(from, to time.Time) []time.Time {
+	from, to = Day(from), Day(to)
+
+	// COUNT is counted from the start, so the days before the period are walked too.
+	// Without it the walk begins at the period, matches doesn't depend on the previous days.
+	first := r.Start
+	if r.Count == 0 && from.After(first) {
+		first = from
+	}
+
+	var res []time.Time
+	count := 0
+	for day := first; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if r.Until != nil && day.After(*r.Until) {
+			break
+		}
+		if !r.matches(day) {
+			continue
+		}
+		count++
+		if r.Count > 0 && count > r.Count {
+			break
+		}
+		if !day.Before(from) {
+			res = append(res, day)
+		}
+	}
+	return res
+}
+
+func (r *Rule) matches(day time.Time) bool {
+	switch r.Freq {
+	case FreqDaily:
+		return daysBetween(r.Start, day)%r.Interval == 0
+	case FreqWeekly:
+		// Weeks start on Monday like in RRULE by default
+		weeks := daysBetween(weekStart(r.Start), weekStart(day)) / 7
+		if weeks%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == r.Start.Weekday()
+		}
+		for _, weekday := range r.ByDay {
+			if day.Weekday() == weekday {
+				return true
+			}
+		}
+		return false
+	case FreqMonthly:
+		months := (day.Year()-r.Start.Year())*12 + int(day.Month()) - int(r.Start.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByMonthDay) == 0 {
+			return day.Day() == r.Start.Day()
+		}
+		lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		for _, monthDay := range r.ByMonthDay {
+			if monthDay < 0 {
+				monthDay = lastDay + monthDay + 1
+			}
+			if day.Day() == monthDay {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// Day drops the time of the date and keeps the day as it is written
+func Day(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ParseDay reads a day like 2023-01-31
+func ParseDay(value string) (time.Time, error) {
+	return time.Parse(DateLayout, value)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
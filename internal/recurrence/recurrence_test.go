@@ -0,0 +1,232 @@
+package recurrence
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+func day(value string) time.Time {
+	res, err := ParseDay(value)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+func days(values ...string) []time.Time {
+	var res []time.Time
+	for _, value := range values {
+		res = append(res, day(value))
+	}
+	return res
+}
+
+func TestParse(t *testing.T) {
+	until := day("2023-03-31")
+	tests := []struct {
+		rrule string
+		want  *Rule
+	}{
+		{"FREQ=DAILY", &Rule{Freq: FreqDaily, Interval: 1}},
+		{"RRULE:freq=weekly;interval=2;byday=MO,th", &Rule{Freq: FreqWeekly, Interval: 2, ByDay: []time.Weekday{time.Monday, time.Thursday}}},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1", &Rule{Freq: FreqMonthly, Interval: 1, ByMonthDay: []int{1, -1}}},
+		{"FREQ=DAILY;COUNT=10", &Rule{Freq: FreqDaily, Interval: 1, Count: 10}},
+		{"FREQ=DAILY;UNTIL=20230331", &Rule{Freq: FreqDaily, Interval: 1, Until: &until}},
+		{"FREQ=DAILY;UNTIL=20230331T235959Z", &Rule{Freq: FreqDaily, Interval: 1, Until: &until}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rrule, func(t *testing.T) {
+			start := day("2023-01-01")
+			tt.want.Start = start
+			got, err := Parse(tt.rrule, start.Add(time.Hour*15))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+func TestParseErrors(t *testing.T) {
+	for _, rrule := range []string{
+		"",
+		"RRULE:",
+		"FREQ",
+		"FREQ=",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=1001",
+		"FREQ=DAILY;INTERVAL=x",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=-32",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;UNTIL=2023",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYDAY=MO",
+		"FREQ=DAILY;BYSETPOS=1",
+	} {
+		t.Run(rrule, func(t *testing.T) {
+			if _, err := Parse(rrule, day("2023-01-01")); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+func TestFromSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule entity.EventSchedule
+		want     []time.Time
+	}{
+		{"daily", entity.EventSchedule{Kind: entity.ScheduleDaily, Start: "2023-02-02"}, days("2023-02-02", "2023-02-03", "2023-02-04", "2023-02-05")},
+		// 2023-01-27 is Friday
+		{"weekdays", entity.EventSchedule{Kind: entity.ScheduleWeekdays, Start: "2023-01-27"}, days("2023-01-30", "2023-01-31", "2023-02-01", "2023-02-02", "2023-02-03")},
+		{"interval", entity.EventSchedule{Kind: entity.ScheduleInterval, Start: "2023-01-29", Interval: 3}, days("2023-02-01", "2023-02-04")},
+		{"rrule", entity.EventSchedule{Kind: entity.ScheduleRRule, Start: "2023-01-01", RRule: "FREQ=WEEKLY;BYDAY=SA,SU"}, days("2023-02-04", "2023-02-05")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := FromSchedule(&tt.schedule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := rule.Between(day("2023-01-30"), day("2023-02-06"))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Between() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, schedule := range []entity.EventSchedule{
+		{Kind: entity.ScheduleDaily, Start: "2023-01-32"},
+		{Kind: entity.ScheduleInterval, Start: "2023-01-01", Interval: 0},
+		{Kind: entity.ScheduleRRule, Start: "2023-01-01", RRule: "FREQ=HOURLY"},
+		{Kind: "yearly", Start: "2023-01-01"},
+	} {
+		if _, err := FromSchedule(&schedule); err == nil {
+			t.Errorf("FromSchedule(%+v): expected an error", schedule)
+		}
+	}
+}
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name  string
+		rrule string
+		start string
+		from  string
+		to    string
+		want  []time.Time
+	}{
+		{
+			name: "daily interval aligned to the start", rrule: "FREQ=DAILY;INTERVAL=3",
+			start: "2023-01-01", from: "2023-01-05", to: "2023-01-14",
+			want: days("2023-01-07", "2023-01-10", "2023-01-13"),
+		},
+		{
+			name: "period before the start", rrule: "FREQ=DAILY",
+			start: "2023-01-10", from: "2023-01-01", to: "2023-01-12",
+			want: days("2023-01-10", "2023-01-11"),
+		},
+		{
+			name: "period after the end", rrule: "FREQ=DAILY;UNTIL=20230105",
+			start: "2023-01-01", from: "2023-01-10", to: "2023-01-20",
+		},
+		{
+			// 2023-01-02 is Monday, the week of 2023-01-09 is skipped
+			name: "weekly interval", rrule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			start: "2023-01-02", from: "2023-01-01", to: "2023-01-24",
+			want: days("2023-01-02", "2023-01-05", "2023-01-16", "2023-01-19"),
+		},
+		{
+			// Weeks start on Monday, so Sunday 2023-01-08 is in the first week
+			name: "weekly interval from sunday", rrule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU",
+			start: "2023-01-04", from: "2023-01-01", to: "2023-01-24",
+			want: days("2023-01-08", "2023-01-16", "2023-01-22"),
+		},
+		{
+			name: "weekly on the day of the start", rrule: "FREQ=WEEKLY;INTERVAL=3",
+			start: "2023-01-04", from: "2023-01-10", to: "2023-02-28",
+			want: days("2023-01-25", "2023-02-15"),
+		},
+		{
+			name: "negative month day", rrule: "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: "2023-01-01", from: "2023-01-01", to: "2023-05-01",
+			want: days("2023-01-31", "2023-02-28", "2023-03-31", "2023-04-30"),
+		},
+		{
+			name: "negative month day in a leap year", rrule: "FREQ=MONTHLY;BYMONTHDAY=-2,15",
+			start: "2024-02-01", from: "2024-02-01", to: "2024-03-01",
+			want: days("2024-02-15", "2024-02-28"),
+		},
+		{
+			name: "month day is missing in short months", rrule: "FREQ=MONTHLY;BYMONTHDAY=31",
+			start: "2023-01-01", from: "2023-01-01", to: "2023-06-01",
+			want: days("2023-01-31", "2023-03-31", "2023-05-31"),
+		},
+		{
+			name: "monthly interval on the day of the start", rrule: "FREQ=MONTHLY;INTERVAL=2",
+			start: "2023-01-15", from: "2023-02-01", to: "2023-08-01",
+			want: days("2023-03-15", "2023-05-15", "2023-07-15"),
+		},
+		{
+			name: "count", rrule: "FREQ=DAILY;COUNT=3",
+			start: "2023-01-01", from: "2023-01-01", to: "2023-01-10",
+			want: days("2023-01-01", "2023-01-02", "2023-01-03"),
+		},
+		{
+			name: "count is taken from the start", rrule: "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=5",
+			start: "2023-01-02", from: "2023-01-10", to: "2023-02-01",
+			want: days("2023-01-13", "2023-01-16"),
+		},
+		{
+			name: "count before the period", rrule: "FREQ=DAILY;COUNT=3",
+			start: "2023-01-01", from: "2023-01-10", to: "2023-01-20",
+		},
+		{
+			name: "until is inclusive", rrule: "FREQ=DAILY;INTERVAL=2;UNTIL=20230105",
+			start: "2023-01-01", from: "2023-01-01", to: "2023-01-10",
+			want: days("2023-01-01", "2023-01-03", "2023-01-05"),
+		},
+		{
+			name: "count and until", rrule: "FREQ=DAILY;COUNT=10;UNTIL=20230102",
+			start: "2023-01-01", from: "2023-01-01", to: "2023-01-10",
+			want: days("2023-01-01", "2023-01-02"),
+		},
+		{
+			name: "to is exclusive", rrule: "FREQ=DAILY",
+			start: "2023-01-01", from: "2023-01-03", to: "2023-01-04",
+			want: days("2023-01-03"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rrule, day(tt.start))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := rule.Between(day(tt.from), day(tt.to))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Between() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+func TestBetweenFarFromStart(t *testing.T) {
+	rule, err := Parse("FREQ=DAILY;INTERVAL=7", day("1900-01-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1900-01-01 and 2023-01-02 are both Monday
+	got := rule.Between(day("2023-01-01"), day("2023-01-15"))
+	if want := days("2023-01-02", "2023-01-09"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Between() = %v, want %v", got, want)
+	}
+}
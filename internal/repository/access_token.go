@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+	"github.com/lib/pq"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+type IAccessToken interface {
+	Create(tx godb.Queryer, ctx context.Context, userID int32, name, tokenHash string, scopes []string, expiresAt *time.Time) (*entity.AccessToken, error)
+	GetByHash(tx godb.Queryer, ctx context.Context, tokenHash string) (*entity.AccessToken, error)
+	ListByUserID(tx godb.Queryer, ctx context.Context, userID int32) ([]*entity.AccessToken, int32, error)
+	UpdateLastUsed(tx godb.Queryer, ctx context.Context, id int32) error
+	DeleteByUserID(tx godb.Queryer, ctx context.Context, userID, id int32) error
+	DeleteAllByUserID(tx godb.Queryer, ctx context.Context, userID int32) ([]int32, error)
+}
+
+type AccessToken struct {
+}
+
+func NewAccessToken() *AccessToken {
+	return &AccessToken{}
+}
+
+func (r *AccessToken) Create(tx godb.Queryer, ctx context.Context, userID int32, name, tokenHash string, scopes []string, expiresAt *time.Time) (*entity.AccessToken, error) {
+	token := &entity.AccessToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: tokenHash,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+
+	q := gosql.NewInsert().Into("access_tokens")
+	q.Columns().Add("user_id", "name", "token_hash", "scopes", "expires_at")
+	q.Columns().Arg(userID, name, tokenHash, pq.Array(scopes), expiresAt)
+	q.Returning().Add("id", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&token.ID, &token.CreatedAt, &token.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+func (r *AccessToken) GetByHash(tx godb.Queryer, ctx context.Context, tokenHash string) (*entity.AccessToken, error) {
+	token := &entity.AccessToken{
+		TokenHash: tokenHash,
+	}
+
+	q := gosql.NewSelect().From("access_tokens")
+	q.Columns().Add("id", "user_id", "name", "scopes", "last_used_at", "expires_at", "created_at", "updated_at")
+	q.Where().AddExpression("token_hash = ?", tokenHash)
+	q.Where().AddExpression("deleted_at IS NULL")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, pq.Array(&token.Scopes), &token.LastUsedAt, &token.ExpiresAt,
+		&token.CreatedAt, &token.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return token, nil
+}
+func (r *AccessToken) ListByUserID(tx godb.Queryer, ctx context.Context, userID int32) ([]*entity.AccessToken, int32, error) {
+	var res []*entity.AccessToken
+
+	q := gosql.NewSelect().From("access_tokens")
+	q.Columns().Add("id", "name", "scopes", "last_used_at", "expires_at", "created_at", "updated_at")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.AddOrder("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		token := &entity.AccessToken{
+			UserID: userID,
+		}
+		err = rows.Scan(&token.ID, &token.Name, pq.Array(&token.Scopes), &token.LastUsedAt, &token.ExpiresAt,
+			&token.CreatedAt, &token.UpdatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		res = append(res, token)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, int32(len(res)), nil
+}
+func (r *AccessToken) UpdateLastUsed(tx godb.Queryer, ctx context.Context, id int32) error {
+	q := gosql.NewUpdate().Table("access_tokens")
+	q.Set().Add("last_used_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *AccessToken) DeleteByUserID(tx godb.Queryer, ctx context.Context, userID, id int32) error {
+	q := gosql.NewUpdate().Table("access_tokens")
+	q.Set().Add("deleted_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *AccessToken) DeleteAllByUserID(tx godb.Queryer, ctx context.Context, userID int32) ([]int32, error) {
+	var res []int32
+
+	q := gosql.NewUpdate().Table("access_tokens")
+	q.Set().Add("deleted_at = now()")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int32
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+type IAuthEvent interface {
+	Create(tx godb.Queryer, ctx context.Context, req *dto.CreateAuthEventDTO) (*entity.AuthEvent, error)
+	ListByUserID(tx godb.Queryer, ctx context.Context, userID int32, req *dto.ListAuthEventDTO) ([]*entity.AuthEvent, int32, error)
+}
+
+type AuthEvent struct {
+}
+
+func NewAuthEvent() *AuthEvent {
+	return &AuthEvent{}
+}
+
+func (r *AuthEvent) Create(tx godb.Queryer, ctx context.Context, req *dto.CreateAuthEventDTO) (*entity.AuthEvent, error) {
+	event := &entity.AuthEvent{
+		UserID:    req.UserID,
+		Type:      req.Type,
+		Success:   req.Success,
+		Reason:    req.Reason,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	}
+
+	q := gosql.NewInsert().Into("auth_events")
+	q.Columns().Add("user_id", "type", "success", "reason", "ip", "user_agent")
+	q.Columns().Arg(req.UserID, req.Type, req.Success, req.Reason, req.IP, req.UserAgent)
+	q.Returning().Add("id", "created_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+func (r *AuthEvent) ListByUserID(tx godb.Queryer, ctx context.Context, userID int32, req *dto.ListAuthEventDTO) ([]*entity.AuthEvent, int32, error) {
+	var res []*entity.AuthEvent
+	var total int32
+
+	// Count all events for pagination
+	q := gosql.NewSelect().From("auth_events")
+	q.Columns().Add("count(*)")
+	q.Where().AddExpression("user_id = ?", userID)
+	err := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	limit, offset := utils.GetPagination(req.Limit, req.Page)
+
+	q = gosql.NewSelect().From("auth_events")
+	q.Columns().Add("id", "type", "success", "reason", "ip", "user_agent", "created_at")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.AddOrder("id DESC")
+	q.SetPagination(limit, offset)
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event := &entity.AuthEvent{
+			UserID: userID,
+		}
+		err = rows.Scan(&event.ID, &event.Type, &event.Success, &event.Reason, &event.IP, &event.UserAgent, &event.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		res = append(res, event)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, total, nil
+}
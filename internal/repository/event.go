@@ -2,24 +2,42 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/HardDie/godb/v2"
 	"github.com/dimonrus/gosql"
+	"github.com/lib/pq"
 
 	"github.com/HardDie/event_tracker/internal/dto"
 	"github.com/HardDie/event_tracker/internal/entity"
 )
 
 type IEvent interface {
-	CreateType(tx godb.Queryer, ctx context.Context, userID int32, name string, isVisible bool) (*entity.EventType, error)
+	CreateType(tx godb.Queryer, ctx context.Context, userID int32, name string, isVisible bool, unit string, shareNotes bool, fields []entity.EventField, schedule *entity.EventSchedule) (*entity.EventType, error)
+	GetType(tx godb.Queryer, ctx context.Context, userID, id int32) (*entity.EventType, error)
 	DeleteType(tx godb.Queryer, ctx context.Context, userID, id int32) error
-	ListType(tx godb.Queryer, ctx context.Context, userID int32, onlyVisible bool) ([]*entity.EventType, int32, error)
-	EditType(tx godb.Queryer, ctx context.Context, userID, id int32, name string, isVisible bool) (*entity.EventType, error)
-
-	CreateEvent(tx godb.Queryer, ctx context.Context, userID, eventTypeID int32, date time.Time) (*entity.Event, error)
+	ArchiveType(tx godb.Queryer, ctx context.Context, userID, id int32) error
+	RestoreType(tx godb.Queryer, ctx context.Context, userID, id int32) (*entity.EventType, error)
+	ListType(tx godb.Queryer, ctx context.Context, filter *dto.ListEventTypeFilter) ([]*entity.EventType, int32, error)
+	EditType(tx godb.Queryer, ctx context.Context, userID, id int32, name string, isVisible bool, unit string, shareNotes bool, fields []entity.EventField, schedule *entity.EventSchedule) (*entity.EventType, error)
+	UsedFieldKeys(tx godb.Queryer, ctx context.Context, userID, typeID int32, keys []string) ([]string, error)
+
+	CreateEvent(tx godb.Queryer, ctx context.Context, userID, eventTypeID int32, date time.Time, endedAt *time.Time, value *float64, note string, fields map[string]interface{}) (*entity.Event, error)
+	GetEvent(tx godb.Queryer, ctx context.Context, userID, id int32) (*entity.Event, error)
+	UpdateEvent(tx godb.Queryer, ctx context.Context, userID, id, typeID int32, date time.Time, endedAt *time.Time, value *float64, note string, fields map[string]interface{}) (*entity.Event, error)
+	StartTimer(tx godb.Queryer, ctx context.Context, userID, typeID int32, date time.Time, value *float64, note string, fields map[string]interface{}) (*entity.Event, error)
+	StopTimer(tx godb.Queryer, ctx context.Context, userID, id int32, endedAt time.Time) (*entity.Event, error)
 	DeleteEvent(tx godb.Queryer, ctx context.Context, userID, id int32) error
+	RestoreEvent(tx godb.Queryer, ctx context.Context, userID, id int32) error
+	DeleteEventsByType(tx godb.Queryer, ctx context.Context, userID, typeID int32) ([]int32, error)
+	RestoreEventsByType(tx godb.Queryer, ctx context.Context, userID, typeID int32, deletedAt time.Time) ([]int32, error)
+	ReassignEvents(tx godb.Queryer, ctx context.Context, userID, fromTypeID, toTypeID int32) ([]int32, error)
 	ListEvent(tx godb.Queryer, ctx context.Context, filter *dto.ListEventFilter) ([]*entity.Event, int32, error)
+	EventStats(tx godb.Queryer, ctx context.Context, filter *dto.ListEventFilter) ([]*dto.EventStatsResponseDTO, error)
 
 	FriendsFeed(tx godb.Queryer, ctx context.Context, userID int32) ([]*dto.FeedResponseDTO, int32, error)
 }
@@ -30,19 +48,63 @@ func NewEvent() *Event {
 	return &Event{}
 }
 
-func (r *Event) CreateType(tx godb.Queryer, ctx context.Context, userID int32, name string, isVisible bool) (*entity.EventType, error) {
+func (r *Event) CreateType(tx godb.Queryer, ctx context.Context, userID int32, name string, isVisible bool, unit string, shareNotes bool, fields []entity.EventField, schedule *entity.EventSchedule) (*entity.EventType, error) {
 	eventType := &entity.EventType{
-		EventType: name,
-		IsVisible: isVisible,
+		UserID:     userID,
+		EventType:  name,
+		IsVisible:  isVisible,
+		Unit:       unit,
+		ShareNotes: shareNotes,
+		Fields:     schemaOrEmpty(fields),
+		Schedule:   schedule,
+	}
+
+	schema, err := json.Marshal(eventType.Fields)
+	if err != nil {
+		return nil, err
+	}
+	scheduleArg, err := scheduleOrNull(schedule)
+	if err != nil {
+		return nil, err
 	}
 
 	q := gosql.NewInsert().Into("event_types")
-	q.Columns().Add("event_type", "is_visible", "user_id")
-	q.Columns().Arg(name, isVisible, userID)
+	q.Columns().Add("event_type", "is_visible", "unit", "share_notes", "fields", "schedule", "user_id")
+	q.Columns().Arg(name, isVisible, unit, shareNotes, string(schema), scheduleArg, userID)
 	q.Returning().Add("id", "created_at", "updated_at")
 	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
 
-	err := row.Scan(&eventType.ID, &eventType.CreatedAt, &eventType.UpdatedAt)
+	err = row.Scan(&eventType.ID, &eventType.CreatedAt, &eventType.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return eventType, nil
+}
+func (r *Event) GetType(tx godb.Queryer, ctx context.Context, userID, id int32) (*entity.EventType, error) {
+	eventType := &entity.EventType{
+		ID:     id,
+		UserID: userID,
+	}
+
+	var schema, schedule []byte
+
+	q := gosql.NewSelect().From("event_types")
+	q.Columns().Add("event_type", "is_visible", "unit", "share_notes", "fields", "schedule", "archived_at", "created_at",
+		"updated_at", "deleted_at")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&eventType.EventType, &eventType.IsVisible, &eventType.Unit, &eventType.ShareNotes, &schema, &schedule,
+		&eventType.ArchivedAt, &eventType.CreatedAt, &eventType.UpdatedAt, &eventType.DeletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	err = unmarshalType(eventType, schema, schedule)
 	if err != nil {
 		return nil, err
 	}
@@ -63,16 +125,69 @@ func (r *Event) DeleteType(tx godb.Queryer, ctx context.Context, userID, id int3
 	}
 	return nil
 }
-func (r *Event) ListType(tx godb.Queryer, ctx context.Context, userID int32, onlyVisible bool) ([]*entity.EventType, int32, error) {
+func (r *Event) ArchiveType(tx godb.Queryer, ctx context.Context, userID, id int32) error {
+	q := gosql.NewUpdate().Table("event_types")
+	q.Set().Add("archived_at = now()")
+	q.Set().Add("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("archived_at IS NULL")
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *Event) RestoreType(tx godb.Queryer, ctx context.Context, userID, id int32) (*entity.EventType, error) {
+	eventType := &entity.EventType{
+		ID:     id,
+		UserID: userID,
+	}
+
+	q := gosql.NewUpdate().Table("event_types")
+	q.Set().Add("archived_at = NULL")
+	q.Set().Add("deleted_at = NULL")
+	q.Set().Add("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Returning().Add("event_type", "is_visible", "unit", "share_notes", "fields", "schedule", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	var schema, schedule []byte
+	err := row.Scan(&eventType.EventType, &eventType.IsVisible, &eventType.Unit, &eventType.ShareNotes, &schema, &schedule,
+		&eventType.CreatedAt, &eventType.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = unmarshalType(eventType, schema, schedule)
+	if err != nil {
+		return nil, err
+	}
+	return eventType, nil
+}
+func (r *Event) ListType(tx godb.Queryer, ctx context.Context, filter *dto.ListEventTypeFilter) ([]*entity.EventType, int32, error) {
 	var res []*entity.EventType
 
 	q := gosql.NewSelect().From("event_types")
-	q.Columns().Add("id", "user_id", "event_type", "is_visible", "created_at", "updated_at")
+	q.Columns().Add("id", "user_id", "event_type", "is_visible", "unit", "share_notes", "fields", "schedule", "archived_at",
+		"created_at", "updated_at")
 	q.Where().AddExpression("deleted_at IS NULL")
-	q.Where().AddExpression("user_id = ?", userID)
-	if onlyVisible {
+	q.Where().AddExpression("user_id = ?", filter.UserID)
+	if filter.OnlyVisible {
 		q.Where().AddExpression("is_visible")
 	}
+	if filter.Archived != nil {
+		if *filter.Archived {
+			q.Where().AddExpression("archived_at IS NOT NULL")
+		} else {
+			q.Where().AddExpression("archived_at IS NULL")
+		}
+	}
 	q.AddOrder("event_type")
 	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
 	if err != nil {
@@ -82,7 +197,13 @@ func (r *Event) ListType(tx godb.Queryer, ctx context.Context, userID int32, onl
 
 	for rows.Next() {
 		eventType := &entity.EventType{}
-		err = rows.Scan(&eventType.ID, &eventType.UserID, &eventType.EventType, &eventType.IsVisible, &eventType.CreatedAt, &eventType.UpdatedAt)
+		var schema, schedule []byte
+		err = rows.Scan(&eventType.ID, &eventType.UserID, &eventType.EventType, &eventType.IsVisible, &eventType.Unit,
+			&eventType.ShareNotes, &schema, &schedule, &eventType.ArchivedAt, &eventType.CreatedAt, &eventType.UpdatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		err = unmarshalType(eventType, schema, schedule)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -96,46 +217,233 @@ func (r *Event) ListType(tx godb.Queryer, ctx context.Context, userID int32, onl
 
 	return res, int32(len(res)), nil
 }
-func (r *Event) EditType(tx godb.Queryer, ctx context.Context, userID, id int32, name string, isVisible bool) (*entity.EventType, error) {
+func (r *Event) EditType(tx godb.Queryer, ctx context.Context, userID, id int32, name string, isVisible bool, unit string, shareNotes bool, fields []entity.EventField, schedule *entity.EventSchedule) (*entity.EventType, error) {
 	eventType := &entity.EventType{
-		ID:        id,
-		UserID:    userID,
-		EventType: name,
-		IsVisible: isVisible,
+		ID:         id,
+		UserID:     userID,
+		EventType:  name,
+		IsVisible:  isVisible,
+		Unit:       unit,
+		ShareNotes: shareNotes,
+		Fields:     schemaOrEmpty(fields),
+		Schedule:   schedule,
+	}
+
+	schema, err := json.Marshal(eventType.Fields)
+	if err != nil {
+		return nil, err
+	}
+	scheduleArg, err := scheduleOrNull(schedule)
+	if err != nil {
+		return nil, err
 	}
 
 	q := gosql.NewUpdate().Table("event_types")
 	q.Set().Append("event_type = ?", name)
 	q.Set().Append("is_visible = ?", isVisible)
+	q.Set().Append("unit = ?", unit)
+	q.Set().Append("share_notes = ?", shareNotes)
+	q.Set().Append("fields = ?", string(schema))
+	q.Set().Append("schedule = ?", scheduleArg)
 	q.Set().Append("updated_at = now()")
 	q.Where().AddExpression("id = ?", id)
 	q.Where().AddExpression("user_id = ?", userID)
 	q.Where().AddExpression("deleted_at IS NULL")
-	q.Returning().Add("created_at", "updated_at")
+	q.Returning().Add("archived_at", "created_at", "updated_at")
 	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
 
-	err := row.Scan(&eventType.CreatedAt, &eventType.UpdatedAt)
+	err = row.Scan(&eventType.ArchivedAt, &eventType.CreatedAt, &eventType.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return eventType, nil
 }
 
-func (r *Event) CreateEvent(tx godb.Queryer, ctx context.Context, userID, typeID int32, date time.Time) (*entity.Event, error) {
-	date = timeToYMD(date)
+// UsedFieldKeys returns the keys from the list which events of the type have, deleted events included
+func (r *Event) UsedFieldKeys(tx godb.Queryer, ctx context.Context, userID, typeID int32, keys []string) ([]string, error) {
+	var res []string
+
+	q := gosql.NewSelect().From("events e")
+	q.Relate("CROSS JOIN LATERAL jsonb_object_keys(e.fields) k")
+	q.Columns().Add("k")
+	q.Where().AddExpression("e.user_id = ?", userID)
+	q.Where().AddExpression("e.type_id = ?", typeID)
+	q.Where().AddExpression("k = ANY(?)", pq.Array(keys))
+	q.GroupBy("k")
+
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		err = rows.Scan(&key)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, key)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *Event) CreateEvent(tx godb.Queryer, ctx context.Context, userID, typeID int32, date time.Time, endedAt *time.Time, value *float64, note string, fields map[string]interface{}) (*entity.Event, error) {
 	event := &entity.Event{
+		UserID:  userID,
+		TypeID:  typeID,
+		Date:    date,
+		EndedAt: endedAt,
+		Value:   value,
+		Note:    note,
+		Fields:  valuesOrEmpty(fields),
+	}
+
+	values, err := json.Marshal(event.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	q := gosql.NewInsert().Into("events")
+	q.Columns().Add("user_id", "type_id", "date", "ended_at", "value", "note", "fields")
+	q.Columns().Arg(userID, typeID, date, endedAt, value, note, string(values))
+	q.Returning().Add("id", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err = row.Scan(&event.ID, &event.CreatedAt, &event.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+func (r *Event) GetEvent(tx godb.Queryer, ctx context.Context, userID, id int32) (*entity.Event, error) {
+	event := &entity.Event{
+		ID:     id,
 		UserID: userID,
-		TypeID: typeID,
-		Date:   date,
+	}
+
+	var values []byte
+
+	q := gosql.NewSelect().From("events")
+	q.Columns().Add("type_id", "date", "ended_at", "running", "value", "note", "fields", "created_at", "updated_at",
+		"deleted_at")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&event.TypeID, &event.Date, &event.EndedAt, &event.Running, &event.Value, &event.Note, &values,
+		&event.CreatedAt, &event.UpdatedAt, &event.DeletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	err = json.Unmarshal(values, &event.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+func (r *Event) UpdateEvent(tx godb.Queryer, ctx context.Context, userID, id, typeID int32, date time.Time, endedAt *time.Time, value *float64, note string, fields map[string]interface{}) (*entity.Event, error) {
+	event := &entity.Event{
+		ID:      id,
+		UserID:  userID,
+		TypeID:  typeID,
+		Date:    date,
+		EndedAt: endedAt,
+		Value:   value,
+		Note:    note,
+		Fields:  valuesOrEmpty(fields),
+	}
+
+	values, err := json.Marshal(event.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	q := gosql.NewUpdate().Table("events")
+	q.Set().Append("type_id = ?", typeID)
+	q.Set().Append("date = ?", date)
+	q.Set().Append("ended_at = ?", endedAt)
+	// A running timer stops when it gets an end
+	q.Set().Append("running = running AND ?", endedAt == nil)
+	q.Set().Append("value = ?", value)
+	q.Set().Append("note = ?", note)
+	q.Set().Append("fields = ?", string(values))
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("running", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err = row.Scan(&event.Running, &event.CreatedAt, &event.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+func (r *Event) StartTimer(tx godb.Queryer, ctx context.Context, userID, typeID int32, date time.Time, value *float64, note string, fields map[string]interface{}) (*entity.Event, error) {
+	event := &entity.Event{
+		UserID:  userID,
+		TypeID:  typeID,
+		Date:    date,
+		Running: true,
+		Value:   value,
+		Note:    note,
+		Fields:  valuesOrEmpty(fields),
+	}
+
+	values, err := json.Marshal(event.Fields)
+	if err != nil {
+		return nil, err
 	}
 
 	q := gosql.NewInsert().Into("events")
-	q.Columns().Add("user_id", "type_id", "date")
-	q.Columns().Arg(userID, typeID, date)
+	q.Columns().Add("user_id", "type_id", "date", "running", "value", "note", "fields")
+	q.Columns().Arg(userID, typeID, date, true, value, note, string(values))
 	q.Returning().Add("id", "created_at", "updated_at")
 	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
 
-	err := row.Scan(&event.ID, &event.CreatedAt, &event.UpdatedAt)
+	err = row.Scan(&event.ID, &event.CreatedAt, &event.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+func (r *Event) StopTimer(tx godb.Queryer, ctx context.Context, userID, id int32, endedAt time.Time) (*entity.Event, error) {
+	event := &entity.Event{
+		ID:      id,
+		UserID:  userID,
+		EndedAt: &endedAt,
+	}
+	var values []byte
+
+	q := gosql.NewUpdate().Table("events")
+	q.Set().Append("ended_at = ?", endedAt)
+	q.Set().Add("running = false")
+	q.Set().Add("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("running")
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("type_id", "date", "value", "note", "fields", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&event.TypeID, &event.Date, &event.Value, &event.Note, &values, &event.CreatedAt, &event.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(values, &event.Fields)
 	if err != nil {
 		return nil, err
 	}
@@ -144,6 +452,9 @@ func (r *Event) CreateEvent(tx godb.Queryer, ctx context.Context, userID, typeID
 func (r *Event) DeleteEvent(tx godb.Queryer, ctx context.Context, userID, id int32) error {
 	q := gosql.NewUpdate().Table("events")
 	q.Set().Add("deleted_at = now()")
+	// A deleted timer is stopped, so another one can be started and the restored event has an end
+	q.Set().Add("ended_at = CASE WHEN running THEN now() ELSE ended_at END")
+	q.Set().Add("running = false")
 	q.Where().AddExpression("id = ?", id)
 	q.Where().AddExpression("user_id = ?", userID)
 	q.Where().AddExpression("deleted_at IS NULL")
@@ -156,33 +467,133 @@ func (r *Event) DeleteEvent(tx godb.Queryer, ctx context.Context, userID, id int
 	}
 	return nil
 }
+func (r *Event) RestoreEvent(tx godb.Queryer, ctx context.Context, userID, id int32) error {
+	q := gosql.NewUpdate().Table("events")
+	q.Set().Add("deleted_at = NULL")
+	q.Set().Add("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NOT NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *Event) DeleteEventsByType(tx godb.Queryer, ctx context.Context, userID, typeID int32) ([]int32, error) {
+	var res []int32
+
+	q := gosql.NewUpdate().Table("events")
+	q.Set().Add("deleted_at = now()")
+	q.Set().Add("ended_at = CASE WHEN running THEN now() ELSE ended_at END")
+	q.Set().Add("running = false")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("type_id = ?", typeID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int32
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+func (r *Event) RestoreEventsByType(tx godb.Queryer, ctx context.Context, userID, typeID int32, deletedAt time.Time) ([]int32, error) {
+	var res []int32
+
+	q := gosql.NewUpdate().Table("events")
+	q.Set().Add("deleted_at = NULL")
+	q.Set().Add("updated_at = now()")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("type_id = ?", typeID)
+	q.Where().AddExpression("deleted_at = ?", deletedAt)
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int32
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+func (r *Event) ReassignEvents(tx godb.Queryer, ctx context.Context, userID, fromTypeID, toTypeID int32) ([]int32, error) {
+	var res []int32
+
+	q := gosql.NewUpdate().Table("events")
+	q.Set().Append("type_id = ?", toTypeID)
+	q.Set().Add("updated_at = now()")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("type_id = ?", fromTypeID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int32
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
 func (r *Event) ListEvent(tx godb.Queryer, ctx context.Context, filter *dto.ListEventFilter) ([]*entity.Event, int32, error) {
 	var res []*entity.Event
 
+	note := "e.note"
+	if filter.ViewerID != nil {
+		// Notes of another user are private unless the type shares them
+		note = "CASE WHEN et.share_notes THEN e.note ELSE '' END"
+	}
+
 	q := gosql.NewSelect().From("events e")
 	q.Relate("JOIN event_types et ON e.type_id = et.id")
-	q.Columns().Add("e.id", "e.user_id", "e.type_id", "e.date", "e.created_at", "e.updated_at")
-	q.Where().AddExpression("e.deleted_at IS NULL")
-	q.Where().AddExpression("et.deleted_at IS NULL")
-	q.Where().AddExpression("e.user_id = ?", filter.UserID)
-	if filter.TypeID != nil {
-		q.Where().AddExpression("e.type_id = ?", filter.TypeID)
-	}
-	if filter.OnlyVisible {
-		q.Where().AddExpression("et.is_visible")
-	}
-	switch filter.PeriodType {
-	case dto.PeriodDay:
-		q.Where().AddExpression("e.date = ?", timeToYMD(filter.Date))
-	case dto.PeriodMonth:
-		first, last := timeToYM(filter.Date)
-		q.Where().AddExpression("e.date >= ?", first)
-		q.Where().AddExpression("e.date <= ?", last)
-	case dto.PeriodYear:
-		first, last := timeToY(filter.Date)
-		q.Where().AddExpression("e.date >= ?", first)
-		q.Where().AddExpression("e.date <= ?", last)
-	}
+	q.Columns().Add("e.id", "e.user_id", "e.type_id", "e.date", "e.ended_at", "e.running", "e.value", note, "e.fields",
+		"e.created_at", "e.updated_at")
+	filterEvents(q, filter)
 	q.AddOrder("e.created_at")
 
 	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
@@ -193,7 +604,13 @@ func (r *Event) ListEvent(tx godb.Queryer, ctx context.Context, filter *dto.List
 
 	for rows.Next() {
 		event := &entity.Event{}
-		err = rows.Scan(&event.ID, &event.UserID, &event.TypeID, &event.Date, &event.CreatedAt, &event.UpdatedAt)
+		var values []byte
+		err = rows.Scan(&event.ID, &event.UserID, &event.TypeID, &event.Date, &event.EndedAt, &event.Running, &event.Value,
+			&event.Note, &values, &event.CreatedAt, &event.UpdatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		err = json.Unmarshal(values, &event.Fields)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -207,22 +624,48 @@ func (r *Event) ListEvent(tx godb.Queryer, ctx context.Context, filter *dto.List
 
 	return res, int32(len(res)), nil
 }
+func (r *Event) EventStats(tx godb.Queryer, ctx context.Context, filter *dto.ListEventFilter) ([]*dto.EventStatsResponseDTO, error) {
+	var res []*dto.EventStatsResponseDTO
+
+	q := gosql.NewSelect().From("events e")
+	q.Relate("JOIN event_types et ON e.type_id = et.id")
+	// Events are grouped by the day in the time zone of the filter, the zone is a checked IANA name
+	day := fmt.Sprintf("(e.date AT TIME ZONE %s)::date", pq.QuoteLiteral(filterLocation(filter).String()))
+	// Aggregate functions skip NULL, so events without a value don't affect the sum and the average
+	q.Columns().Add(day, "e.type_id", "count(*)", "count(e.value)", "sum(e.value)", "avg(e.value)",
+		"min(e.value)", "max(e.value)")
+	filterEvents(q, filter)
+	q.GroupBy(day, "e.type_id")
+	q.AddOrder(day, "e.type_id")
+
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		stats := &dto.EventStatsResponseDTO{}
+		err = rows.Scan(&stats.Date, &stats.EventTypeID, &stats.Count, &stats.ValueCount, &stats.Sum, &stats.Avg,
+			&stats.Min, &stats.Max)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, stats)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
 
 func (r *Event) FriendsFeed(tx godb.Queryer, ctx context.Context, userID int32) ([]*dto.FeedResponseDTO, int32, error) {
 	var res []*dto.FeedResponseDTO
 
-	q := gosql.NewSelect().From("friends f")
-	q.Columns().Add("e.id", "u.id", "et.id", "et.event_type", "e.date", "e.created_at")
-	q.Relate("JOIN users u ON f.with_user_id = u.id")
-	q.Relate("JOIN events e ON f.with_user_id = e.user_id")
-	q.Relate("JOIN event_types et ON e.type_id = et.id")
-	q.Where().AddExpression("f.user_id = ?", userID)
-	q.Where().AddExpression("f.deleted_at IS NULL")
-	q.Where().AddExpression("u.deleted_at IS NULL")
-	q.Where().AddExpression("et.deleted_at IS NULL")
-	q.Where().AddExpression("et.is_visible")
-	q.AddOrder("e.id DESC")
-	q.SetPagination(100, 0)
+	q := friendsFeedQuery(userID)
 
 	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
 	if err != nil {
@@ -232,7 +675,8 @@ func (r *Event) FriendsFeed(tx godb.Queryer, ctx context.Context, userID int32)
 
 	for rows.Next() {
 		event := &dto.FeedResponseDTO{}
-		err = rows.Scan(&event.EventID, &event.UserID, &event.EventTypeID, &event.EventType, &event.Date, &event.CreatedAt)
+		err = rows.Scan(&event.EventID, &event.UserID, &event.EventTypeID, &event.EventType, &event.Date, &event.Note,
+			&event.CreatedAt)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -247,15 +691,129 @@ func (r *Event) FriendsFeed(tx godb.Queryer, ctx context.Context, userID int32)
 	return res, int32(len(res)), nil
 }
 
-func timeToYMD(date time.Time) time.Time {
-	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+// friendsFeedQuery selects the latest events of friends, only visible and not archived types are shown
+func friendsFeedQuery(userID int32) *gosql.Select {
+	q := gosql.NewSelect().From("friends f")
+	// Notes are private unless the type shares them
+	q.Columns().Add("e.id", "u.id", "et.id", "et.event_type", "e.date", "CASE WHEN et.share_notes THEN e.note ELSE '' END",
+		"e.created_at")
+	q.Relate("JOIN users u ON f.with_user_id = u.id")
+	q.Relate("JOIN events e ON f.with_user_id = e.user_id")
+	q.Relate("JOIN event_types et ON e.type_id = et.id")
+	q.Where().AddExpression("f.user_id = ?", userID)
+	q.Where().AddExpression("f.deleted_at IS NULL")
+	q.Where().AddExpression("u.deleted_at IS NULL")
+	q.Where().AddExpression("e.deleted_at IS NULL")
+	q.Where().AddExpression("et.deleted_at IS NULL")
+	q.Where().AddExpression("et.archived_at IS NULL")
+	q.Where().AddExpression("et.is_visible")
+	q.AddOrder("e.id DESC")
+	q.SetPagination(100, 0)
+	return q
+}
+
+func filterEvents(q *gosql.Select, filter *dto.ListEventFilter) {
+	q.Where().AddExpression("e.deleted_at IS NULL")
+	q.Where().AddExpression("et.deleted_at IS NULL")
+	q.Where().AddExpression("e.user_id = ?", filter.UserID)
+	if filter.TypeID != nil {
+		q.Where().AddExpression("e.type_id = ?", filter.TypeID)
+	}
+	if filter.OnlyVisible {
+		q.Where().AddExpression("et.is_visible")
+	}
+	if filter.ViewerID != nil {
+		q.Where().AddExpression("EXISTS (SELECT 1 FROM friends f WHERE f.user_id = ? AND f.with_user_id = e.user_id "+
+			"AND f.deleted_at IS NULL)", *filter.ViewerID)
+	}
+	if filter.PeriodType != 0 {
+		from, to := filter.PeriodType.Bounds(filter.Date, filterLocation(filter))
+		q.Where().AddExpression("e.date >= ?", from)
+		q.Where().AddExpression("e.date < ?", to)
+	}
+	if filter.MinValue != nil {
+		q.Where().AddExpression("e.value >= ?", *filter.MinValue)
+	}
+	if filter.MaxValue != nil {
+		q.Where().AddExpression("e.value <= ?", *filter.MaxValue)
+	}
+	for _, field := range filter.Fields {
+		filterField(q, field)
+	}
+	if filter.OverlapFrom != nil {
+		// Events without an end are points in time and don't overlap anything
+		q.Where().AddExpression("(e.running OR e.ended_at > ?)", *filter.OverlapFrom)
+		if filter.OverlapTo != nil {
+			q.Where().AddExpression("e.date < ?", *filter.OverlapTo)
+		}
+	}
+	if filter.OnlyRunning {
+		q.Where().AddExpression("e.running")
+	}
 }
-func timeToYM(date time.Time) (time.Time, time.Time) {
-	firstDayOfMonth := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
-	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
-	return firstDayOfMonth, lastDayOfMonth
+
+var fieldOperators = map[string]string{
+	dto.FieldOpEq:  "=",
+	dto.FieldOpNe:  "<>",
+	dto.FieldOpGt:  ">",
+	dto.FieldOpGte: ">=",
+	dto.FieldOpLt:  "<",
+	dto.FieldOpLte: "<=",
+}
+
+// filterField compares the value of a custom field, events without the field or with a value
+// of another type, e.g. written before the schema was changed, never match
+func filterField(q *gosql.Select, filter *dto.FieldFilterDTO) {
+	op := fieldOperators[filter.Op]
+	switch value := filter.Value.(type) {
+	case float64:
+		q.Where().AddExpression(fmt.Sprintf("CASE WHEN jsonb_typeof(e.fields->?::text) = 'number' "+
+			"THEN (e.fields->>?::text)::numeric END %s ?", op), filter.Key, filter.Key, value)
+	case string:
+		q.Where().AddExpression(fmt.Sprintf("e.fields->?::text %s to_jsonb(?::text)", op), filter.Key, value)
+	case bool:
+		q.Where().AddExpression(fmt.Sprintf("e.fields->?::text %s to_jsonb(?::boolean)", op), filter.Key, value)
+	}
 }
-func timeToY(date time.Time) (time.Time, time.Time) {
-	return time.Date(date.Year(), 1, 1, 0, 0, 0, 0, time.UTC),
-		time.Date(date.Year(), 12, 31, 0, 0, 0, 0, time.UTC)
+
+// unmarshalType reads the JSON columns of the type, the schedule is NULL for types without one
+func unmarshalType(eventType *entity.EventType, schema, schedule []byte) error {
+	err := json.Unmarshal(schema, &eventType.Fields)
+	if err != nil {
+		return err
+	}
+	if schedule == nil {
+		return nil
+	}
+	eventType.Schedule = &entity.EventSchedule{}
+	return json.Unmarshal(schedule, eventType.Schedule)
+}
+func scheduleOrNull(value *entity.EventSchedule) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+func schemaOrEmpty(fields []entity.EventField) []entity.EventField {
+	if fields == nil {
+		return []entity.EventField{}
+	}
+	return fields
+}
+func valuesOrEmpty(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return map[string]interface{}{}
+	}
+	return fields
+}
+
+func filterLocation(filter *dto.ListEventFilter) *time.Location {
+	if filter.Location == nil {
+		return time.UTC
+	}
+	return filter.Location
 }
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFriendsFeedQuery(t *testing.T) {
+	q := friendsFeedQuery(7)
+	query := q.String()
+
+	// Events of deleted, archived and hidden types aren't shown to friends
+	for _, condition := range []string{
+		"f.user_id = ?",
+		"f.deleted_at IS NULL",
+		"u.deleted_at IS NULL",
+		"e.deleted_at IS NULL",
+		"et.deleted_at IS NULL",
+		"et.archived_at IS NULL",
+		"et.is_visible",
+	} {
+		if !strings.Contains(query, condition) {
+			t.Errorf("feed query has no %q condition: %s", condition, query)
+		}
+	}
+	if !strings.Contains(query, "CASE WHEN et.share_notes THEN e.note ELSE '' END") {
+		t.Errorf("feed query returns notes of types which don't share them: %s", query)
+	}
+
+	args := q.GetArguments()
+	if len(args) != 1 || args[0] != int32(7) {
+		t.Errorf("arguments = %v, want [7]", args)
+	}
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+type IExport interface {
+	Create(tx godb.Queryer, ctx context.Context, userID int32, expiresAt time.Time) (*entity.Export, error)
+	GetByID(tx godb.Queryer, ctx context.Context, id int32) (*entity.Export, error)
+	GetPendingByUserID(tx godb.Queryer, ctx context.Context, userID int32, now time.Time) (*entity.Export, error)
+	GetArchive(tx godb.Queryer, ctx context.Context, id int32) ([]byte, error)
+	Complete(tx godb.Queryer, ctx context.Context, id int32, archive []byte, expiresAt time.Time) error
+	Fail(tx godb.Queryer, ctx context.Context, id int32) error
+	DeleteExpired(tx godb.Queryer, ctx context.Context, now time.Time) ([]int32, error)
+}
+
+type Export struct {
+}
+
+func NewExport() *Export {
+	return &Export{}
+}
+
+func (r *Export) Create(tx godb.Queryer, ctx context.Context, userID int32, expiresAt time.Time) (*entity.Export, error) {
+	export := &entity.Export{
+		UserID:    userID,
+		Status:    entity.ExportStatusPending,
+		ExpiresAt: expiresAt,
+	}
+
+	q := gosql.NewInsert().Into("exports")
+	q.Columns().Add("user_id", "status", "expires_at")
+	q.Columns().Arg(userID, export.Status, expiresAt)
+	q.Returning().Add("id", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&export.ID, &export.CreatedAt, &export.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+func (r *Export) GetByID(tx godb.Queryer, ctx context.Context, id int32) (*entity.Export, error) {
+	export := &entity.Export{
+		ID: id,
+	}
+
+	q := gosql.NewSelect().From("exports")
+	q.Columns().Add("user_id", "status", "size", "expires_at", "completed_at", "created_at", "updated_at")
+	q.Where().AddExpression("id = ?", id)
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&export.UserID, &export.Status, &export.Size, &export.ExpiresAt, &export.CompletedAt,
+		&export.CreatedAt, &export.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return export, nil
+}
+func (r *Export) GetPendingByUserID(tx godb.Queryer, ctx context.Context, userID int32, now time.Time) (*entity.Export, error) {
+	export := &entity.Export{
+		UserID: userID,
+	}
+
+	q := gosql.NewSelect().From("exports")
+	q.Columns().Add("id", "status", "size", "expires_at", "completed_at", "created_at", "updated_at")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("status = ?", entity.ExportStatusPending)
+	q.Where().AddExpression("expires_at > ?", now)
+	q.AddOrder("id DESC")
+	q.SetPagination(1, 0)
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&export.ID, &export.Status, &export.Size, &export.ExpiresAt, &export.CompletedAt,
+		&export.CreatedAt, &export.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return export, nil
+}
+func (r *Export) GetArchive(tx godb.Queryer, ctx context.Context, id int32) ([]byte, error) {
+	var archive []byte
+
+	q := gosql.NewSelect().From("exports")
+	q.Columns().Add("archive")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("status = ?", entity.ExportStatusReady)
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&archive)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return archive, nil
+}
+func (r *Export) Complete(tx godb.Queryer, ctx context.Context, id int32, archive []byte, expiresAt time.Time) error {
+	q := gosql.NewUpdate().Table("exports")
+	q.Set().Append("status = ?", entity.ExportStatusReady)
+	q.Set().Append("archive = ?", archive)
+	q.Set().Append("size = ?", len(archive))
+	q.Set().Append("expires_at = ?", expiresAt)
+	q.Set().Append("completed_at = now()")
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *Export) Fail(tx godb.Queryer, ctx context.Context, id int32) error {
+	q := gosql.NewUpdate().Table("exports")
+	q.Set().Append("status = ?", entity.ExportStatusFailed)
+	q.Set().Append("completed_at = now()")
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *Export) DeleteExpired(tx godb.Queryer, ctx context.Context, now time.Time) ([]int32, error) {
+	var res []int32
+
+	q := gosql.NewDelete().From("exports")
+	q.Where().AddExpression("expires_at < ?", now)
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetGetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int32
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
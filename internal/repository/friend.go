@@ -15,6 +15,7 @@ import (
 type IFriend interface {
 	CreateInvite(tx godb.Queryer, ctx context.Context, userID, id int32) (*entity.FriendInvite, error)
 	ListPendingInvitations(tx godb.Queryer, ctx context.Context, userID int32) ([]*dto.InviteListResponseDTO, int32, error)
+	ListSentInvitations(tx godb.Queryer, ctx context.Context, userID int32) ([]*dto.InviteListResponseDTO, int32, error)
 	DeleteInvite(tx godb.Queryer, ctx context.Context, userID, id int32) error
 	GetInviteByUserID(tx godb.Queryer, ctx context.Context, userID, withUserID int32) (*entity.FriendInvite, error)
 	GetInviteByID(tx godb.Queryer, ctx context.Context, userID, id int32) (*entity.FriendInvite, error)
@@ -81,6 +82,38 @@ func (r *Friend) ListPendingInvitations(tx godb.Queryer, ctx context.Context, us
 
 	return res, int32(len(res)), nil
 }
+func (r *Friend) ListSentInvitations(tx godb.Queryer, ctx context.Context, userID int32) ([]*dto.InviteListResponseDTO, int32, error) {
+	var res []*dto.InviteListResponseDTO
+
+	q := gosql.NewSelect().From("friend_invites fi")
+	q.Columns().Add("fi.id", "fi.with_user_id", "u.displayed_name", "u.profile_image", "fi.created_at")
+	q.Relate("JOIN users u ON fi.with_user_id = u.id")
+	q.Where().AddExpression("fi.user_id = ?", userID)
+	q.Where().AddExpression("fi.deleted_at IS NULL")
+	q.Where().AddExpression("u.deleted_at IS NULL")
+	q.AddOrder("fi.id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		friendRequest := &dto.InviteListResponseDTO{}
+		err = rows.Scan(&friendRequest.ID, &friendRequest.User.ID, &friendRequest.User.DisplayedName, &friendRequest.User.ProfileImage, &friendRequest.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		res = append(res, friendRequest)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, int32(len(res)), nil
+}
 func (r *Friend) DeleteInvite(tx godb.Queryer, ctx context.Context, userID, inviteID int32) error {
 	q := gosql.NewUpdate().Table("friend_invites")
 	q.Set().Add("deleted_at = now()")
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+	"github.com/lib/pq"
+
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+type IGoal interface {
+	Create(tx godb.Queryer, ctx context.Context, userID, typeID int32, period, metric, comparison string, target float64) (*entity.Goal, error)
+	Get(tx godb.Queryer, ctx context.Context, userID, id int32) (*entity.Goal, error)
+	List(tx godb.Queryer, ctx context.Context, userID int32) ([]*entity.Goal, int32, error)
+	Update(tx godb.Queryer, ctx context.Context, userID, id, typeID int32, period, metric, comparison string, target float64) (*entity.Goal, error)
+	Delete(tx godb.Queryer, ctx context.Context, userID, id int32) error
+	Totals(tx godb.Queryer, ctx context.Context, goal *entity.Goal, from, to time.Time, loc *time.Location) ([]*dto.GoalTotalDTO, error)
+}
+
+type Goal struct {
+}
+
+func NewGoal() *Goal {
+	return &Goal{}
+}
+
+func (r *Goal) Create(tx godb.Queryer, ctx context.Context, userID, typeID int32, period, metric, comparison string, target float64) (*entity.Goal, error) {
+	goal := &entity.Goal{
+		UserID:      userID,
+		EventTypeID: typeID,
+		Period:      period,
+		Metric:      metric,
+		Comparison:  comparison,
+		Target:      target,
+	}
+
+	q := gosql.NewInsert().Into("goals")
+	q.Columns().Add("user_id", "type_id", "period", "metric", "comparison", "target")
+	q.Columns().Arg(userID, typeID, period, metric, comparison, target)
+	q.Returning().Add("id", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&goal.ID, &goal.CreatedAt, &goal.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
+func (r *Goal) Get(tx godb.Queryer, ctx context.Context, userID, id int32) (*entity.Goal, error) {
+	goal := &entity.Goal{
+		ID:     id,
+		UserID: userID,
+	}
+
+	q := gosql.NewSelect().From("goals g")
+	q.Relate("JOIN event_types et ON g.type_id = et.id")
+	q.Columns().Add("g.type_id", "g.period", "g.metric", "g.comparison", "g.target", "g.created_at", "g.updated_at")
+	q.Where().AddExpression("g.id = ?", id)
+	q.Where().AddExpression("g.user_id = ?", userID)
+	q.Where().AddExpression("et.deleted_at IS NULL")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&goal.EventTypeID, &goal.Period, &goal.Metric, &goal.Comparison, &goal.Target, &goal.CreatedAt,
+		&goal.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return goal, nil
+}
+func (r *Goal) List(tx godb.Queryer, ctx context.Context, userID int32) ([]*entity.Goal, int32, error) {
+	var res []*entity.Goal
+
+	q := gosql.NewSelect().From("goals g")
+	q.Relate("JOIN event_types et ON g.type_id = et.id")
+	q.Columns().Add("g.id", "g.type_id", "g.period", "g.metric", "g.comparison", "g.target", "g.created_at", "g.updated_at")
+	q.Where().AddExpression("g.user_id = ?", userID)
+	q.Where().AddExpression("et.deleted_at IS NULL")
+	q.AddOrder("g.id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		goal := &entity.Goal{
+			UserID: userID,
+		}
+		err = rows.Scan(&goal.ID, &goal.EventTypeID, &goal.Period, &goal.Metric, &goal.Comparison, &goal.Target,
+			&goal.CreatedAt, &goal.UpdatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		res = append(res, goal)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, int32(len(res)), nil
+}
+func (r *Goal) Update(tx godb.Queryer, ctx context.Context, userID, id, typeID int32, period, metric, comparison string, target float64) (*entity.Goal, error) {
+	goal := &entity.Goal{
+		ID:          id,
+		UserID:      userID,
+		EventTypeID: typeID,
+		Period:      period,
+		Metric:      metric,
+		Comparison:  comparison,
+		Target:      target,
+	}
+
+	q := gosql.NewUpdate().Table("goals")
+	q.Set().Append("type_id = ?", typeID)
+	q.Set().Append("period = ?", period)
+	q.Set().Append("metric = ?", metric)
+	q.Set().Append("comparison = ?", comparison)
+	q.Set().Append("target = ?", target)
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Returning().Add("created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&goal.CreatedAt, &goal.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
+func (r *Goal) Delete(tx godb.Queryer, ctx context.Context, userID, id int32) error {
+	q := gosql.NewDelete().From("goals")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetGetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Totals returns the number and the sum of values of the events of the goal type for each period of the goal
+// between from and to. Periods start in the time zone, weeks start on Monday.
+func (r *Goal) Totals(tx godb.Queryer, ctx context.Context, goal *entity.Goal, from, to time.Time, loc *time.Location) ([]*dto.GoalTotalDTO, error) {
+	var res []*dto.GoalTotalDTO
+
+	// The period is one of the goal constants and the zone is a checked IANA name
+	period := fmt.Sprintf("date_trunc(%s, e.date AT TIME ZONE %s)::date", pq.QuoteLiteral(goal.Period),
+		pq.QuoteLiteral(loc.String()))
+
+	q := gosql.NewSelect().From("events e")
+	q.Columns().Add(period, "count(*)", "COALESCE(sum(e.value), 0)")
+	q.Where().AddExpression("e.user_id = ?", goal.UserID)
+	q.Where().AddExpression("e.type_id = ?", goal.EventTypeID)
+	q.Where().AddExpression("e.deleted_at IS NULL")
+	q.Where().AddExpression("e.date >= ?", from)
+	q.Where().AddExpression("e.date < ?", to)
+	q.GroupBy(period)
+	q.AddOrder(period)
+
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		total := &dto.GoalTotalDTO{}
+		var day time.Time
+		err = rows.Scan(&day, &total.Count, &total.Sum)
+		if err != nil {
+			return nil, err
+		}
+		total.Day = day.Format("2006-01-02")
+		res = append(res, total)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
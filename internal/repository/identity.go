@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+type IIdentity interface {
+	Create(tx godb.Queryer, ctx context.Context, userID int32, provider, subject string, email *string) (*entity.Identity, error)
+	GetBySubject(tx godb.Queryer, ctx context.Context, provider, subject string) (*entity.Identity, error)
+	ListByUserID(tx godb.Queryer, ctx context.Context, userID int32) ([]*entity.Identity, int32, error)
+	UpdateEmail(tx godb.Queryer, ctx context.Context, id int32, email *string) error
+	DeleteByUserID(tx godb.Queryer, ctx context.Context, userID, id int32) error
+
+	CreateState(tx godb.Queryer, ctx context.Context, state *entity.OIDCState) (*entity.OIDCState, error)
+	GetStateByHash(tx godb.Queryer, ctx context.Context, stateHash string) (*entity.OIDCState, error)
+	DeleteState(tx godb.Queryer, ctx context.Context, id int32) error
+	DeleteExpiredStates(tx godb.Queryer, ctx context.Context, now time.Time) (int32, error)
+}
+
+type Identity struct {
+}
+
+func NewIdentity() *Identity {
+	return &Identity{}
+}
+
+func (r *Identity) Create(tx godb.Queryer, ctx context.Context, userID int32, provider, subject string, email *string) (*entity.Identity, error) {
+	identity := &entity.Identity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  subject,
+		Email:    email,
+	}
+
+	q := gosql.NewInsert().Into("identities")
+	q.Columns().Add("user_id", "provider", "subject", "email")
+	q.Columns().Arg(userID, provider, subject, email)
+	q.Returning().Add("id", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&identity.ID, &identity.CreatedAt, &identity.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+func (r *Identity) GetBySubject(tx godb.Queryer, ctx context.Context, provider, subject string) (*entity.Identity, error) {
+	identity := &entity.Identity{
+		Provider: provider,
+		Subject:  subject,
+	}
+
+	q := gosql.NewSelect().From("identities")
+	q.Columns().Add("id", "user_id", "email", "created_at", "updated_at")
+	q.Where().AddExpression("provider = ?", provider)
+	q.Where().AddExpression("subject = ?", subject)
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&identity.ID, &identity.UserID, &identity.Email, &identity.CreatedAt, &identity.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return identity, nil
+}
+func (r *Identity) ListByUserID(tx godb.Queryer, ctx context.Context, userID int32) ([]*entity.Identity, int32, error) {
+	var res []*entity.Identity
+
+	q := gosql.NewSelect().From("identities")
+	q.Columns().Add("id", "provider", "subject", "email", "created_at", "updated_at")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.AddOrder("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		identity := &entity.Identity{
+			UserID: userID,
+		}
+		err = rows.Scan(&identity.ID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt,
+			&identity.UpdatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		res = append(res, identity)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, int32(len(res)), nil
+}
+func (r *Identity) UpdateEmail(tx godb.Queryer, ctx context.Context, id int32, email *string) error {
+	q := gosql.NewUpdate().Table("identities")
+	q.Set().Append("email = ?", email)
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *Identity) DeleteByUserID(tx godb.Queryer, ctx context.Context, userID, id int32) error {
+	q := gosql.NewDelete().From("identities")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetGetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Identity) CreateState(tx godb.Queryer, ctx context.Context, state *entity.OIDCState) (*entity.OIDCState, error) {
+	q := gosql.NewInsert().Into("oidc_states")
+	q.Columns().Add("state_hash", "nonce", "code_verifier", "user_id", "use_cookie", "expires_at")
+	q.Columns().Arg(state.StateHash, state.Nonce, state.CodeVerifier, state.UserID, state.UseCookie, state.ExpiresAt)
+	q.Returning().Add("id", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&state.ID, &state.CreatedAt, &state.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+func (r *Identity) GetStateByHash(tx godb.Queryer, ctx context.Context, stateHash string) (*entity.OIDCState, error) {
+	state := &entity.OIDCState{
+		StateHash: stateHash,
+	}
+
+	q := gosql.NewSelect().From("oidc_states")
+	q.Columns().Add("id", "nonce", "code_verifier", "user_id", "use_cookie", "expires_at", "created_at", "updated_at")
+	q.Where().AddExpression("state_hash = ?", stateHash)
+	q.Where().AddExpression("deleted_at IS NULL")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&state.ID, &state.Nonce, &state.CodeVerifier, &state.UserID, &state.UseCookie, &state.ExpiresAt,
+		&state.CreatedAt, &state.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return state, nil
+}
+func (r *Identity) DeleteState(tx godb.Queryer, ctx context.Context, id int32) error {
+	q := gosql.NewUpdate().Table("oidc_states")
+	q.Set().Add("deleted_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteExpiredStates removes states of logins which were never finished
+func (r *Identity) DeleteExpiredStates(tx godb.Queryer, ctx context.Context, now time.Time) (int32, error) {
+	var count int32
+
+	q := gosql.NewDelete().From("oidc_states")
+	q.Where().AddExpression("expires_at < ?", now)
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetGetArguments()...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		count++
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
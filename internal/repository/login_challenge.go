@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+type ILoginChallenge interface {
+	Create(tx godb.Queryer, ctx context.Context, userID int32, challengeHash string, expiresAt time.Time) (*entity.LoginChallenge, error)
+	GetByHash(tx godb.Queryer, ctx context.Context, challengeHash string) (*entity.LoginChallenge, error)
+	IncreaseFailedAttempts(tx godb.Queryer, ctx context.Context, id int32) (*entity.LoginChallenge, error)
+	DeleteByID(tx godb.Queryer, ctx context.Context, id int32) error
+}
+
+type LoginChallenge struct {
+}
+
+func NewLoginChallenge() *LoginChallenge {
+	return &LoginChallenge{}
+}
+
+func (r *LoginChallenge) Create(tx godb.Queryer, ctx context.Context, userID int32, challengeHash string, expiresAt time.Time) (*entity.LoginChallenge, error) {
+	challenge := &entity.LoginChallenge{
+		UserID:        userID,
+		ChallengeHash: challengeHash,
+		ExpiresAt:     expiresAt,
+	}
+
+	q := gosql.NewInsert().Into("login_challenges")
+	q.Columns().Add("user_id", "challenge_hash", "expires_at")
+	q.Columns().Arg(userID, challengeHash, expiresAt)
+	q.Returning().Add("id", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&challenge.ID, &challenge.CreatedAt, &challenge.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+func (r *LoginChallenge) GetByHash(tx godb.Queryer, ctx context.Context, challengeHash string) (*entity.LoginChallenge, error) {
+	challenge := &entity.LoginChallenge{
+		ChallengeHash: challengeHash,
+	}
+
+	q := gosql.NewSelect().From("login_challenges")
+	q.Columns().Add("id", "user_id", "failed_attempts", "expires_at", "created_at", "updated_at")
+	q.Where().AddExpression("challenge_hash = ?", challengeHash)
+	q.Where().AddExpression("deleted_at IS NULL")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&challenge.ID, &challenge.UserID, &challenge.FailedAttempts, &challenge.ExpiresAt,
+		&challenge.CreatedAt, &challenge.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return challenge, nil
+}
+func (r *LoginChallenge) IncreaseFailedAttempts(tx godb.Queryer, ctx context.Context, id int32) (*entity.LoginChallenge, error) {
+	challenge := &entity.LoginChallenge{
+		ID: id,
+	}
+
+	q := gosql.NewUpdate().Table("login_challenges")
+	q.Set().Add("failed_attempts = failed_attempts + 1")
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("user_id", "challenge_hash", "failed_attempts", "expires_at", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&challenge.UserID, &challenge.ChallengeHash, &challenge.FailedAttempts, &challenge.ExpiresAt,
+		&challenge.CreatedAt, &challenge.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+func (r *LoginChallenge) DeleteByID(tx godb.Queryer, ctx context.Context, id int32) error {
+	q := gosql.NewUpdate().Table("login_challenges")
+	q.Set().Add("deleted_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -17,6 +17,8 @@ type IPassword interface {
 	Update(tx godb.Queryer, ctx context.Context, id int32, passwordHash string) (*entity.Password, error)
 	IncreaseFailedAttempts(tx godb.Queryer, ctx context.Context, id int32) (*entity.Password, error)
 	ResetFailedAttempts(tx godb.Queryer, ctx context.Context, id int32) (*entity.Password, error)
+	Reset(tx godb.Queryer, ctx context.Context, id int32, passwordHash string) (*entity.Password, error)
+	Unblock(tx godb.Queryer, ctx context.Context, userID int32) error
 }
 
 type Password struct {
@@ -122,3 +124,42 @@ func (r *Password) ResetFailedAttempts(tx godb.Queryer, ctx context.Context, id
 	}
 	return password, nil
 }
+func (r *Password) Reset(tx godb.Queryer, ctx context.Context, id int32, passwordHash string) (*entity.Password, error) {
+	password := &entity.Password{
+		ID:           id,
+		PasswordHash: passwordHash,
+	}
+
+	q := gosql.NewUpdate().Table("passwords")
+	q.Set().Append("password_hash = ?", passwordHash)
+	q.Set().Add("failed_attempts = 0")
+	q.Set().Add("blocked_at = NULL")
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("user_id", "failed_attempts", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&password.UserID, &password.FailedAttempts, &password.CreatedAt, &password.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return password, nil
+}
+func (r *Password) Unblock(tx godb.Queryer, ctx context.Context, userID int32) error {
+	q := gosql.NewUpdate().Table("passwords")
+	q.Set().Add("failed_attempts = 0")
+	q.Set().Add("blocked_at = NULL")
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	var id int32
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+)
+
+type IRateLimit interface {
+	Increase(tx godb.Queryer, ctx context.Context, key string, windowStart, expiresAt time.Time) (int32, error)
+	GetCount(tx godb.Queryer, ctx context.Context, key string, windowStart time.Time) (int32, error)
+	DeleteExpired(tx godb.Queryer, ctx context.Context, now time.Time) (int32, error)
+}
+
+type RateLimit struct {
+}
+
+func NewRateLimit() *RateLimit {
+	return &RateLimit{}
+}
+
+func (r *RateLimit) Increase(tx godb.Queryer, ctx context.Context, key string, windowStart, expiresAt time.Time) (int32, error) {
+	var count int32
+
+	q := gosql.NewInsert().Into("rate_limits")
+	q.Columns().Add("key", "window_start", "expires_at")
+	q.Columns().Arg(key, windowStart, expiresAt)
+	q.Conflict().Object("key, window_start").Action("UPDATE").Set().Add("count = rate_limits.count + 1")
+	q.Returning().Add("count")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+func (r *RateLimit) GetCount(tx godb.Queryer, ctx context.Context, key string, windowStart time.Time) (int32, error) {
+	var count int32
+
+	q := gosql.NewSelect().From("rate_limits")
+	q.Columns().Add("count")
+	q.Where().AddExpression("key = ?", key)
+	q.Where().AddExpression("window_start = ?", windowStart)
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&count)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return count, nil
+}
+func (r *RateLimit) DeleteExpired(tx godb.Queryer, ctx context.Context, now time.Time) (int32, error) {
+	var count int32
+
+	q := gosql.NewDelete().From("rate_limits")
+	q.Where().AddExpression("expires_at < ?", now)
+	q.Returning().Add("key")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetGetArguments()...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		count++
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
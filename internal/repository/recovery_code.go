@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+)
+
+type IRecoveryCode interface {
+	Create(tx godb.Queryer, ctx context.Context, userID int32, codeHashes []string) error
+	Use(tx godb.Queryer, ctx context.Context, userID int32, codeHash string) (bool, error)
+	DeleteByUserID(tx godb.Queryer, ctx context.Context, userID int32) error
+}
+
+type RecoveryCode struct {
+}
+
+func NewRecoveryCode() *RecoveryCode {
+	return &RecoveryCode{}
+}
+
+func (r *RecoveryCode) Create(tx godb.Queryer, ctx context.Context, userID int32, codeHashes []string) error {
+	q := gosql.NewInsert().Into("recovery_codes")
+	q.Columns().Add("user_id", "code_hash")
+	for _, codeHash := range codeHashes {
+		q.Columns().Arg(userID, codeHash)
+	}
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+func (r *RecoveryCode) Use(tx godb.Queryer, ctx context.Context, userID int32, codeHash string) (bool, error) {
+	q := gosql.NewUpdate().Table("recovery_codes")
+	q.Set().Add("used_at = now()")
+	q.Set().Add("updated_at = now()")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("code_hash = ?", codeHash)
+	q.Where().AddExpression("used_at IS NULL")
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	var id int32
+	err := row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+func (r *RecoveryCode) DeleteByUserID(tx godb.Queryer, ctx context.Context, userID int32) error {
+	q := gosql.NewUpdate().Table("recovery_codes")
+	q.Set().Add("deleted_at = now()")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+	return rows.Err()
+}
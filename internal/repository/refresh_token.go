@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+type IRefreshToken interface {
+	Create(tx godb.Queryer, ctx context.Context, sessionID int32, tokenHash string, expiresAt time.Time) (*entity.RefreshToken, error)
+	GetByHash(tx godb.Queryer, ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+	MarkUsed(tx godb.Queryer, ctx context.Context, id int32) error
+}
+
+type RefreshToken struct {
+}
+
+func NewRefreshToken() *RefreshToken {
+	return &RefreshToken{}
+}
+
+func (r *RefreshToken) Create(tx godb.Queryer, ctx context.Context, sessionID int32, tokenHash string, expiresAt time.Time) (*entity.RefreshToken, error) {
+	token := &entity.RefreshToken{
+		SessionID: sessionID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+
+	q := gosql.NewInsert().Into("refresh_tokens")
+	q.Columns().Add("session_id", "token_hash", "expires_at")
+	q.Columns().Arg(sessionID, tokenHash, expiresAt)
+	q.Returning().Add("id", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&token.ID, &token.CreatedAt, &token.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+func (r *RefreshToken) GetByHash(tx godb.Queryer, ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	token := &entity.RefreshToken{
+		TokenHash: tokenHash,
+	}
+
+	q := gosql.NewSelect().From("refresh_tokens")
+	q.Columns().Add("id", "session_id", "expires_at", "used_at", "created_at", "updated_at")
+	q.Where().AddExpression("token_hash = ?", tokenHash)
+	q.Where().AddExpression("deleted_at IS NULL")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&token.ID, &token.SessionID, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt, &token.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return token, nil
+}
+func (r *RefreshToken) MarkUsed(tx godb.Queryer, ctx context.Context, id int32) error {
+	q := gosql.NewUpdate().Table("refresh_tokens")
+	q.Set().Add("used_at = now()")
+	q.Set().Add("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("used_at IS NULL")
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/HardDie/godb/v2"
 	"github.com/dimonrus/gosql"
@@ -12,9 +13,17 @@ import (
 )
 
 type ISession interface {
-	CreateOrUpdate(tx godb.Queryer, ctx context.Context, userID int32, sessionHash string) (*entity.Session, error)
+	Create(tx godb.Queryer, ctx context.Context, userID int32, sessionHash, userAgent, ip string) (*entity.Session, error)
 	GetByUserID(tx godb.Queryer, ctx context.Context, sessionHash string) (*entity.Session, error)
+	GetByID(tx godb.Queryer, ctx context.Context, id int32) (*entity.Session, error)
+	ListByUserID(tx godb.Queryer, ctx context.Context, userID int32) ([]*entity.Session, int32, error)
+	UpdateLastSeen(tx godb.Queryer, ctx context.Context, id int32) error
+	UpdateHash(tx godb.Queryer, ctx context.Context, id int32, sessionHash, userAgent, ip string) (*entity.Session, error)
 	DeleteByID(tx godb.Queryer, ctx context.Context, id int32) error
+	DeleteByUserID(tx godb.Queryer, ctx context.Context, userID, id int32) error
+	DeleteOthersByUserID(tx godb.Queryer, ctx context.Context, userID, exceptID int32) ([]int32, error)
+	DeleteAllByUserID(tx godb.Queryer, ctx context.Context, userID int32) ([]int32, error)
+	ListDeletedSince(tx godb.Queryer, ctx context.Context, since time.Time) ([]int32, error)
 }
 
 type Session struct {
@@ -24,21 +33,21 @@ func NewSession() *Session {
 	return &Session{}
 }
 
-func (r *Session) CreateOrUpdate(tx godb.Queryer, ctx context.Context, userID int32, sessionHash string) (*entity.Session, error) {
+func (r *Session) Create(tx godb.Queryer, ctx context.Context, userID int32, sessionHash, userAgent, ip string) (*entity.Session, error) {
 	session := &entity.Session{
 		UserID:      userID,
 		SessionHash: sessionHash,
+		UserAgent:   userAgent,
+		IP:          ip,
 	}
 
 	q := gosql.NewInsert().Into("sessions")
-	q.Columns().Add("user_id", "session_hash")
-	q.Columns().Arg(userID, sessionHash)
-	q.Conflict().Object("user_id").Action("UPDATE").Set().
-		Add("session_hash = EXCLUDED.session_hash", "updated_at = now()", "deleted_at = NULL")
-	q.Returning().Add("id", "created_at", "updated_at")
+	q.Columns().Add("user_id", "session_hash", "user_agent", "ip")
+	q.Columns().Arg(userID, sessionHash, userAgent, ip)
+	q.Returning().Add("id", "last_seen_at", "created_at", "updated_at")
 	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
 
-	err := row.Scan(&session.ID, &session.CreatedAt, &session.UpdatedAt)
+	err := row.Scan(&session.ID, &session.LastSeenAt, &session.CreatedAt, &session.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -50,12 +59,13 @@ func (r *Session) GetByUserID(tx godb.Queryer, ctx context.Context, sessionHash
 	}
 
 	q := gosql.NewSelect().From("sessions")
-	q.Columns().Add("id", "user_id", "created_at", "updated_at")
+	q.Columns().Add("id", "user_id", "user_agent", "ip", "last_seen_at", "created_at", "updated_at")
 	q.Where().AddExpression("session_hash = ?", sessionHash)
 	q.Where().AddExpression("deleted_at IS NULL")
 	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
 
-	err := row.Scan(&session.ID, &session.UserID, &session.CreatedAt, &session.UpdatedAt)
+	err := row.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.LastSeenAt,
+		&session.CreatedAt, &session.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -64,6 +74,97 @@ func (r *Session) GetByUserID(tx godb.Queryer, ctx context.Context, sessionHash
 	}
 	return session, nil
 }
+func (r *Session) GetByID(tx godb.Queryer, ctx context.Context, id int32) (*entity.Session, error) {
+	session := &entity.Session{
+		ID: id,
+	}
+
+	q := gosql.NewSelect().From("sessions")
+	q.Columns().Add("user_id", "user_agent", "ip", "last_seen_at", "created_at", "updated_at")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&session.UserID, &session.UserAgent, &session.IP, &session.LastSeenAt, &session.CreatedAt, &session.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return session, nil
+}
+func (r *Session) ListByUserID(tx godb.Queryer, ctx context.Context, userID int32) ([]*entity.Session, int32, error) {
+	var res []*entity.Session
+
+	q := gosql.NewSelect().From("sessions")
+	q.Columns().Add("id", "user_agent", "ip", "last_seen_at", "created_at", "updated_at")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.AddOrder("last_seen_at DESC")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session := &entity.Session{
+			UserID: userID,
+		}
+		err = rows.Scan(&session.ID, &session.UserAgent, &session.IP, &session.LastSeenAt, &session.CreatedAt, &session.UpdatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		res = append(res, session)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, int32(len(res)), nil
+}
+func (r *Session) UpdateLastSeen(tx godb.Queryer, ctx context.Context, id int32) error {
+	q := gosql.NewUpdate().Table("sessions")
+	q.Set().Add("last_seen_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *Session) UpdateHash(tx godb.Queryer, ctx context.Context, id int32, sessionHash, userAgent, ip string) (*entity.Session, error) {
+	session := &entity.Session{
+		ID:          id,
+		SessionHash: sessionHash,
+		UserAgent:   userAgent,
+		IP:          ip,
+	}
+
+	q := gosql.NewUpdate().Table("sessions")
+	q.Set().Append("session_hash = ?", sessionHash)
+	q.Set().Append("user_agent = ?", userAgent)
+	q.Set().Append("ip = ?", ip)
+	q.Set().Append("last_seen_at = now()")
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("user_id", "last_seen_at", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&session.UserID, &session.LastSeenAt, &session.CreatedAt, &session.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
 func (r *Session) DeleteByID(tx godb.Queryer, ctx context.Context, id int32) error {
 	q := gosql.NewUpdate().Table("sessions")
 	q.Set().Add("deleted_at = now()")
@@ -78,3 +179,107 @@ func (r *Session) DeleteByID(tx godb.Queryer, ctx context.Context, id int32) err
 	}
 	return nil
 }
+func (r *Session) DeleteByUserID(tx godb.Queryer, ctx context.Context, userID, id int32) error {
+	q := gosql.NewUpdate().Table("sessions")
+	q.Set().Add("deleted_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *Session) DeleteOthersByUserID(tx godb.Queryer, ctx context.Context, userID, exceptID int32) ([]int32, error) {
+	var res []int32
+
+	q := gosql.NewUpdate().Table("sessions")
+	q.Set().Add("deleted_at = now()")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("id <> ?", exceptID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int32
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+func (r *Session) DeleteAllByUserID(tx godb.Queryer, ctx context.Context, userID int32) ([]int32, error) {
+	var res []int32
+
+	q := gosql.NewUpdate().Table("sessions")
+	q.Set().Add("deleted_at = now()")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int32
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+func (r *Session) ListDeletedSince(tx godb.Queryer, ctx context.Context, since time.Time) ([]int32, error) {
+	var res []int32
+
+	q := gosql.NewSelect().From("sessions")
+	q.Columns().Add("id")
+	q.Where().AddExpression("deleted_at > ?", since)
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int32
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
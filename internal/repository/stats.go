@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+type IStats interface {
+	Get(tx godb.Queryer, ctx context.Context) (*dto.StatsResponseDTO, error)
+}
+
+type Stats struct {
+}
+
+func NewStats() *Stats {
+	return &Stats{}
+}
+
+func (r *Stats) Get(tx godb.Queryer, ctx context.Context) (*dto.StatsResponseDTO, error) {
+	stats := &dto.StatsResponseDTO{}
+
+	q := gosql.NewSelect()
+	q.Columns().Add(
+		"(SELECT count(*) FROM users WHERE deleted_at IS NULL)",
+		"(SELECT count(*) FROM users WHERE deleted_at IS NULL AND disabled_at IS NOT NULL)",
+		"(SELECT count(*) FROM users WHERE deleted_at IS NOT NULL)",
+		"(SELECT count(*) FROM sessions WHERE deleted_at IS NULL)",
+		"(SELECT count(*) FROM access_tokens WHERE deleted_at IS NULL)",
+		"(SELECT count(*) FROM event_types WHERE deleted_at IS NULL)",
+		"(SELECT count(*) FROM events WHERE deleted_at IS NULL)",
+		// Every friendship is stored in both directions
+		"(SELECT count(*) FROM friends WHERE deleted_at IS NULL AND user_id < with_user_id)",
+		"(SELECT count(*) FROM auth_events WHERE type = '"+entity.AuthEventLogin+"' AND success AND created_at > now() - INTERVAL '1 day')",
+	)
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&stats.Users, &stats.DisabledUsers, &stats.DeletedUsers, &stats.ActiveSessions, &stats.AccessTokens,
+		&stats.EventTypes, &stats.Events, &stats.Friendships, &stats.LoginsLastDay)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
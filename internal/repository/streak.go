@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+	"github.com/lib/pq"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+type IStreak interface {
+	CreateFreeze(tx godb.Queryer, ctx context.Context, userID, typeID int32, date string) (*entity.StreakFreeze, error)
+	GetFreeze(tx godb.Queryer, ctx context.Context, userID, typeID int32, date string) (*entity.StreakFreeze, error)
+	ListFreezes(tx godb.Queryer, ctx context.Context, userID, typeID int32) ([]*entity.StreakFreeze, error)
+	ListAllFreezes(tx godb.Queryer, ctx context.Context, userID int32) ([]*entity.StreakFreeze, error)
+	DeleteFreeze(tx godb.Queryer, ctx context.Context, userID, typeID int32, date string) error
+	EventDays(tx godb.Queryer, ctx context.Context, userID, typeID int32, loc *time.Location) ([]string, error)
+}
+
+type Streak struct {
+}
+
+func NewStreak() *Streak {
+	return &Streak{}
+}
+
+func (r *Streak) CreateFreeze(tx godb.Queryer, ctx context.Context, userID, typeID int32, date string) (*entity.StreakFreeze, error) {
+	freeze := &entity.StreakFreeze{
+		UserID:      userID,
+		EventTypeID: typeID,
+		Date:        date,
+	}
+
+	q := gosql.NewInsert().Into("streak_freezes")
+	q.Columns().Add("user_id", "type_id", "date")
+	q.Columns().Arg(userID, typeID, date)
+	q.Returning().Add("id", "created_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&freeze.ID, &freeze.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return freeze, nil
+}
+func (r *Streak) GetFreeze(tx godb.Queryer, ctx context.Context, userID, typeID int32, date string) (*entity.StreakFreeze, error) {
+	freeze := &entity.StreakFreeze{
+		UserID:      userID,
+		EventTypeID: typeID,
+		Date:        date,
+	}
+
+	q := gosql.NewSelect().From("streak_freezes")
+	q.Columns().Add("id", "created_at")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("type_id = ?", typeID)
+	q.Where().AddExpression("date = ?", date)
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&freeze.ID, &freeze.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return freeze, nil
+}
+func (r *Streak) ListFreezes(tx godb.Queryer, ctx context.Context, userID, typeID int32) ([]*entity.StreakFreeze, error) {
+	var res []*entity.StreakFreeze
+
+	q := gosql.NewSelect().From("streak_freezes")
+	q.Columns().Add("id", "date", "created_at")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("type_id = ?", typeID)
+	q.AddOrder("date")
+
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		freeze := &entity.StreakFreeze{
+			UserID:      userID,
+			EventTypeID: typeID,
+		}
+		var date time.Time
+		err = rows.Scan(&freeze.ID, &date, &freeze.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		freeze.Date = date.Format("2006-01-02")
+		res = append(res, freeze)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+func (r *Streak) ListAllFreezes(tx godb.Queryer, ctx context.Context, userID int32) ([]*entity.StreakFreeze, error) {
+	var res []*entity.StreakFreeze
+
+	q := gosql.NewSelect().From("streak_freezes")
+	q.Columns().Add("id", "type_id", "date", "created_at")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.AddOrder("type_id", "date")
+
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		freeze := &entity.StreakFreeze{
+			UserID: userID,
+		}
+		var date time.Time
+		err = rows.Scan(&freeze.ID, &freeze.EventTypeID, &date, &freeze.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		freeze.Date = date.Format("2006-01-02")
+		res = append(res, freeze)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+func (r *Streak) DeleteFreeze(tx godb.Queryer, ctx context.Context, userID, typeID int32, date string) error {
+	q := gosql.NewDelete().From("streak_freezes")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("type_id = ?", typeID)
+	q.Where().AddExpression("date = ?", date)
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetGetArguments()...)
+
+	var id int32
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// EventDays returns the days in the time zone on which events of the type happened, in ascending order
+func (r *Streak) EventDays(tx godb.Queryer, ctx context.Context, userID, typeID int32, loc *time.Location) ([]string, error) {
+	var res []string
+
+	// The zone is a checked IANA name
+	day := fmt.Sprintf("(e.date AT TIME ZONE %s)::date", pq.QuoteLiteral(loc.String()))
+
+	q := gosql.NewSelect().From("events e")
+	q.Columns().Add(day)
+	q.Where().AddExpression("e.user_id = ?", userID)
+	q.Where().AddExpression("e.type_id = ?", typeID)
+	q.Where().AddExpression("e.deleted_at IS NULL")
+	q.GroupBy(day)
+	q.AddOrder(day)
+
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var date time.Time
+		err = rows.Scan(&date)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, date.Format("2006-01-02"))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
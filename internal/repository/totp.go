@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+)
+
+type ITOTP interface {
+	CreateOrUpdate(tx godb.Queryer, ctx context.Context, userID int32, secret string) (*entity.TOTP, error)
+	GetByUserID(tx godb.Queryer, ctx context.Context, userID int32) (*entity.TOTP, error)
+	Enable(tx godb.Queryer, ctx context.Context, id int32, step int64) error
+	UpdateLastUsedStep(tx godb.Queryer, ctx context.Context, id int32, step int64) error
+	DeleteByID(tx godb.Queryer, ctx context.Context, id int32) error
+}
+
+type TOTP struct {
+}
+
+func NewTOTP() *TOTP {
+	return &TOTP{}
+}
+
+func (r *TOTP) CreateOrUpdate(tx godb.Queryer, ctx context.Context, userID int32, secret string) (*entity.TOTP, error) {
+	totp := &entity.TOTP{
+		UserID: userID,
+		Secret: secret,
+	}
+
+	q := gosql.NewInsert().Into("totp")
+	q.Columns().Add("user_id", "secret")
+	q.Columns().Arg(userID, secret)
+	q.Conflict().Object("user_id").Action("UPDATE").Set().
+		Add("secret = EXCLUDED.secret", "last_used_step = 0", "enabled_at = NULL", "updated_at = now()", "deleted_at = NULL")
+	q.Returning().Add("id", "created_at", "updated_at")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&totp.ID, &totp.CreatedAt, &totp.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return totp, nil
+}
+func (r *TOTP) GetByUserID(tx godb.Queryer, ctx context.Context, userID int32) (*entity.TOTP, error) {
+	totp := &entity.TOTP{
+		UserID: userID,
+	}
+
+	q := gosql.NewSelect().From("totp")
+	q.Columns().Add("id", "secret", "last_used_step", "enabled_at", "created_at", "updated_at")
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NULL")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&totp.ID, &totp.Secret, &totp.LastUsedStep, &totp.EnabledAt, &totp.CreatedAt, &totp.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return totp, nil
+}
+func (r *TOTP) Enable(tx godb.Queryer, ctx context.Context, id int32, step int64) error {
+	q := gosql.NewUpdate().Table("totp")
+	q.Set().Append("enabled_at = now()")
+	q.Set().Append("last_used_step = ?", step)
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *TOTP) UpdateLastUsedStep(tx godb.Queryer, ctx context.Context, id int32, step int64) error {
+	q := gosql.NewUpdate().Table("totp")
+	q.Set().Append("last_used_step = ?", step)
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *TOTP) DeleteByID(tx godb.Queryer, ctx context.Context, id int32) error {
+	q := gosql.NewUpdate().Table("totp")
+	q.Set().Add("deleted_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+	"github.com/dimonrus/gosql"
+
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+type ITrash interface {
+	List(tx godb.Queryer, ctx context.Context, userID int32, req *dto.ListTrashDTO) ([]*dto.TrashItemResponseDTO, int32, error)
+	DeleteEvent(tx godb.Queryer, ctx context.Context, userID, id int32) error
+	DeleteType(tx godb.Queryer, ctx context.Context, userID, id int32) error
+	PurgeEvents(tx godb.Queryer, ctx context.Context, deletedBefore time.Time) ([]int32, error)
+	PurgeTypes(tx godb.Queryer, ctx context.Context, deletedBefore time.Time) ([]int32, error)
+}
+
+type Trash struct {
+}
+
+func NewTrash() *Trash {
+	return &Trash{}
+}
+
+func (r *Trash) List(tx godb.Queryer, ctx context.Context, userID int32, req *dto.ListTrashDTO) ([]*dto.TrashItemResponseDTO, int32, error) {
+	var res []*dto.TrashItemResponseDTO
+	var total int32
+
+	// Count all items for pagination
+	q := gosql.NewSelect().From("trash")
+	q.With().Add("trash", trashItems(userID, req.Kind))
+	q.Columns().Add("count(*)")
+	err := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	limit, offset := utils.GetPagination(req.Limit, req.Page)
+
+	q = gosql.NewSelect().From("trash")
+	q.With().Add("trash", trashItems(userID, req.Kind))
+	q.Columns().Add("kind", "id", "name", "type_id", "date", "deleted_at")
+	q.AddOrder("deleted_at DESC", "id DESC")
+	q.SetPagination(limit, offset)
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := &dto.TrashItemResponseDTO{}
+		err = rows.Scan(&item.Kind, &item.ID, &item.Name, &item.EventTypeID, &item.Date, &item.DeletedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		res = append(res, item)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, total, nil
+}
+func (r *Trash) DeleteEvent(tx godb.Queryer, ctx context.Context, userID, id int32) error {
+	q := gosql.NewDelete().From("events")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NOT NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetGetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteType removes the type with all its events, they are deleted by the foreign key
+func (r *Trash) DeleteType(tx godb.Queryer, ctx context.Context, userID, id int32) error {
+	q := gosql.NewDelete().From("event_types")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("user_id = ?", userID)
+	q.Where().AddExpression("deleted_at IS NOT NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetGetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *Trash) PurgeEvents(tx godb.Queryer, ctx context.Context, deletedBefore time.Time) ([]int32, error) {
+	return purgeDeleted(tx, ctx, "events", deletedBefore)
+}
+func (r *Trash) PurgeTypes(tx godb.Queryer, ctx context.Context, deletedBefore time.Time) ([]int32, error) {
+	return purgeDeleted(tx, ctx, "event_types", deletedBefore)
+}
+
+// trashItems selects deleted events and types of the user in the same shape
+func trashItems(userID int32, kind string) *gosql.Select {
+	events := gosql.NewSelect().From("events e")
+	events.Relate("JOIN event_types et ON e.type_id = et.id")
+	events.Columns().Add("'"+dto.TrashKindEvents+"' AS kind", "e.id", "et.event_type AS name", "e.type_id",
+		"e.date", "e.deleted_at")
+	events.Where().AddExpression("e.user_id = ?", userID)
+	events.Where().AddExpression("e.deleted_at IS NOT NULL")
+
+	types := gosql.NewSelect().From("event_types")
+	types.Columns().Add("'"+dto.TrashKindTypes+"' AS kind", "id", "event_type AS name", "NULL::INT AS type_id",
+		"NULL::TIMESTAMPTZ AS date", "deleted_at")
+	types.Where().AddExpression("user_id = ?", userID)
+	types.Where().AddExpression("deleted_at IS NOT NULL")
+
+	switch kind {
+	case dto.TrashKindEvents:
+		return events
+	case dto.TrashKindTypes:
+		return types
+	}
+	return events.Union(types)
+}
+func purgeDeleted(tx godb.Queryer, ctx context.Context, table string, deletedBefore time.Time) ([]int32, error) {
+	var res []int32
+
+	q := gosql.NewDelete().From(table)
+	q.Where().AddExpression("deleted_at < ?", deletedBefore)
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetGetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int32
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/HardDie/godb/v2"
 	"github.com/dimonrus/gosql"
 
 	"github.com/HardDie/event_tracker/internal/dto"
 	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/utils"
 )
 
 type IUser interface {
@@ -18,6 +20,16 @@ type IUser interface {
 	Create(tx godb.Queryer, ctx context.Context, name, displayedName string) (*entity.User, error)
 	UpdateProfile(tx godb.Queryer, ctx context.Context, req *dto.UpdateProfileDTO) (*entity.User, error)
 	UpdateImage(tx godb.Queryer, ctx context.Context, req *dto.UpdateProfileImageDTO) (*entity.User, error)
+	VerifyEmail(tx godb.Queryer, ctx context.Context, id int32, email string) error
+	GetDeletedByName(tx godb.Queryer, ctx context.Context, name string) (*entity.User, error)
+	GetDeletedByID(tx godb.Queryer, ctx context.Context, id int32) (*entity.User, error)
+	Delete(tx godb.Queryer, ctx context.Context, id int32) error
+	Restore(tx godb.Queryer, ctx context.Context, id int32) error
+	PurgeDeleted(tx godb.Queryer, ctx context.Context, deletedBefore time.Time) ([]int32, error)
+	List(tx godb.Queryer, ctx context.Context, req *dto.ListUsersDTO) ([]*entity.User, int32, error)
+	UpdateRole(tx godb.Queryer, ctx context.Context, id int32, role string) error
+	Disable(tx godb.Queryer, ctx context.Context, id int32) error
+	Enable(tx godb.Queryer, ctx context.Context, id int32) error
 }
 
 type User struct {
@@ -35,7 +47,7 @@ func (r *User) GetByID(tx godb.Queryer, ctx context.Context, id int32, showPriva
 	q := gosql.NewSelect().From("users")
 	q.Columns().Add("displayed_name", "profile_image", "created_at", "updated_at", "deleted_at")
 	if showPrivateInfo {
-		q.Columns().Add("username", "email")
+		q.Columns().Add("username", "email", "email_verified_at", "role", "time_zone", "disabled_at")
 	}
 	q.Where().AddExpression("id = ?", id)
 	q.Where().AddExpression("deleted_at IS NULL")
@@ -44,7 +56,7 @@ func (r *User) GetByID(tx godb.Queryer, ctx context.Context, id int32, showPriva
 	var err error
 	if showPrivateInfo {
 		err = row.Scan(&user.DisplayedName, &user.ProfileImage, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
-			&user.Username, &user.Email)
+			&user.Username, &user.Email, &user.EmailVerifiedAt, &user.Role, &user.TimeZone, &user.DisabledAt)
 	} else {
 		err = row.Scan(&user.DisplayedName, &user.ProfileImage, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt)
 	}
@@ -63,12 +75,14 @@ func (r *User) GetByName(tx godb.Queryer, ctx context.Context, name string) (*en
 	}
 
 	q := gosql.NewSelect().From("users")
-	q.Columns().Add("id", "displayed_name", "email", "profile_image", "created_at", "updated_at", "deleted_at")
+	q.Columns().Add("id", "displayed_name", "email", "email_verified_at", "profile_image", "role", "created_at", "updated_at",
+		"deleted_at", "disabled_at")
 	q.Where().AddExpression("username = ?", name)
 	q.Where().AddExpression("deleted_at IS NULL")
 	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
 
-	err := row.Scan(&user.ID, &user.DisplayedName, &user.Email, &user.ProfileImage, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt)
+	err := row.Scan(&user.ID, &user.DisplayedName, &user.Email, &user.EmailVerifiedAt, &user.ProfileImage, &user.Role,
+		&user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.DisabledAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -104,14 +118,18 @@ func (r *User) UpdateProfile(tx godb.Queryer, ctx context.Context, req *dto.Upda
 
 	q := gosql.NewUpdate().Table("users")
 	q.Set().Append("displayed_name = ?", req.DisplayedName)
+	// A new email address has to be verified again
+	q.Set().Append("email_verified_at = CASE WHEN email IS NOT DISTINCT FROM ? THEN email_verified_at END", req.Email)
 	q.Set().Append("email = ?", req.Email)
+	q.Set().Append("time_zone = COALESCE(?, time_zone)", req.TimeZone)
 	q.Set().Append("updated_at = now()")
 	q.Where().AddExpression("id = ?", req.ID)
 	q.Where().AddExpression("deleted_at IS NULL")
-	q.Returning().Add("username", "profile_image", "created_at", "updated_at")
+	q.Returning().Add("username", "email_verified_at", "profile_image", "time_zone", "created_at", "updated_at")
 	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
 
-	err := row.Scan(&user.Username, &user.ProfileImage, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.Username, &user.EmailVerifiedAt, &user.ProfileImage, &user.TimeZone, &user.CreatedAt,
+		&user.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -128,12 +146,235 @@ func (r *User) UpdateImage(tx godb.Queryer, ctx context.Context, req *dto.Update
 	q.Set().Append("updated_at = now()")
 	q.Where().AddExpression("id = ?", req.ID)
 	q.Where().AddExpression("deleted_at IS NULL")
-	q.Returning().Add("username", "displayed_name", "email", "created_at", "updated_at")
+	q.Returning().Add("username", "displayed_name", "email", "email_verified_at", "time_zone", "created_at", "updated_at")
 	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
 
-	err := row.Scan(&user.Username, &user.DisplayedName, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.Username, &user.DisplayedName, &user.Email, &user.EmailVerifiedAt, &user.TimeZone, &user.CreatedAt,
+		&user.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
+func (r *User) VerifyEmail(tx godb.Queryer, ctx context.Context, id int32, email string) error {
+	q := gosql.NewUpdate().Table("users")
+	q.Set().Add("email_verified_at = now()")
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("email = ?", email)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *User) GetDeletedByName(tx godb.Queryer, ctx context.Context, name string) (*entity.User, error) {
+	user := &entity.User{
+		Username: name,
+	}
+
+	q := gosql.NewSelect().From("users")
+	q.Columns().Add("id", "displayed_name", "email", "email_verified_at", "profile_image", "role", "created_at", "updated_at",
+		"deleted_at", "disabled_at")
+	q.Where().AddExpression("username = ?", name)
+	q.Where().AddExpression("deleted_at IS NOT NULL")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&user.ID, &user.DisplayedName, &user.Email, &user.EmailVerifiedAt, &user.ProfileImage, &user.Role,
+		&user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.DisabledAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+func (r *User) GetDeletedByID(tx godb.Queryer, ctx context.Context, id int32) (*entity.User, error) {
+	user := &entity.User{
+		ID: id,
+	}
+
+	q := gosql.NewSelect().From("users")
+	q.Columns().Add("username", "displayed_name", "email", "email_verified_at", "profile_image", "role", "created_at",
+		"updated_at", "deleted_at", "disabled_at")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NOT NULL")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&user.Username, &user.DisplayedName, &user.Email, &user.EmailVerifiedAt, &user.ProfileImage,
+		&user.Role, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.DisabledAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+func (r *User) Delete(tx godb.Queryer, ctx context.Context, id int32) error {
+	q := gosql.NewUpdate().Table("users")
+	q.Set().Add("deleted_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *User) Restore(tx godb.Queryer, ctx context.Context, id int32) error {
+	q := gosql.NewUpdate().Table("users")
+	q.Set().Add("deleted_at = NULL")
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NOT NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// PurgeDeleted removes users deleted before the time, all their data is removed by the cascade
+func (r *User) PurgeDeleted(tx godb.Queryer, ctx context.Context, deletedBefore time.Time) ([]int32, error) {
+	var res []int32
+
+	q := gosql.NewDelete().From("users")
+	q.Where().AddExpression("deleted_at < ?", deletedBefore)
+	q.Returning().Add("id")
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetGetArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int32
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// List returns all users including deleted and disabled ones, it's used by the admin API
+func (r *User) List(tx godb.Queryer, ctx context.Context, req *dto.ListUsersDTO) ([]*entity.User, int32, error) {
+	var res []*entity.User
+	var total int32
+
+	filter := func(q *gosql.Select) {
+		if req.Query != "" {
+			like := utils.PrepareStringToLike(req.Query)
+			q.Where().AddExpression("(username ILIKE ? OR displayed_name ILIKE ?)", like, like)
+		}
+		if req.Role != "" {
+			q.Where().AddExpression("role = ?", req.Role)
+		}
+	}
+
+	// Count all users for pagination
+	q := gosql.NewSelect().From("users")
+	q.Columns().Add("count(*)")
+	filter(q)
+	err := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	limit, offset := utils.GetPagination(req.Limit, req.Page)
+
+	q = gosql.NewSelect().From("users")
+	q.Columns().Add("id", "username", "displayed_name", "email", "email_verified_at", "profile_image", "role", "created_at",
+		"updated_at", "deleted_at", "disabled_at")
+	filter(q)
+	q.AddOrder("id")
+	q.SetPagination(limit, offset)
+	rows, err := tx.QueryContext(ctx, q.String(), q.GetArguments()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user := &entity.User{}
+		err = rows.Scan(&user.ID, &user.Username, &user.DisplayedName, &user.Email, &user.EmailVerifiedAt, &user.ProfileImage,
+			&user.Role, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.DisabledAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		res = append(res, user)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res, total, nil
+}
+func (r *User) UpdateRole(tx godb.Queryer, ctx context.Context, id int32, role string) error {
+	q := gosql.NewUpdate().Table("users")
+	q.Set().Append("role = ?", role)
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *User) Disable(tx godb.Queryer, ctx context.Context, id int32) error {
+	q := gosql.NewUpdate().Table("users")
+	q.Set().Add("disabled_at = now()")
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Where().AddExpression("disabled_at IS NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (r *User) Enable(tx godb.Queryer, ctx context.Context, id int32) error {
+	q := gosql.NewUpdate().Table("users")
+	q.Set().Add("disabled_at = NULL")
+	q.Set().Append("updated_at = now()")
+	q.Where().AddExpression("id = ?", id)
+	q.Where().AddExpression("deleted_at IS NULL")
+	q.Where().AddExpression("disabled_at IS NOT NULL")
+	q.Returning().Add("id")
+	row := tx.QueryRowContext(ctx, q.String(), q.GetArguments()...)
+
+	err := row.Scan(&id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/service"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+type Admin struct {
+	service service.IAdmin
+}
+
+func NewAdmin(service service.IAdmin) *Admin {
+	return &Admin{
+		service: service,
+	}
+}
+
+// RegisterPrivateRouter expects a middleware that allows moderators and admins, some routes are for admins only
+func (s *Admin) RegisterPrivateRouter(router *mux.Router, middleware ...mux.MiddlewareFunc) {
+	adminRouter := router.PathPrefix("").Subrouter()
+	adminRouter.HandleFunc("/users", s.ListUsers).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/users/{id:[0-9]+}", s.GetUser).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/users/{id:[0-9]+}/unblock", s.Unblock).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/users/{id:[0-9]+}/logout", s.Logout).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/users/{id:[0-9]+}/disable", withRole(entity.UserRoleAdmin, s.Disable)).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/users/{id:[0-9]+}/enable", withRole(entity.UserRoleAdmin, s.Enable)).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/users/{id:[0-9]+}/role", withRole(entity.UserRoleAdmin, s.UpdateRole)).Methods(http.MethodPut)
+	adminRouter.HandleFunc("/stats", withRole(entity.UserRoleAdmin, s.Stats)).Methods(http.MethodGet)
+	adminRouter.Use(middleware...)
+}
+
+/*
+ * Private
+ */
+
+// swagger:parameters AdminListUsersRequest
+type AdminListUsersRequest struct {
+	// In: query
+	Query string `json:"query"`
+	// In: query
+	Role string `json:"role"`
+	// In: query
+	Limit int32 `json:"limit"`
+	// In: query
+	Page int32 `json:"page"`
+}
+
+// swagger:response AdminListUsersResponse
+type AdminListUsersResponse struct {
+	// In: body
+	Body struct {
+		Data []*entity.User `json:"data"`
+		Meta *utils.Meta    `json:"meta"`
+	}
+}
+
+// swagger:route GET /api/v1/admin/users Admin AdminListUsersRequest
+//
+// # Searching users by username or displayed name
+//
+// Deleted and disabled users are included. Available for moderators and admins.
+//
+//	Responses:
+//	  200: AdminListUsersResponse
+func (s *Admin) ListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := &dto.ListUsersDTO{
+		Query: r.URL.Query().Get("query"),
+		Role:  r.URL.Query().Get("role"),
+		Limit: utils.GetInt32FromQuery(r, "limit", 50),
+		Page:  utils.GetInt32FromQuery(r, "page", 1),
+	}
+
+	err := GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	users, total, err := s.service.ListUsers(ctx, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if users == nil {
+		users = make([]*entity.User, 0)
+	}
+
+	err = utils.ResponseWithMeta(w, users, &utils.Meta{
+		Total: total,
+		Limit: req.Limit,
+		Page:  req.Page,
+	})
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AdminGetUserRequest
+type AdminGetUserRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response AdminGetUserResponse
+type AdminGetUserResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.AdminUserResponseDTO `json:"data"`
+	}
+}
+
+// swagger:route GET /api/v1/admin/users/{id} Admin AdminGetUserRequest
+//
+// # Getting a user with the lockout state and sessions
+//
+// Available for moderators and admins.
+//
+//	Responses:
+//	  200: AdminGetUserResponse
+func (s *Admin) GetUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.service.GetUser(ctx, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, user)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AdminUnblockRequest
+type AdminUnblockRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response AdminUnblockResponse
+type AdminUnblockResponse struct {
+}
+
+// swagger:route POST /api/v1/admin/users/{id}/unblock Admin AdminUnblockRequest
+//
+// # Unblocking the password locked after failed login attempts
+//
+// Available for moderators and admins, moderators can act only on users without a staff role.
+//
+//	Responses:
+//	  200: AdminUnblockResponse
+func (s *Admin) Unblock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = s.service.Unblock(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AdminLogoutRequest
+type AdminLogoutRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response AdminLogoutResponse
+type AdminLogoutResponse struct {
+}
+
+// swagger:route POST /api/v1/admin/users/{id}/logout Admin AdminLogoutRequest
+//
+// # Closing all sessions of a user
+//
+// Available for moderators and admins, moderators can act only on users without a staff role.
+//
+//	Responses:
+//	  200: AdminLogoutResponse
+func (s *Admin) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = s.service.Logout(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AdminDisableRequest
+type AdminDisableRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response AdminDisableResponse
+type AdminDisableResponse struct {
+}
+
+// swagger:route POST /api/v1/admin/users/{id}/disable Admin AdminDisableRequest
+//
+// # Disabling an account
+//
+// All sessions and access tokens of the user are revoked and login is rejected. Available for admins.
+//
+//	Responses:
+//	  200: AdminDisableResponse
+func (s *Admin) Disable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = s.service.Disable(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AdminEnableRequest
+type AdminEnableRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response AdminEnableResponse
+type AdminEnableResponse struct {
+}
+
+// swagger:route POST /api/v1/admin/users/{id}/enable Admin AdminEnableRequest
+//
+// # Enabling a disabled account
+//
+// Available for admins.
+//
+//	Responses:
+//	  200: AdminEnableResponse
+func (s *Admin) Enable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = s.service.Enable(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AdminUpdateRoleRequest
+type AdminUpdateRoleRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+	// In: body
+	Body struct {
+		dto.UpdateRoleDTO
+	}
+}
+
+// swagger:response AdminUpdateRoleResponse
+type AdminUpdateRoleResponse struct {
+}
+
+// swagger:route PUT /api/v1/admin/users/{id}/role Admin AdminUpdateRoleRequest
+//
+// # Changing the role of a user
+//
+// Available for admins.
+//
+//	Responses:
+//	  200: AdminUpdateRoleResponse
+func (s *Admin) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	req := &dto.UpdateRoleDTO{}
+	err = utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.service.UpdateRole(ctx, userID, id, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AdminStatsRequest
+type AdminStatsRequest struct {
+}
+
+// swagger:response AdminStatsResponse
+type AdminStatsResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.StatsResponseDTO `json:"data"`
+	}
+}
+
+// swagger:route GET /api/v1/admin/stats Admin AdminStatsRequest
+//
+// # Getting system statistics
+//
+// Available for admins.
+//
+//	Responses:
+//	  200: AdminStatsResponse
+func (s *Admin) Stats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	stats, err := s.service.Stats(ctx)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, stats)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
@@ -2,6 +2,8 @@ package server
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -15,25 +17,56 @@ import (
 )
 
 type Auth struct {
-	service service.IAuth
-	cfg     *config.Config
+	service          service.IAuth
+	twoFactorService service.ITwoFactor
+	emailService     service.IEmail
+	oidcService      service.IOIDC
+	cfg              *config.Config
 }
 
-func NewAuth(cfg *config.Config, service service.IAuth) *Auth {
+func NewAuth(cfg *config.Config, service service.IAuth, twoFactor service.ITwoFactor, email service.IEmail,
+	oidc service.IOIDC) *Auth {
 	return &Auth{
-		cfg:     cfg,
-		service: service,
+		cfg:              cfg,
+		service:          service,
+		twoFactorService: twoFactor,
+		emailService:     email,
+		oidcService:      oidc,
 	}
 }
-func (s *Auth) RegisterPublicRouter(router *mux.Router) {
+func (s *Auth) RegisterPublicRouter(router *mux.Router, middleware ...mux.MiddlewareFunc) {
 	authRouter := router.PathPrefix("").Subrouter()
 	authRouter.HandleFunc("/register", s.Register).Methods(http.MethodPost)
 	authRouter.HandleFunc("/login", s.Login).Methods(http.MethodPost)
+	authRouter.HandleFunc("/login/2fa", s.LoginTwoFactor).Methods(http.MethodPost)
+	authRouter.HandleFunc("/refresh", s.Refresh).Methods(http.MethodPost)
+	authRouter.HandleFunc("/email/verify", s.VerifyEmail).Methods(http.MethodPost)
+	authRouter.HandleFunc("/password/forgot", s.ForgotPassword).Methods(http.MethodPost)
+	authRouter.HandleFunc("/password/reset", s.ResetPassword).Methods(http.MethodPost)
+	authRouter.HandleFunc("/oidc/authorize", s.OIDCAuthorize).Methods(http.MethodPost)
+	authRouter.HandleFunc("/oidc/callback", s.OIDCCallback).Methods(http.MethodPost)
+	authRouter.Use(middleware...)
 }
 func (s *Auth) RegisterPrivateRouter(router *mux.Router, middleware ...mux.MiddlewareFunc) {
 	authRouter := router.PathPrefix("").Subrouter()
 	authRouter.HandleFunc("/user", s.User).Methods(http.MethodGet)
 	authRouter.HandleFunc("/logout", s.Logout).Methods(http.MethodPost)
+	authRouter.HandleFunc("/logout/others", s.LogoutOthers).Methods(http.MethodPost)
+	authRouter.HandleFunc("/sessions", s.ListSessions).Methods(http.MethodGet)
+	authRouter.HandleFunc("/sessions/{id:[0-9]+}", s.DeleteSession).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/history", s.History).Methods(http.MethodGet)
+	authRouter.HandleFunc("/tokens", s.CreateAccessToken).Methods(http.MethodPost)
+	authRouter.HandleFunc("/tokens", s.ListAccessTokens).Methods(http.MethodGet)
+	authRouter.HandleFunc("/tokens/{id:[0-9]+}", s.DeleteAccessToken).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/2fa", s.TwoFactorStatus).Methods(http.MethodGet)
+	authRouter.HandleFunc("/2fa/enroll", s.TwoFactorEnroll).Methods(http.MethodPost)
+	authRouter.HandleFunc("/2fa/confirm", s.TwoFactorConfirm).Methods(http.MethodPost)
+	authRouter.HandleFunc("/2fa/disable", s.TwoFactorDisable).Methods(http.MethodPost)
+	authRouter.HandleFunc("/email/verify/send", s.SendEmailVerification).Methods(http.MethodPost)
+	authRouter.HandleFunc("/oidc/link", s.OIDCLink).Methods(http.MethodPost)
+	authRouter.HandleFunc("/oidc/link/callback", s.OIDCLinkCallback).Methods(http.MethodPost)
+	authRouter.HandleFunc("/oidc/identities", s.ListIdentities).Methods(http.MethodGet)
+	authRouter.HandleFunc("/oidc/identities/{id:[0-9]+}", s.DeleteIdentity).Methods(http.MethodDelete)
 	authRouter.Use(middleware...)
 }
 
@@ -72,6 +105,8 @@ func (s *Auth) Register(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Can't parse request", http.StatusBadRequest)
 		return
 	}
+	req.UserAgent = r.UserAgent()
+	req.IP = utils.GetClientIP(r)
 
 	err = GetValidator().Struct(req)
 	if err != nil {
@@ -85,7 +120,7 @@ func (s *Auth) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, err := s.service.GenerateCookie(ctx, user.ID)
+	session, err := s.service.GenerateCookie(ctx, user.ID, req.UserAgent, req.IP)
 	if err != nil {
 		errs.HttpError(w, err)
 		return
@@ -114,12 +149,27 @@ type AuthLoginResponse struct {
 	}
 }
 
+// swagger:response AuthLoginChallengeResponse
+type AuthLoginChallengeResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.LoginChallengeResponseDTO `json:"data"`
+	}
+}
+
 // swagger:route POST /api/v1/auth/login Auth AuthLoginRequest
 //
 // # Login form
 //
+// If two-factor authentication is enabled, a challenge is returned instead of the session,
+// it has to be passed to /api/v1/auth/login/2fa together with the code.
+//
+// With useCookie the session is set in HttpOnly cookies and only the CSRF token is returned,
+// it has to be sent in the X-CSRF-Token header with every request that changes data.
+//
 //	Responses:
 //	  200: AuthLoginResponse
+//	  202: AuthLoginChallengeResponse
 func (s *Auth) Login(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -129,12 +179,18 @@ func (s *Auth) Login(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Can't parse request", http.StatusBadRequest)
 		return
 	}
+	req.UserAgent = r.UserAgent()
+	req.IP = utils.GetClientIP(r)
 
 	err = GetValidator().Struct(req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if req.UseCookie && !s.cfg.AuthCookie {
+		http.Error(w, "Cookie authentication is disabled", http.StatusBadRequest)
+		return
+	}
 
 	user, err := s.service.Login(ctx, req)
 	if err != nil {
@@ -142,11 +198,41 @@ func (s *Auth) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, err := s.service.GenerateCookie(ctx, user.ID)
+	challenge, err := s.twoFactorService.CreateChallenge(ctx, user.ID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if challenge != nil {
+		w.WriteHeader(http.StatusAccepted)
+		err = utils.Response(w, challenge)
+		if err != nil {
+			logger.Error.Println("error write to socket:", err.Error())
+		}
+		return
+	}
+
+	// A deleted account is restored only after the full login
+	if user.DeletedAt != nil {
+		err = s.service.RestoreAccount(ctx, user.ID, req.IP, req.UserAgent)
+		if err != nil {
+			errs.HttpError(w, err)
+			return
+		}
+	}
+
+	session, err := s.service.GenerateCookie(ctx, user.ID, req.UserAgent, req.IP)
 	if err != nil {
 		errs.HttpError(w, err)
 		return
 	}
+	if req.UseCookie {
+		err = s.setSessionCookies(w, session)
+		if err != nil {
+			errs.HttpError(w, err)
+			return
+		}
+	}
 
 	err = utils.Response(w, session)
 	if err != nil {
@@ -154,65 +240,1153 @@ func (s *Auth) Login(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-/*
- * Private
- */
+// swagger:parameters AuthLoginTwoFactorRequest
+type AuthLoginTwoFactorRequest struct {
+	// In: body
+	Body struct {
+		dto.LoginTwoFactorDTO
+	}
+}
 
-// swagger:parameters AuthUserRequest
-type AuthUserRequest struct {
+// swagger:response AuthLoginTwoFactorResponse
+type AuthLoginTwoFactorResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.Session `json:"data"`
+	}
 }
 
-// swagger:response AuthUserResponse
-type AuthUserResponse struct {
+// swagger:route POST /api/v1/auth/login/2fa Auth AuthLoginTwoFactorRequest
+//
+// # Second step of the login with the code from the authenticator app or a recovery code
+//
+//	Responses:
+//	  200: AuthLoginTwoFactorResponse
+func (s *Auth) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := &dto.LoginTwoFactorDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+	req.UserAgent = r.UserAgent()
+	req.IP = utils.GetClientIP(r)
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.UseCookie && !s.cfg.AuthCookie {
+		http.Error(w, "Cookie authentication is disabled", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := s.twoFactorService.VerifyChallenge(ctx, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	// The password may have been checked for a deleted account, it's restored only after the second factor
+	err = s.service.RestoreAccount(ctx, userID, req.IP, req.UserAgent)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	session, err := s.service.GenerateCookie(ctx, userID, req.UserAgent, req.IP)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if req.UseCookie {
+		err = s.setSessionCookies(w, session)
+		if err != nil {
+			errs.HttpError(w, err)
+			return
+		}
+	}
+
+	err = utils.Response(w, session)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthRefreshRequest
+type AuthRefreshRequest struct {
 	// In: body
 	Body struct {
-		Data *entity.User `json:"data"`
+		dto.RefreshDTO
 	}
 }
 
-// swagger:route GET /api/v1/auth/user Auth AuthUserRequest
+// swagger:response AuthRefreshResponse
+type AuthRefreshResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.Session `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/auth/refresh Auth AuthRefreshRequest
 //
-// # Getting information about the current user
+// # Renew the session with a refresh token
+//
+// A browser client that logged in with useCookie sends an empty body, the refresh token is taken from the cookie.
 //
 //	Responses:
-//	  200: AuthUserResponse
-func (s *Auth) User(w http.ResponseWriter, r *http.Request) {
+//	  200: AuthRefreshResponse
+func (s *Auth) Refresh(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	userID := utils.GetUserIDFromContext(ctx)
 
-	user, err := s.service.GetUserInfo(ctx, userID)
+	req := &dto.RefreshDTO{}
+	err := utils.ParseOptionalJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+	req.UserAgent = r.UserAgent()
+	req.IP = utils.GetClientIP(r)
+
+	useCookie := false
+	if req.RefreshToken == "" && s.cfg.AuthCookie {
+		req.RefreshToken = utils.GetCookie(r, utils.RefreshCookieName)
+		useCookie = req.RefreshToken != ""
+		if useCookie && !utils.CheckCSRF(r) {
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	session, err := s.service.RefreshCookie(ctx, req)
 	if err != nil {
 		errs.HttpError(w, err)
 		return
 	}
+	if useCookie {
+		err = s.setSessionCookies(w, session)
+		if err != nil {
+			errs.HttpError(w, err)
+			return
+		}
+	}
 
-	err = utils.Response(w, user)
+	err = utils.Response(w, session)
 	if err != nil {
 		logger.Error.Println("error write to socket:", err.Error())
 	}
 }
 
-// swagger:parameters AuthLogoutRequest
-type AuthLogoutRequest struct {
+// swagger:parameters AuthVerifyEmailRequest
+type AuthVerifyEmailRequest struct {
+	// In: body
+	Body struct {
+		dto.VerifyEmailDTO
+	}
 }
 
-// swagger:response AuthLogoutResponse
-type AuthLogoutResponse struct {
+// swagger:response AuthVerifyEmailResponse
+type AuthVerifyEmailResponse struct {
 }
 
-// swagger:route POST /api/v1/auth/logout Auth AuthLogoutRequest
+// swagger:route POST /api/v1/auth/email/verify Auth AuthVerifyEmailRequest
 //
-// # Close the current session
+// # Confirm the email with a token from the verification link
 //
 //	Responses:
-//	  200: AuthLogoutResponse
-func (s *Auth) Logout(w http.ResponseWriter, r *http.Request) {
+//	  200: AuthVerifyEmailResponse
+func (s *Auth) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	session := utils.GetSessionFromContext(ctx)
 
-	err := s.service.Logout(ctx, session.ID)
+	req := &dto.VerifyEmailDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.emailService.Verify(ctx, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AuthForgotPasswordRequest
+type AuthForgotPasswordRequest struct {
+	// In: body
+	Body struct {
+		dto.ForgotPasswordDTO
+	}
+}
+
+// swagger:response AuthForgotPasswordResponse
+type AuthForgotPasswordResponse struct {
+}
+
+// swagger:route POST /api/v1/auth/password/forgot Auth AuthForgotPasswordRequest
+//
+// # Send a password reset link to the verified email of the user
+//
+//	Responses:
+//	  200: AuthForgotPasswordResponse
+func (s *Auth) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := &dto.ForgotPasswordDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.emailService.ForgotPassword(ctx, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AuthResetPasswordRequest
+type AuthResetPasswordRequest struct {
+	// In: body
+	Body struct {
+		dto.ResetPasswordDTO
+	}
+}
+
+// swagger:response AuthResetPasswordResponse
+type AuthResetPasswordResponse struct {
+}
+
+// swagger:route POST /api/v1/auth/password/reset Auth AuthResetPasswordRequest
+//
+// # Set a new password with a token from the reset link
+//
+//	Responses:
+//	  200: AuthResetPasswordResponse
+func (s *Auth) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := &dto.ResetPasswordDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+	req.UserAgent = r.UserAgent()
+	req.IP = utils.GetClientIP(r)
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.emailService.ResetPassword(ctx, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AuthOIDCAuthorizeRequest
+type AuthOIDCAuthorizeRequest struct {
+	// In: body
+	Body struct {
+		dto.OIDCAuthorizeDTO
+	}
+}
+
+// swagger:response AuthOIDCAuthorizeResponse
+type AuthOIDCAuthorizeResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.OIDCAuthorizeResponseDTO `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/auth/oidc/authorize Auth AuthOIDCAuthorizeRequest
+//
+// # Start the login with the OpenID Connect provider
+//
+// The user has to be redirected to the returned URL, after the login the provider
+// redirects back to the frontend with the code and the state for /api/v1/auth/oidc/callback.
+//
+//	Responses:
+//	  200: AuthOIDCAuthorizeResponse
+func (s *Auth) OIDCAuthorize(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := &dto.OIDCAuthorizeDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.oidcService.Authorize(ctx, nil, req)
 	if err != nil {
 		errs.HttpError(w, err)
 		return
 	}
+
+	err = utils.Response(w, resp)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthOIDCCallbackRequest
+type AuthOIDCCallbackRequest struct {
+	// In: body
+	Body struct {
+		dto.OIDCCallbackDTO
+	}
+}
+
+// swagger:response AuthOIDCCallbackResponse
+type AuthOIDCCallbackResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.Session `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/auth/oidc/callback Auth AuthOIDCCallbackRequest
+//
+// # Finish the login with the OpenID Connect provider
+//
+// A new user is created on the first login if auto-provisioning is enabled. The login with the provider replaces
+// only the password, if two-factor authentication is enabled a challenge is returned like on the password login.
+//
+//	Responses:
+//	  200: AuthOIDCCallbackResponse
+//	  202: AuthLoginChallengeResponse
+func (s *Auth) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := &dto.OIDCCallbackDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+	req.UserAgent = r.UserAgent()
+	req.IP = utils.GetClientIP(r)
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, useCookie, err := s.oidcService.Login(ctx, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	challenge, err := s.twoFactorService.CreateChallenge(ctx, user.ID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if challenge != nil {
+		w.WriteHeader(http.StatusAccepted)
+		err = utils.Response(w, challenge)
+		if err != nil {
+			logger.Error.Println("error write to socket:", err.Error())
+		}
+		return
+	}
+
+	// A deleted account is restored only after the full login
+	if user.DeletedAt != nil {
+		err = s.service.RestoreAccount(ctx, user.ID, req.IP, req.UserAgent)
+		if err != nil {
+			errs.HttpError(w, err)
+			return
+		}
+	}
+
+	session, err := s.service.GenerateCookie(ctx, user.ID, req.UserAgent, req.IP)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if useCookie {
+		err = s.setSessionCookies(w, session)
+		if err != nil {
+			errs.HttpError(w, err)
+			return
+		}
+	}
+
+	err = utils.Response(w, session)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+/*
+ * Private
+ */
+
+// swagger:parameters AuthUserRequest
+type AuthUserRequest struct {
+}
+
+// swagger:response AuthUserResponse
+type AuthUserResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.User `json:"data"`
+	}
+}
+
+// swagger:route GET /api/v1/auth/user Auth AuthUserRequest
+//
+// # Getting information about the current user
+//
+//	Responses:
+//	  200: AuthUserResponse
+func (s *Auth) User(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	user, err := s.service.GetUserInfo(ctx, userID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, user)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthLogoutRequest
+type AuthLogoutRequest struct {
+}
+
+// swagger:response AuthLogoutResponse
+type AuthLogoutResponse struct {
+}
+
+// swagger:route POST /api/v1/auth/logout Auth AuthLogoutRequest
+//
+// # Close the current session
+//
+//	Responses:
+//	  200: AuthLogoutResponse
+func (s *Auth) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	session := utils.GetSessionFromContext(ctx)
+
+	err := s.service.Logout(ctx, session)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if s.cfg.AuthCookie {
+		s.clearSessionCookies(w)
+	}
+}
+
+// swagger:parameters AuthLogoutOthersRequest
+type AuthLogoutOthersRequest struct {
+}
+
+// swagger:response AuthLogoutOthersResponse
+type AuthLogoutOthersResponse struct {
+}
+
+// swagger:route POST /api/v1/auth/logout/others Auth AuthLogoutOthersRequest
+//
+// # Close all sessions except the current one
+//
+//	Responses:
+//	  200: AuthLogoutOthersResponse
+func (s *Auth) LogoutOthers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	session := utils.GetSessionFromContext(ctx)
+
+	err := s.service.LogoutOthers(ctx, session)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AuthListSessionsRequest
+type AuthListSessionsRequest struct {
+}
+
+// swagger:response AuthListSessionsResponse
+type AuthListSessionsResponse struct {
+	// In: body
+	Body struct {
+		Data []*dto.SessionResponseDTO `json:"data"`
+		Meta *utils.Meta               `json:"meta"`
+	}
+}
+
+// swagger:route GET /api/v1/auth/sessions Auth AuthListSessionsRequest
+//
+// # Get a list of active sessions of the current user
+//
+//	Responses:
+//	  200: AuthListSessionsResponse
+func (s *Auth) ListSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	session := utils.GetSessionFromContext(ctx)
+
+	sessions, total, err := s.service.ListSessions(ctx, session.UserID, session.ID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.ResponseWithMeta(w, sessions, &utils.Meta{
+		Total: total,
+	})
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthDeleteSessionRequest
+type AuthDeleteSessionRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response AuthDeleteSessionResponse
+type AuthDeleteSessionResponse struct {
+}
+
+// swagger:route DELETE /api/v1/auth/sessions/{id} Auth AuthDeleteSessionRequest
+//
+// # Close the session on another device
+//
+//	Responses:
+//	  200: AuthDeleteSessionResponse
+func (s *Auth) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	session := utils.GetSessionFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = s.service.DeleteSession(ctx, session, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AuthHistoryRequest
+type AuthHistoryRequest struct {
+	// In: query
+	Limit int32 `json:"limit"`
+	// In: query
+	Page int32 `json:"page"`
+}
+
+// swagger:response AuthHistoryResponse
+type AuthHistoryResponse struct {
+	// In: body
+	Body struct {
+		Data []*entity.AuthEvent `json:"data"`
+		Meta *utils.Meta         `json:"meta"`
+	}
+}
+
+// swagger:route GET /api/v1/auth/history Auth AuthHistoryRequest
+//
+// # Getting a list of logins, logouts and password changes of the current user
+//
+//	Responses:
+//	  200: AuthHistoryResponse
+func (s *Auth) History(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.ListAuthEventDTO{
+		Limit: utils.GetInt32FromQuery(r, "limit", 50),
+		Page:  utils.GetInt32FromQuery(r, "page", 1),
+	}
+
+	err := GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, total, err := s.service.History(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if events == nil {
+		events = make([]*entity.AuthEvent, 0)
+	}
+
+	err = utils.ResponseWithMeta(w, events, &utils.Meta{
+		Total: total,
+		Limit: req.Limit,
+		Page:  req.Page,
+	})
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthCreateAccessTokenRequest
+type AuthCreateAccessTokenRequest struct {
+	// In: body
+	Body struct {
+		dto.CreateAccessTokenDTO
+	}
+}
+
+// swagger:response AuthCreateAccessTokenResponse
+type AuthCreateAccessTokenResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.AccessToken `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/auth/tokens Auth AuthCreateAccessTokenRequest
+//
+// # Create a personal access token, the token value is returned only once
+//
+//	Responses:
+//	  200: AuthCreateAccessTokenResponse
+func (s *Auth) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.CreateAccessTokenDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := s.service.CreateAccessToken(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	err = utils.Response(w, token)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthListAccessTokensRequest
+type AuthListAccessTokensRequest struct {
+}
+
+// swagger:response AuthListAccessTokensResponse
+type AuthListAccessTokensResponse struct {
+	// In: body
+	Body struct {
+		Data []*entity.AccessToken `json:"data"`
+		Meta *utils.Meta           `json:"meta"`
+	}
+}
+
+// swagger:route GET /api/v1/auth/tokens Auth AuthListAccessTokensRequest
+//
+// # Get a list of personal access tokens
+//
+//	Responses:
+//	  200: AuthListAccessTokensResponse
+func (s *Auth) ListAccessTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	tokens, total, err := s.service.ListAccessTokens(ctx, userID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if tokens == nil {
+		tokens = make([]*entity.AccessToken, 0)
+	}
+
+	err = utils.ResponseWithMeta(w, tokens, &utils.Meta{
+		Total: total,
+	})
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthDeleteAccessTokenRequest
+type AuthDeleteAccessTokenRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response AuthDeleteAccessTokenResponse
+type AuthDeleteAccessTokenResponse struct {
+}
+
+// swagger:route DELETE /api/v1/auth/tokens/{id} Auth AuthDeleteAccessTokenRequest
+//
+// # Revoke a personal access token
+//
+//	Responses:
+//	  200: AuthDeleteAccessTokenResponse
+func (s *Auth) DeleteAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = s.service.DeleteAccessToken(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AuthTwoFactorStatusRequest
+type AuthTwoFactorStatusRequest struct {
+}
+
+// swagger:response AuthTwoFactorStatusResponse
+type AuthTwoFactorStatusResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.TwoFactorStatusResponseDTO `json:"data"`
+	}
+}
+
+// swagger:route GET /api/v1/auth/2fa Auth AuthTwoFactorStatusRequest
+//
+// # Check if two-factor authentication is enabled
+//
+//	Responses:
+//	  200: AuthTwoFactorStatusResponse
+func (s *Auth) TwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	status, err := s.twoFactorService.Status(ctx, userID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, status)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthTwoFactorEnrollRequest
+type AuthTwoFactorEnrollRequest struct {
+}
+
+// swagger:response AuthTwoFactorEnrollResponse
+type AuthTwoFactorEnrollResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.TwoFactorEnrollResponseDTO `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/auth/2fa/enroll Auth AuthTwoFactorEnrollRequest
+//
+// # Start enrollment of two-factor authentication, the returned URI can be shown as a QR code
+//
+//	Responses:
+//	  200: AuthTwoFactorEnrollResponse
+func (s *Auth) TwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	enroll, err := s.twoFactorService.Enroll(ctx, userID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, enroll)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthTwoFactorConfirmRequest
+type AuthTwoFactorConfirmRequest struct {
+	// In: body
+	Body struct {
+		dto.TwoFactorConfirmDTO
+	}
+}
+
+// swagger:response AuthTwoFactorConfirmResponse
+type AuthTwoFactorConfirmResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.TwoFactorConfirmResponseDTO `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/auth/2fa/confirm Auth AuthTwoFactorConfirmRequest
+//
+// # Enable two-factor authentication with the first code, returns one-time recovery codes
+//
+//	Responses:
+//	  200: AuthTwoFactorConfirmResponse
+func (s *Auth) TwoFactorConfirm(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.TwoFactorConfirmDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	codes, err := s.twoFactorService.Confirm(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, codes)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthTwoFactorDisableRequest
+type AuthTwoFactorDisableRequest struct {
+	// In: body
+	Body struct {
+		dto.TwoFactorDisableDTO
+	}
+}
+
+// swagger:response AuthTwoFactorDisableResponse
+type AuthTwoFactorDisableResponse struct {
+}
+
+// swagger:route POST /api/v1/auth/2fa/disable Auth AuthTwoFactorDisableRequest
+//
+// # Disable two-factor authentication
+//
+//	Responses:
+//	  200: AuthTwoFactorDisableResponse
+func (s *Auth) TwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.TwoFactorDisableDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.twoFactorService.Disable(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AuthSendEmailVerificationRequest
+type AuthSendEmailVerificationRequest struct {
+}
+
+// swagger:response AuthSendEmailVerificationResponse
+type AuthSendEmailVerificationResponse struct {
+}
+
+// swagger:route POST /api/v1/auth/email/verify/send Auth AuthSendEmailVerificationRequest
+//
+// # Send a verification link to the email of the current user
+//
+//	Responses:
+//	  200: AuthSendEmailVerificationResponse
+func (s *Auth) SendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	err := s.emailService.SendVerification(ctx, userID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters AuthOIDCLinkRequest
+type AuthOIDCLinkRequest struct {
+}
+
+// swagger:response AuthOIDCLinkResponse
+type AuthOIDCLinkResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.OIDCAuthorizeResponseDTO `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/auth/oidc/link Auth AuthOIDCLinkRequest
+//
+// # Start linking an identity of the OpenID Connect provider to the current user
+//
+// The code and the state from the provider have to be passed to /api/v1/auth/oidc/link/callback.
+//
+//	Responses:
+//	  200: AuthOIDCLinkResponse
+func (s *Auth) OIDCLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	resp, err := s.oidcService.Authorize(ctx, &userID, &dto.OIDCAuthorizeDTO{})
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, resp)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthOIDCLinkCallbackRequest
+type AuthOIDCLinkCallbackRequest struct {
+	// In: body
+	Body struct {
+		dto.OIDCCallbackDTO
+	}
+}
+
+// swagger:response AuthOIDCLinkCallbackResponse
+type AuthOIDCLinkCallbackResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.Identity `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/auth/oidc/link/callback Auth AuthOIDCLinkCallbackRequest
+//
+// # Finish linking an identity of the OpenID Connect provider
+//
+//	Responses:
+//	  200: AuthOIDCLinkCallbackResponse
+func (s *Auth) OIDCLinkCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.OIDCCallbackDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+	req.UserAgent = r.UserAgent()
+	req.IP = utils.GetClientIP(r)
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	identity, err := s.oidcService.Link(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, identity)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthListIdentitiesRequest
+type AuthListIdentitiesRequest struct {
+}
+
+// swagger:response AuthListIdentitiesResponse
+type AuthListIdentitiesResponse struct {
+	// In: body
+	Body struct {
+		Data []*entity.Identity `json:"data"`
+		Meta *utils.Meta        `json:"meta"`
+	}
+}
+
+// swagger:route GET /api/v1/auth/oidc/identities Auth AuthListIdentitiesRequest
+//
+// # List external identities linked to the current user
+//
+//	Responses:
+//	  200: AuthListIdentitiesResponse
+func (s *Auth) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	identities, total, err := s.oidcService.ListIdentities(ctx, userID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if identities == nil {
+		identities = make([]*entity.Identity, 0)
+	}
+
+	err = utils.ResponseWithMeta(w, identities, &utils.Meta{
+		Total: total,
+	})
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters AuthDeleteIdentityRequest
+type AuthDeleteIdentityRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response AuthDeleteIdentityResponse
+type AuthDeleteIdentityResponse struct {
+}
+
+// swagger:route DELETE /api/v1/auth/oidc/identities/{id} Auth AuthDeleteIdentityRequest
+//
+// # Unlink an external identity from the current user
+//
+//	Responses:
+//	  200: AuthDeleteIdentityResponse
+func (s *Auth) DeleteIdentity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = s.oidcService.DeleteIdentity(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// setSessionCookies moves the session and the refresh token from the response body to HttpOnly cookies
+// and issues a new CSRF token for the double-submit check
+func (s *Auth) setSessionCookies(w http.ResponseWriter, session *entity.Session) error {
+	csrfToken, err := utils.GenerateSessionKey()
+	if err != nil {
+		logger.Error.Printf("error generate csrf token: %v", err)
+		return errs.InternalError
+	}
+
+	sessionAge := int(time.Hour/time.Second) * s.cfg.SessionAbsoluteTimeout
+	refreshAge := int(time.Hour/time.Second) * s.cfg.RefreshTokenTimeout
+	http.SetCookie(w, s.newCookie(utils.SessionCookieName, session.Session, "/api", sessionAge, true))
+	http.SetCookie(w, s.newCookie(utils.RefreshCookieName, session.RefreshToken, "/api/v1/auth/refresh", refreshAge, true))
+	// The frontend has to read the CSRF token to put it in the header
+	http.SetCookie(w, s.newCookie(utils.CSRFCookieName, csrfToken, "/", sessionAge, false))
+
+	session.Session = ""
+	session.RefreshToken = ""
+	// The session cookie is checked against the database, a signed token is only for the header
+	session.AccessToken = ""
+	session.CSRFToken = csrfToken
+	return nil
+}
+func (s *Auth) clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, s.newCookie(utils.SessionCookieName, "", "/api", -1, true))
+	http.SetCookie(w, s.newCookie(utils.RefreshCookieName, "", "/api/v1/auth/refresh", -1, true))
+	http.SetCookie(w, s.newCookie(utils.CSRFCookieName, "", "/", -1, false))
+}
+func (s *Auth) newCookie(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	sameSite := http.SameSiteLaxMode
+	switch strings.ToLower(s.cfg.CookieSameSite) {
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   s.cfg.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   s.cfg.CookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite,
+	}
 }
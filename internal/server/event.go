@@ -14,25 +14,43 @@ import (
 )
 
 type Event struct {
-	service service.IEvent
+	service       service.IEvent
+	streakService service.IStreak
 }
 
-func NewEvent(service service.IEvent) *Event {
+func NewEvent(service service.IEvent, streak service.IStreak) *Event {
 	return &Event{
-		service: service,
+		service:       service,
+		streakService: streak,
 	}
 }
 
 func (s *Event) RegisterPrivateRouter(router *mux.Router, middleware ...mux.MiddlewareFunc) {
 	eventRouter := router.PathPrefix("").Subrouter()
-	eventRouter.HandleFunc("", s.CreateEvent).Methods(http.MethodPost)
-	eventRouter.HandleFunc("/list", s.ListEvent).Methods(http.MethodPost)
-	eventRouter.HandleFunc("/feed", s.FeedEvents).Methods(http.MethodGet)
+	eventRouter.HandleFunc("", withScope(entity.ScopeEventsWrite, s.CreateEvent)).Methods(http.MethodPost)
+	eventRouter.HandleFunc("/list", withScope(entity.ScopeEventsRead, s.ListEvent)).Methods(http.MethodPost)
+	eventRouter.HandleFunc("/stats", withScope(entity.ScopeEventsRead, s.EventStats)).Methods(http.MethodPost)
+	eventRouter.HandleFunc("/durations", withScope(entity.ScopeEventsRead, s.EventDurations)).Methods(http.MethodPost)
+	eventRouter.HandleFunc("/expected", withScope(entity.ScopeEventsRead, s.ExpectedEvents)).Methods(http.MethodPost)
+	eventRouter.HandleFunc("/feed", withScope(entity.ScopeEventsRead, s.FeedEvents)).Methods(http.MethodGet)
+	eventRouter.HandleFunc("/{id:[0-9]+}", withScope(entity.ScopeEventsRead, s.GetEvent)).Methods(http.MethodGet)
+	eventRouter.HandleFunc("/{id:[0-9]+}", withScope(entity.ScopeEventsWrite, s.UpdateEvent)).Methods(http.MethodPut, http.MethodPatch)
+	eventRouter.HandleFunc("/{id:[0-9]+}", withScope(entity.ScopeEventsWrite, s.DeleteEvent)).Methods(http.MethodDelete)
 
 	eventTypeRouter := eventRouter.PathPrefix("/types").Subrouter()
-	eventTypeRouter.HandleFunc("", s.CreateEventType).Methods(http.MethodPost)
-	eventTypeRouter.HandleFunc("", s.ListEventType).Methods(http.MethodGet)
-	eventTypeRouter.HandleFunc("/{id:[0-9]+}", s.EditEventType).Methods(http.MethodPut)
+	eventTypeRouter.HandleFunc("", withScope(entity.ScopeEventsWrite, s.CreateEventType)).Methods(http.MethodPost)
+	eventTypeRouter.HandleFunc("", withScope(entity.ScopeEventsRead, s.ListEventType)).Methods(http.MethodGet)
+	eventTypeRouter.HandleFunc("/{id:[0-9]+}", withScope(entity.ScopeEventsWrite, s.EditEventType)).Methods(http.MethodPut)
+	eventTypeRouter.HandleFunc("/{id:[0-9]+}", withScope(entity.ScopeEventsWrite, s.DeleteEventType)).Methods(http.MethodDelete)
+	eventTypeRouter.HandleFunc("/{id:[0-9]+}/restore", withScope(entity.ScopeEventsWrite, s.RestoreEventType)).Methods(http.MethodPost)
+	eventTypeRouter.HandleFunc("/{id:[0-9]+}/streaks", withScope(entity.ScopeEventsRead, s.GetStreaks)).Methods(http.MethodGet)
+	eventTypeRouter.HandleFunc("/{id:[0-9]+}/streaks/freezes", withScope(entity.ScopeEventsWrite, s.CreateStreakFreeze)).Methods(http.MethodPost)
+	eventTypeRouter.HandleFunc("/{id:[0-9]+}/streaks/freezes/{date:[0-9]{4}-[0-9]{2}-[0-9]{2}}", withScope(entity.ScopeEventsWrite, s.DeleteStreakFreeze)).Methods(http.MethodDelete)
+
+	timerRouter := eventRouter.PathPrefix("/timers").Subrouter()
+	timerRouter.HandleFunc("", withScope(entity.ScopeEventsWrite, s.StartTimer)).Methods(http.MethodPost)
+	timerRouter.HandleFunc("", withScope(entity.ScopeEventsRead, s.ListTimers)).Methods(http.MethodGet)
+	timerRouter.HandleFunc("/{id:[0-9]+}/stop", withScope(entity.ScopeEventsWrite, s.StopTimer)).Methods(http.MethodPost)
 
 	eventRouter.Use(middleware...)
 }
@@ -61,6 +79,9 @@ type CreateEventTypeResponse struct {
 //
 // # Create an event type
 //
+// A schedule declares the days on which events are expected: daily, weekdays, every N days (interval)
+// or an RRULE with FREQ of DAILY, WEEKLY or MONTHLY. Without a start the schedule starts today.
+//
 //	Responses:
 //	  200: CreateEventTypeResponse
 func (s *Event) CreateEventType(w http.ResponseWriter, r *http.Request) {
@@ -95,6 +116,9 @@ func (s *Event) CreateEventType(w http.ResponseWriter, r *http.Request) {
 
 // swagger:parameters ListEventTypeRequest
 type ListEventTypeRequest struct {
+	// Return only archived types instead of active ones
+	// In: query
+	Archived bool `json:"archived"`
 }
 
 // swagger:response ListEventTypeResponse
@@ -116,7 +140,11 @@ func (s *Event) ListEventType(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userID := utils.GetUserIDFromContext(ctx)
 
-	eventTypes, total, err := s.service.ListType(ctx, userID)
+	req := &dto.ListEventTypeDTO{
+		Archived: utils.GetBoolFromQuery(r, "archived", false),
+	}
+
+	eventTypes, total, err := s.service.ListType(ctx, userID, req)
 	if err != nil {
 		errs.HttpError(w, err)
 		return
@@ -156,6 +184,9 @@ type EditEventTypeResponse struct {
 //
 // # Editing the event type
 //
+// The field schema is replaced only if fields are passed. The type of an existing field can't be changed,
+// events keep the values they were written with. The schedule is replaced, a missing one removes it.
+//
 //	Responses:
 //	  200: EditEventTypeResponse
 func (s *Event) EditEventType(w http.ResponseWriter, r *http.Request) {
@@ -193,6 +224,103 @@ func (s *Event) EditEventType(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// swagger:parameters DeleteEventTypeRequest
+type DeleteEventTypeRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+	// In: body
+	Body struct {
+		dto.DeleteEventTypeDTO
+	}
+}
+
+// swagger:response DeleteEventTypeResponse
+type DeleteEventTypeResponse struct {
+}
+
+// swagger:route DELETE /api/v1/events/types/{id} EventType DeleteEventTypeRequest
+//
+// # Deleting the event type
+//
+// Mode archive hides the type and keeps its events, delete removes the type together with its events,
+// reassign moves the events to the type from targetTypeId and removes the type.
+//
+//	Responses:
+//	  200: DeleteEventTypeResponse
+func (s *Event) DeleteEventType(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.DeleteEventTypeDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	req.ID, err = utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.service.DeleteType(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters RestoreEventTypeRequest
+type RestoreEventTypeRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response RestoreEventTypeResponse
+type RestoreEventTypeResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.EventType `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/events/types/{id}/restore EventType RestoreEventTypeRequest
+//
+// # Restoring an archived or deleted event type
+//
+// A deleted type is restored together with the events deleted with it, if the retention period has not passed.
+//
+//	Responses:
+//	  200: RestoreEventTypeResponse
+func (s *Event) RestoreEventType(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	eventType, err := s.service.RestoreType(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, eventType)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
 // swagger:parameters CreateEventRequest
 type CreateEventRequest struct {
 	// In: body
@@ -213,6 +341,9 @@ type CreateEventResponse struct {
 //
 // # Create an event
 //
+// The date is stored with the time of day. With endedAt the event has a length and must not overlap
+// other events of the type with a length. Custom fields are validated against the field schema of the event type.
+//
 //	Responses:
 //	  200: CreateEventResponse
 func (s *Event) CreateEvent(w http.ResponseWriter, r *http.Request) {
@@ -245,6 +376,149 @@ func (s *Event) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// swagger:parameters GetEventRequest
+type GetEventRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response GetEventResponse
+type GetEventResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.Event `json:"data"`
+	}
+}
+
+// swagger:route GET /api/v1/events/{id} Event GetEventRequest
+//
+// # Getting an event by ID
+//
+//	Responses:
+//	  200: GetEventResponse
+func (s *Event) GetEvent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	event, err := s.service.GetEvent(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, event)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters UpdateEventRequest
+type UpdateEventRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+	// In: body
+	Body struct {
+		dto.UpdateEventDTO
+	}
+}
+
+// swagger:response UpdateEventResponse
+type UpdateEventResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.Event `json:"data"`
+	}
+}
+
+// swagger:route PUT /api/v1/events/{id} Event UpdateEventRequest
+//
+// # Editing the date, the type, the value and the note of an event
+//
+// PUT requires the type and the date and clears the value, the note and the custom fields if they are missing,
+// PATCH with the same body changes only the passed fields. Custom fields are checked against the schema
+// of the type when they or the type are changed. Setting endedAt of a running timer stops it.
+//
+//	Responses:
+//	  200: UpdateEventResponse
+func (s *Event) UpdateEvent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.UpdateEventDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	req.ID, err = utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Method == http.MethodPut && (req.EventTypeID == nil || req.Date == nil) {
+		http.Error(w, "eventTypeId and date are required", http.StatusBadRequest)
+		return
+	}
+	req.Replace = r.Method == http.MethodPut
+
+	event, err := s.service.UpdateEvent(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, event)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters DeleteEventRequest
+type DeleteEventRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response DeleteEventResponse
+type DeleteEventResponse struct {
+}
+
+// swagger:route DELETE /api/v1/events/{id} Event DeleteEventRequest
+//
+// # Deleting an event
+//
+//	Responses:
+//	  200: DeleteEventResponse
+func (s *Event) DeleteEvent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = s.service.DeleteEvent(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
 // swagger:parameters ListEventRequest
 type ListEventRequest struct {
 	// In: body
@@ -266,6 +540,11 @@ type ListEventResponse struct {
 //
 // # Getting a list of events
 //
+// The day, the month or the year of the date is taken in the time zone of the current user.
+// With minValue or maxValue only events with a value in the range are returned.
+// Filters by custom fields skip events without the field or with a value of another type.
+// Events of another user are returned only to friends, notes only if the type shares them.
+//
 //	Responses:
 //	  200: ListEventResponse
 func (s *Event) ListEvent(w http.ResponseWriter, r *http.Request) {
@@ -303,43 +582,503 @@ func (s *Event) ListEvent(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// swagger:parameters FeedEventsRequest
-type FeedEventsRequest struct {
+// swagger:parameters EventStatsRequest
+type EventStatsRequest struct {
+	// In: body
+	Body struct {
+		dto.ListEventDTO
+	}
 }
 
-// swagger:response FeedEventsResponse
-type FeedEventsResponse struct {
+// swagger:response EventStatsResponse
+type EventStatsResponse struct {
 	// In: body
 	Body struct {
-		Data []*dto.FeedResponseDTO `json:"data"`
-		Meta *utils.Meta            `json:"meta"`
+		Data []*dto.EventStatsResponseDTO `json:"data"`
 	}
 }
 
-// swagger:route GET /api/v1/events/feed Event FeedEventsRequest
+// swagger:route POST /api/v1/events/stats Event EventStatsRequest
 //
-// # Getting a list of friend events
+// # Getting the count, sum and average of event values per day
+//
+// Takes the same filters as the list of events, one item is returned for each day and type.
 //
 //	Responses:
-//	  200: FeedEventsResponse
-func (s *Event) FeedEvents(w http.ResponseWriter, r *http.Request) {
+//	  200: EventStatsResponse
+func (s *Event) EventStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userID := utils.GetUserIDFromContext(ctx)
 
-	events, total, err := s.service.FriendsFeed(ctx, userID)
+	req := &dto.ListEventDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
 	if err != nil {
-		errs.HttpError(w, err)
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
 		return
 	}
-	if events == nil {
-		events = make([]*dto.FeedResponseDTO, 0)
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	meta := &utils.Meta{
-		Total: total,
+	stats, err := s.service.EventStats(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
 	}
-	err = utils.ResponseWithMeta(w, events, meta)
+	if stats == nil {
+		stats = make([]*dto.EventStatsResponseDTO, 0)
+	}
+
+	err = utils.Response(w, stats)
 	if err != nil {
 		logger.Error.Println("error write to socket:", err.Error())
 	}
 }
+
+// swagger:parameters EventDurationsRequest
+type EventDurationsRequest struct {
+	// In: body
+	Body struct {
+		dto.ListEventDTO
+	}
+}
+
+// swagger:response EventDurationsResponse
+type EventDurationsResponse struct {
+	// In: body
+	Body struct {
+		Data []*dto.EventDurationResponseDTO `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/events/durations Event EventDurationsRequest
+//
+// # Getting the total length of events per type in the period
+//
+// Takes the same filters as the list of events. Events which only partly overlap the period are cut to it,
+// running timers are counted up to now.
+//
+//	Responses:
+//	  200: EventDurationsResponse
+func (s *Event) EventDurations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.ListEventDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	durations, err := s.service.EventDurations(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if durations == nil {
+		durations = make([]*dto.EventDurationResponseDTO, 0)
+	}
+
+	err = utils.Response(w, durations)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters ExpectedEventsRequest
+type ExpectedEventsRequest struct {
+	// In: body
+	Body struct {
+		dto.ListExpectedDTO
+	}
+}
+
+// swagger:response ExpectedEventsResponse
+type ExpectedEventsResponse struct {
+	// In: body
+	Body struct {
+		Data []*dto.ExpectedResponseDTO `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/events/expected Event ExpectedEventsRequest
+//
+// # Getting the expected and the actual events per day for the types with a schedule
+//
+// Days are returned if an event was expected or happened, days are calculated in the time zone of the user.
+//
+//	Responses:
+//	  200: ExpectedEventsResponse
+func (s *Event) ExpectedEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.ListExpectedDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	days, err := s.service.ExpectedEvents(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if days == nil {
+		days = make([]*dto.ExpectedResponseDTO, 0)
+	}
+
+	err = utils.Response(w, days)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters FeedEventsRequest
+type FeedEventsRequest struct {
+}
+
+// swagger:response FeedEventsResponse
+type FeedEventsResponse struct {
+	// In: body
+	Body struct {
+		Data []*dto.FeedResponseDTO `json:"data"`
+		Meta *utils.Meta            `json:"meta"`
+	}
+}
+
+// swagger:route GET /api/v1/events/feed Event FeedEventsRequest
+//
+// # Getting a list of friend events
+//
+// Events of archived types are hidden. Notes are shown only for the types that share them.
+//
+//	Responses:
+//	  200: FeedEventsResponse
+func (s *Event) FeedEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	events, total, err := s.service.FriendsFeed(ctx, userID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if events == nil {
+		events = make([]*dto.FeedResponseDTO, 0)
+	}
+
+	meta := &utils.Meta{
+		Total: total,
+	}
+	err = utils.ResponseWithMeta(w, events, meta)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters StartTimerRequest
+type StartTimerRequest struct {
+	// In: body
+	Body struct {
+		dto.StartTimerDTO
+	}
+}
+
+// swagger:response StartTimerResponse
+type StartTimerResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.Event `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/events/timers Timer StartTimerRequest
+//
+// # Starting a timer
+//
+// Creates an event which starts now and runs until it is stopped. Only one timer of a type can run at a time.
+//
+//	Responses:
+//	  200: StartTimerResponse
+func (s *Event) StartTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.StartTimerDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event, err := s.service.StartTimer(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, event)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters ListTimersRequest
+type ListTimersRequest struct {
+}
+
+// swagger:response ListTimersResponse
+type ListTimersResponse struct {
+	// In: body
+	Body struct {
+		Data []*entity.Event `json:"data"`
+		Meta *utils.Meta     `json:"meta"`
+	}
+}
+
+// swagger:route GET /api/v1/events/timers Timer ListTimersRequest
+//
+// # Getting a list of running timers
+//
+//	Responses:
+//	  200: ListTimersResponse
+func (s *Event) ListTimers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	events, total, err := s.service.ListTimers(ctx, userID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if events == nil {
+		events = make([]*entity.Event, 0)
+	}
+
+	meta := &utils.Meta{
+		Total: total,
+	}
+	err = utils.ResponseWithMeta(w, events, meta)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters StopTimerRequest
+type StopTimerRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response StopTimerResponse
+type StopTimerResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.Event `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/events/timers/{id}/stop Timer StopTimerRequest
+//
+// # Stopping a running timer
+//
+// The event ends now.
+//
+//	Responses:
+//	  200: StopTimerResponse
+func (s *Event) StopTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	event, err := s.service.StopTimer(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, event)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters GetStreaksRequest
+type GetStreaksRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response GetStreaksResponse
+type GetStreaksResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.StreakResponseDTO `json:"data"`
+	}
+}
+
+// swagger:route GET /api/v1/events/types/{id}/streaks Streak GetStreaksRequest
+//
+// # Getting the current and the longest streak of an event type with the history of runs
+//
+// Days are calculated in the time zone of the user. Only the days on which events are expected by the schedule
+// of the type count, without a schedule every day does. A frozen day doesn't break the streak.
+//
+//	Responses:
+//	  200: GetStreaksResponse
+func (s *Event) GetStreaks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	streaks, err := s.streakService.Get(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, streaks)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters CreateStreakFreezeRequest
+type CreateStreakFreezeRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+	// In: body
+	Body struct {
+		dto.StreakFreezeDTO
+	}
+}
+
+// swagger:response CreateStreakFreezeResponse
+type CreateStreakFreezeResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.StreakFreeze `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/events/types/{id}/streaks/freezes Streak CreateStreakFreezeRequest
+//
+// # Freezing a day of the streak
+//
+// The date is a day like 2023-01-31 in the time zone of the user, future days can be frozen too.
+//
+//	Responses:
+//	  200: CreateStreakFreezeResponse
+func (s *Event) CreateStreakFreeze(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.StreakFreezeDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	req.EventTypeID, err = utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	freeze, err := s.streakService.CreateFreeze(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, freeze)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters DeleteStreakFreezeRequest
+type DeleteStreakFreezeRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+	// In: path
+	Date string `json:"date"`
+}
+
+// swagger:response DeleteStreakFreezeResponse
+type DeleteStreakFreezeResponse struct {
+}
+
+// swagger:route DELETE /api/v1/events/types/{id}/streaks/freezes/{date} Streak DeleteStreakFreezeRequest
+//
+// # Unfreezing a day of the streak
+//
+//	Responses:
+//	  200: DeleteStreakFreezeResponse
+func (s *Event) DeleteStreakFreeze(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+	req := &dto.StreakFreezeDTO{
+		EventTypeID: id,
+		Date:        mux.Vars(r)["date"],
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.streakService.DeleteFreeze(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/service"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+type Export struct {
+	service service.IExport
+}
+
+func NewExport(service service.IExport) *Export {
+	return &Export{
+		service: service,
+	}
+}
+func (s *Export) RegisterPublicRouter(router *mux.Router, middleware ...mux.MiddlewareFunc) {
+	exportRouter := router.PathPrefix("").Subrouter()
+	exportRouter.HandleFunc("/{id:[0-9]+}/download", s.Download).Methods(http.MethodGet)
+	exportRouter.Use(middleware...)
+}
+func (s *Export) RegisterPrivateRouter(router *mux.Router, middleware ...mux.MiddlewareFunc) {
+	exportRouter := router.PathPrefix("").Subrouter()
+	exportRouter.HandleFunc("", s.Create).Methods(http.MethodPost)
+	exportRouter.HandleFunc("/{id:[0-9]+}", s.Get).Methods(http.MethodGet)
+	exportRouter.Use(middleware...)
+}
+
+/*
+ * Public
+ */
+
+// swagger:parameters ExportDownloadRequest
+type ExportDownloadRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+	// In: query
+	Token string `json:"token"`
+}
+
+// swagger:response ExportDownloadResponse
+type ExportDownloadResponse struct {
+	// In: body
+	Body []byte
+}
+
+// swagger:route GET /api/v1/user/export/{id}/download Export ExportDownloadRequest
+//
+// # Downloading the ZIP archive of a personal data export
+//
+// The link with the token is returned by the status endpoint once the export is ready.
+//
+//	Produces:
+//	- application/zip
+//
+//	Responses:
+//	  200: ExportDownloadResponse
+func (s *Export) Download(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Missing token", http.StatusBadRequest)
+		return
+	}
+
+	archive, err := s.service.Download(ctx, id, token)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"export-%d.zip\"", id))
+	_, err = w.Write(archive)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+/*
+ * Private
+ */
+
+// swagger:parameters ExportCreateRequest
+type ExportCreateRequest struct {
+}
+
+// swagger:response ExportCreateResponse
+type ExportCreateResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.Export `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/user/export Export ExportCreateRequest
+//
+// # Requesting an archive with all personal data
+//
+// The archive is built in the background, the progress can be checked with the status endpoint.
+// While an export is pending, the same export is returned.
+//
+//	Responses:
+//	  202: ExportCreateResponse
+func (s *Export) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	export, err := s.service.Create(ctx, userID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	err = utils.Response(w, export)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters ExportGetRequest
+type ExportGetRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response ExportGetResponse
+type ExportGetResponse struct {
+	// In: body
+	Body struct {
+		Data *entity.Export `json:"data"`
+	}
+}
+
+// swagger:route GET /api/v1/user/export/{id} Export ExportGetRequest
+//
+// # Getting the status of a personal data export
+//
+// A ready export contains an expiring download link.
+//
+//	Responses:
+//	  200: ExportGetResponse
+func (s *Export) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	export, err := s.service.Get(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, export)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
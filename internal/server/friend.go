@@ -25,13 +25,13 @@ func NewFriend(service service.IFriend) *Friend {
 
 func (s *Friend) RegisterPrivateRouter(router *mux.Router, middleware ...mux.MiddlewareFunc) {
 	friendRouter := router.PathPrefix("").Subrouter()
-	friendRouter.HandleFunc("", s.FriendList).Methods(http.MethodGet)
-	friendRouter.HandleFunc("/{id:[0-9]+}", s.InviteAccept).Methods(http.MethodDelete)
+	friendRouter.HandleFunc("", withScope(entity.ScopeFriendsRead, s.FriendList)).Methods(http.MethodGet)
+	friendRouter.HandleFunc("/{id:[0-9]+}", withScope(entity.ScopeFriendsWrite, s.InviteAccept)).Methods(http.MethodDelete)
 
-	friendRouter.HandleFunc("/invites", s.InviteFriend).Methods(http.MethodPost)
-	friendRouter.HandleFunc("/invites", s.InviteList).Methods(http.MethodGet)
-	friendRouter.HandleFunc("/invites/{id:[0-9]+}", s.InviteAccept).Methods(http.MethodPost)
-	friendRouter.HandleFunc("/invites/{id:[0-9]+}", s.InviteReject).Methods(http.MethodDelete)
+	friendRouter.HandleFunc("/invites", withScope(entity.ScopeFriendsWrite, s.InviteFriend)).Methods(http.MethodPost)
+	friendRouter.HandleFunc("/invites", withScope(entity.ScopeFriendsRead, s.InviteList)).Methods(http.MethodGet)
+	friendRouter.HandleFunc("/invites/{id:[0-9]+}", withScope(entity.ScopeFriendsWrite, s.InviteAccept)).Methods(http.MethodPost)
+	friendRouter.HandleFunc("/invites/{id:[0-9]+}", withScope(entity.ScopeFriendsWrite, s.InviteReject)).Methods(http.MethodDelete)
 	friendRouter.Use(middleware...)
 }
 
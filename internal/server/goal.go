@@ -0,0 +1,281 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/service"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+type Goal struct {
+	service service.IGoal
+}
+
+func NewGoal(service service.IGoal) *Goal {
+	return &Goal{
+		service: service,
+	}
+}
+func (s *Goal) RegisterPrivateRouter(router *mux.Router, middleware ...mux.MiddlewareFunc) {
+	goalRouter := router.PathPrefix("").Subrouter()
+	goalRouter.HandleFunc("", withScope(entity.ScopeEventsWrite, s.Create)).Methods(http.MethodPost)
+	goalRouter.HandleFunc("", withScope(entity.ScopeEventsRead, s.List)).Methods(http.MethodGet)
+	goalRouter.HandleFunc("/{id:[0-9]+}", withScope(entity.ScopeEventsRead, s.Get)).Methods(http.MethodGet)
+	goalRouter.HandleFunc("/{id:[0-9]+}", withScope(entity.ScopeEventsWrite, s.Update)).Methods(http.MethodPut)
+	goalRouter.HandleFunc("/{id:[0-9]+}", withScope(entity.ScopeEventsWrite, s.Delete)).Methods(http.MethodDelete)
+	goalRouter.Use(middleware...)
+}
+
+/*
+ * Private
+ */
+
+// swagger:parameters GoalCreateRequest
+type GoalCreateRequest struct {
+	// In: body
+	Body struct {
+		dto.CreateGoalDTO
+	}
+}
+
+// swagger:response GoalCreateResponse
+type GoalCreateResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.GoalStatusDTO `json:"data"`
+	}
+}
+
+// swagger:route POST /api/v1/goals Goal GoalCreateRequest
+//
+// # Creating a goal for an event type
+//
+// A goal compares the number of events or the sum of their values in each day, week, month or year
+// with the target, at_least or at_most. Weeks start on Monday, periods are calculated in the time zone of the user.
+//
+//	Responses:
+//	  200: GoalCreateResponse
+func (s *Goal) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.CreateGoalDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	goal, err := s.service.Create(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, goal)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters GoalListRequest
+type GoalListRequest struct {
+}
+
+// swagger:response GoalListResponse
+type GoalListResponse struct {
+	// In: body
+	Body struct {
+		Data []*dto.GoalStatusDTO `json:"data"`
+		Meta *utils.Meta          `json:"meta"`
+	}
+}
+
+// swagger:route GET /api/v1/goals Goal GoalListRequest
+//
+// # Getting a list of goals with the progress in the current period
+//
+//	Responses:
+//	  200: GoalListResponse
+func (s *Goal) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	goals, total, err := s.service.List(ctx, userID)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if goals == nil {
+		goals = make([]*dto.GoalStatusDTO, 0)
+	}
+
+	meta := &utils.Meta{
+		Total: total,
+	}
+	err = utils.ResponseWithMeta(w, goals, meta)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters GoalGetRequest
+type GoalGetRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+	// Number of past periods in the history, 12 by default
+	// In: query
+	Periods int32 `json:"periods"`
+}
+
+// swagger:response GoalGetResponse
+type GoalGetResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.GoalStatusDTO `json:"data"`
+	}
+}
+
+// swagger:route GET /api/v1/goals/{id} Goal GoalGetRequest
+//
+// # Getting a goal with the progress in the current period and the completion history
+//
+//	Responses:
+//	  200: GoalGetResponse
+func (s *Goal) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+	req := &dto.GetGoalDTO{
+		ID:      id,
+		Periods: utils.GetInt32FromQuery(r, "periods", 12),
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	goal, err := s.service.Get(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, goal)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters GoalUpdateRequest
+type GoalUpdateRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+	// In: body
+	Body struct {
+		dto.UpdateGoalDTO
+	}
+}
+
+// swagger:response GoalUpdateResponse
+type GoalUpdateResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.GoalStatusDTO `json:"data"`
+	}
+}
+
+// swagger:route PUT /api/v1/goals/{id} Goal GoalUpdateRequest
+//
+// # Editing a goal
+//
+// The progress of past periods is evaluated again with the new target.
+//
+//	Responses:
+//	  200: GoalUpdateResponse
+func (s *Goal) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.UpdateGoalDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+
+	req.ID, err = utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	goal, err := s.service.Update(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, goal)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters GoalDeleteRequest
+type GoalDeleteRequest struct {
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response GoalDeleteResponse
+type GoalDeleteResponse struct {
+}
+
+// swagger:route DELETE /api/v1/goals/{id} Goal GoalDeleteRequest
+//
+// # Deleting a goal
+//
+//	Responses:
+//	  200: GoalDeleteResponse
+func (s *Goal) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return
+	}
+
+	err = s.service.Delete(ctx, userID, id)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+// withRole rejects requests of users without the role, the role is put in the context by the role middleware
+func withRole(role string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !utils.HasRoleInContext(r.Context(), role) {
+			http.Error(w, "Not enough permissions", http.StatusForbidden)
+			return
+		}
+		handler(w, r)
+	}
+}
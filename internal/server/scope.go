@@ -0,0 +1,18 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+// withScope rejects requests made with an access token that has no such scope
+func withScope(scope string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !utils.HasScopeInContext(r.Context(), scope) {
+			http.Error(w, "Access token has no "+scope+" scope", http.StatusForbidden)
+			return
+		}
+		handler(w, r)
+	}
+}
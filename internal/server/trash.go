@@ -0,0 +1,193 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/service"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+type Trash struct {
+	service service.ITrash
+}
+
+func NewTrash(service service.ITrash) *Trash {
+	return &Trash{
+		service: service,
+	}
+}
+
+func (s *Trash) RegisterPrivateRouter(router *mux.Router, middleware ...mux.MiddlewareFunc) {
+	trashRouter := router.PathPrefix("").Subrouter()
+	trashRouter.HandleFunc("", withScope(entity.ScopeEventsRead, s.List)).Methods(http.MethodGet)
+	trashRouter.HandleFunc("/{kind:events|types}/{id:[0-9]+}/restore", withScope(entity.ScopeEventsWrite, s.Restore)).Methods(http.MethodPost)
+	trashRouter.HandleFunc("/{kind:events|types}/{id:[0-9]+}", withScope(entity.ScopeEventsWrite, s.Delete)).Methods(http.MethodDelete)
+	trashRouter.Use(middleware...)
+}
+
+/*
+ * Private
+ */
+
+// swagger:parameters TrashListRequest
+type TrashListRequest struct {
+	// Only events or only types
+	// In: query
+	Kind string `json:"kind"`
+	// In: query
+	Limit int32 `json:"limit"`
+	// In: query
+	Page int32 `json:"page"`
+}
+
+// swagger:response TrashListResponse
+type TrashListResponse struct {
+	// In: body
+	Body struct {
+		Data []*dto.TrashItemResponseDTO `json:"data"`
+		Meta *utils.Meta                 `json:"meta"`
+	}
+}
+
+// swagger:route GET /api/v1/trash Trash TrashListRequest
+//
+// # Getting a list of deleted events and event types
+//
+// Items are deleted permanently after the retention period.
+//
+//	Responses:
+//	  200: TrashListResponse
+func (s *Trash) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.ListTrashDTO{
+		Kind:  r.URL.Query().Get("kind"),
+		Limit: utils.GetInt32FromQuery(r, "limit", 50),
+		Page:  utils.GetInt32FromQuery(r, "page", 1),
+	}
+
+	err := GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, total, err := s.service.List(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+	if items == nil {
+		items = make([]*dto.TrashItemResponseDTO, 0)
+	}
+
+	err = utils.ResponseWithMeta(w, items, &utils.Meta{
+		Total: total,
+		Limit: req.Limit,
+		Page:  req.Page,
+	})
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
+
+// swagger:parameters TrashRestoreRequest
+type TrashRestoreRequest struct {
+	// events or types
+	// In: path
+	Kind string `json:"kind"`
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response TrashRestoreResponse
+type TrashRestoreResponse struct {
+}
+
+// swagger:route POST /api/v1/trash/{kind}/{id}/restore Trash TrashRestoreRequest
+//
+// # Restoring a deleted event or event type
+//
+// A type is restored together with the events deleted with it. An event of a deleted type can't be restored
+// until the type is restored.
+//
+//	Responses:
+//	  200: TrashRestoreResponse
+func (s *Trash) Restore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req, ok := getTrashItem(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.service.Restore(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+// swagger:parameters TrashDeleteRequest
+type TrashDeleteRequest struct {
+	// events or types
+	// In: path
+	Kind string `json:"kind"`
+	// In: path
+	ID int32 `json:"id"`
+}
+
+// swagger:response TrashDeleteResponse
+type TrashDeleteResponse struct {
+}
+
+// swagger:route DELETE /api/v1/trash/{kind}/{id} Trash TrashDeleteRequest
+//
+// # Permanently deleting an event or event type from the trash
+//
+// A type is deleted with all its events.
+//
+//	Responses:
+//	  200: TrashDeleteResponse
+func (s *Trash) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req, ok := getTrashItem(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.service.Delete(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+}
+
+func getTrashItem(w http.ResponseWriter, r *http.Request) (*dto.TrashItemDTO, bool) {
+	id, err := utils.GetInt32FromPath(r, "id")
+	if err != nil {
+		http.Error(w, "Bad id in path", http.StatusBadRequest)
+		return nil, false
+	}
+	req := &dto.TrashItemDTO{
+		Kind: mux.Vars(r)["kind"],
+		ID:   id,
+	}
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return req, true
+}
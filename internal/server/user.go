@@ -28,6 +28,7 @@ func (s *User) RegisterPrivateRouter(router *mux.Router, middleware ...mux.Middl
 	userRouter.HandleFunc("/password", s.Password).Methods(http.MethodPut)
 	userRouter.HandleFunc("/profile", s.UpdateProfile).Methods(http.MethodPut)
 	userRouter.HandleFunc("/image", s.UpdateImage).Methods(http.MethodPut)
+	userRouter.HandleFunc("", s.Delete).Methods(http.MethodDelete)
 	userRouter.Use(middleware...)
 }
 
@@ -114,6 +115,8 @@ func (s *User) Password(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Can't parse request", http.StatusBadRequest)
 		return
 	}
+	req.UserAgent = r.UserAgent()
+	req.IP = utils.GetClientIP(r)
 
 	err = GetValidator().Struct(req)
 	if err != nil {
@@ -148,6 +151,8 @@ type UserUpdateProfileResponse struct {
 //
 // # Updating user information
 //
+// The time zone is an IANA name like Europe/Berlin, days of events are calculated in it.
+//
 //	Responses:
 //	  200: UserUpdateProfileResponse
 func (s *User) UpdateProfile(w http.ResponseWriter, r *http.Request) {
@@ -231,3 +236,58 @@ func (s *User) UpdateImage(w http.ResponseWriter, r *http.Request) {
 		logger.Error.Println("error write to socket:", err.Error())
 	}
 }
+
+// swagger:parameters UserDeleteRequest
+type UserDeleteRequest struct {
+	// In: body
+	Body struct {
+		dto.DeleteAccountDTO
+	}
+}
+
+// swagger:response UserDeleteResponse
+type UserDeleteResponse struct {
+	// In: body
+	Body struct {
+		Data *dto.DeleteAccountResponseDTO `json:"data"`
+	}
+}
+
+// swagger:route DELETE /api/v1/user User UserDeleteRequest
+//
+// # Deleting the account of the current user
+//
+// All data is removed after the grace period, until then the account can be restored by logging in.
+//
+//	Responses:
+//	  200: UserDeleteResponse
+func (s *User) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := utils.GetUserIDFromContext(ctx)
+
+	req := &dto.DeleteAccountDTO{}
+	err := utils.ParseJsonFromHTTPRequest(r.Body, req)
+	if err != nil {
+		http.Error(w, "Can't parse request", http.StatusBadRequest)
+		return
+	}
+	req.UserAgent = r.UserAgent()
+	req.IP = utils.GetClientIP(r)
+
+	err = GetValidator().Struct(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.service.Delete(ctx, userID, req)
+	if err != nil {
+		errs.HttpError(w, err)
+		return
+	}
+
+	err = utils.Response(w, resp)
+	if err != nil {
+		logger.Error.Println("error write to socket:", err.Error())
+	}
+}
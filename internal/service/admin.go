@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+
+	"github.com/HardDie/event_tracker/internal/config"
+	"github.com/HardDie/event_tracker/internal/db"
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/repository"
+)
+
+type IAdmin interface {
+	GetRole(ctx context.Context, userID int32) (string, error)
+
+	ListUsers(ctx context.Context, req *dto.ListUsersDTO) ([]*entity.User, int32, error)
+	GetUser(ctx context.Context, id int32) (*dto.AdminUserResponseDTO, error)
+	Unblock(ctx context.Context, adminID, id int32) error
+	Logout(ctx context.Context, adminID, id int32) error
+	Disable(ctx context.Context, adminID, id int32) error
+	Enable(ctx context.Context, adminID, id int32) error
+	UpdateRole(ctx context.Context, adminID, id int32, req *dto.UpdateRoleDTO) error
+	Stats(ctx context.Context) (*dto.StatsResponseDTO, error)
+
+	Bootstrap(ctx context.Context, username string) error
+}
+
+type Admin struct {
+	userRepository        repository.IUser
+	passwordRepository    repository.IPassword
+	sessionRepository     repository.ISession
+	accessTokenRepository repository.IAccessToken
+	totpRepository        repository.ITOTP
+	statsRepository       repository.IStats
+	authEventRepository   repository.IAuthEvent
+
+	tokens ITokenRevoker
+	cfg    *config.Config
+	db     *db.DB
+}
+
+func NewAdmin(db *db.DB, cfg *config.Config, tokens ITokenRevoker, user repository.IUser, password repository.IPassword,
+	session repository.ISession, accessToken repository.IAccessToken, totp repository.ITOTP, stats repository.IStats,
+	authEvent repository.IAuthEvent) *Admin {
+	return &Admin{
+		db:                    db,
+		cfg:                   cfg,
+		tokens:                tokens,
+		userRepository:        user,
+		passwordRepository:    password,
+		sessionRepository:     session,
+		accessTokenRepository: accessToken,
+		totpRepository:        totp,
+		statsRepository:       stats,
+		authEventRepository:   authEvent,
+	}
+}
+
+func (s *Admin) GetRole(ctx context.Context, userID int32) (string, error) {
+	user, err := s.userRepository.GetByID(s.db.DB, ctx, userID, true)
+	if err != nil {
+		logger.Error.Printf("error while trying get user: %v", err.Error())
+		return "", errs.InternalError
+	}
+	if user == nil || user.DisabledAt != nil {
+		return "", errs.Forbidden.AddMessage("user not found")
+	}
+	return user.Role, nil
+}
+
+func (s *Admin) ListUsers(ctx context.Context, req *dto.ListUsersDTO) ([]*entity.User, int32, error) {
+	res, total, err := s.userRepository.List(s.db.DB, ctx, req)
+	if err != nil {
+		logger.Error.Printf("error list users: %v", err.Error())
+		return nil, 0, errs.InternalError
+	}
+	return res, total, nil
+}
+func (s *Admin) GetUser(ctx context.Context, id int32) (*dto.AdminUserResponseDTO, error) {
+	user, err := s.userRepository.GetByID(s.db.DB, ctx, id, true)
+	if err != nil {
+		logger.Error.Printf("error while trying get user: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if user == nil {
+		return nil, errs.BadRequest.AddMessage("user not found")
+	}
+	res := &dto.AdminUserResponseDTO{
+		User: user,
+	}
+
+	// Lockout state is calculated the same way as on login
+	password, err := s.passwordRepository.GetByUserID(s.db.DB, ctx, id)
+	if err != nil {
+		logger.Error.Printf("error while trying get password: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if password != nil {
+		res.FailedAttempts = password.FailedAttempts
+		if blockedUntil := passwordBlockedUntil(s.cfg, password); blockedUntil != nil && time.Now().Before(*blockedUntil) {
+			res.BlockedUntil = blockedUntil
+		}
+	}
+
+	_, res.ActiveSessions, err = s.sessionRepository.ListByUserID(s.db.DB, ctx, id)
+	if err != nil {
+		logger.Error.Printf("error list sessions: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	_, res.AccessTokens, err = s.accessTokenRepository.ListByUserID(s.db.DB, ctx, id)
+	if err != nil {
+		logger.Error.Printf("error list access tokens: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	totp, err := s.totpRepository.GetByUserID(s.db.DB, ctx, id)
+	if err != nil {
+		logger.Error.Printf("error read totp from DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	res.TwoFactor = totp != nil && totp.EnabledAt != nil
+
+	return res, nil
+}
+func (s *Admin) Unblock(ctx context.Context, adminID, id int32) error {
+	err := s.checkTarget(ctx, adminID, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.passwordRepository.Unblock(s.db.DB, ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.BadRequest.AddMessage("user not found")
+		}
+		logger.Error.Printf("error unblocking password: %v", err.Error())
+		return errs.InternalError
+	}
+
+	s.recordAdminEvent(ctx, s.db.DB, adminID, id, entity.AuthEventUnblock)
+	return nil
+}
+func (s *Admin) Logout(ctx context.Context, adminID, id int32) error {
+	err := s.checkTarget(ctx, adminID, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	err = s.closeSessions(tx, ctx, id)
+	if err != nil {
+		return errs.InternalError
+	}
+
+	s.recordAdminEvent(ctx, tx, adminID, id, entity.AuthEventSessionRevoke)
+	return nil
+}
+func (s *Admin) Disable(ctx context.Context, adminID, id int32) error {
+	if adminID == id {
+		return errs.BadRequest.AddMessage("you can't disable your own account")
+	}
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	err = s.userRepository.Disable(tx, ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+			return errs.BadRequest.AddMessage("user not found or already disabled")
+		}
+		logger.Error.Printf("error disabling user: %v", err.Error())
+		return errs.InternalError
+	}
+
+	// A disabled user must lose access immediately
+	err = s.closeSessions(tx, ctx, id)
+	if err != nil {
+		return errs.InternalError
+	}
+	_, err = s.accessTokenRepository.DeleteAllByUserID(tx, ctx, id)
+	if err != nil {
+		logger.Error.Printf("error deleting access tokens: %v", err.Error())
+		return errs.InternalError
+	}
+
+	s.recordAdminEvent(ctx, tx, adminID, id, entity.AuthEventAccountDisable)
+	return nil
+}
+func (s *Admin) Enable(ctx context.Context, adminID, id int32) error {
+	err := s.userRepository.Enable(s.db.DB, ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.BadRequest.AddMessage("user not found or not disabled")
+		}
+		logger.Error.Printf("error enabling user: %v", err.Error())
+		return errs.InternalError
+	}
+
+	s.recordAdminEvent(ctx, s.db.DB, adminID, id, entity.AuthEventAccountEnable)
+	return nil
+}
+func (s *Admin) UpdateRole(ctx context.Context, adminID, id int32, req *dto.UpdateRoleDTO) error {
+	// Otherwise the last admin could lock everyone out of the admin API
+	if adminID == id {
+		return errs.BadRequest.AddMessage("you can't change your own role")
+	}
+
+	err := s.userRepository.UpdateRole(s.db.DB, ctx, id, req.Role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.BadRequest.AddMessage("user not found")
+		}
+		logger.Error.Printf("error updating user role: %v", err.Error())
+		return errs.InternalError
+	}
+
+	s.recordAdminEvent(ctx, s.db.DB, adminID, id, entity.AuthEventRoleChange, "role "+req.Role)
+	return nil
+}
+func (s *Admin) Stats(ctx context.Context) (*dto.StatsResponseDTO, error) {
+	stats, err := s.statsRepository.Get(s.db.DB, ctx)
+	if err != nil {
+		logger.Error.Printf("error read stats from DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	return stats, nil
+}
+
+// Bootstrap gives the admin role to the configured user, so the first admin doesn't have to be created with SQL.
+// The user must be registered before the setting is applied, otherwise anyone could register the name
+// and become admin on the next restart, so the service doesn't start without it.
+func (s *Admin) Bootstrap(ctx context.Context, username string) error {
+	if username == "" {
+		return nil
+	}
+
+	user, err := s.userRepository.GetByName(s.db.DB, ctx, username)
+	if err != nil {
+		logger.Error.Printf("error while trying get user: %v", err.Error())
+		return errs.InternalError
+	}
+	if user == nil {
+		return fmt.Errorf("admin user %q not found, register it before setting ADMIN_USERNAME", username)
+	}
+	if user.Role == entity.UserRoleAdmin {
+		return nil
+	}
+
+	err = s.userRepository.UpdateRole(s.db.DB, ctx, user.ID, entity.UserRoleAdmin)
+	if err != nil {
+		logger.Error.Printf("error updating user role: %v", err.Error())
+		return errs.InternalError
+	}
+	logger.Info.Printf("user %q is promoted to admin", username)
+	return nil
+}
+
+// checkTarget returns an error if the staff user can't act on the user. Moderators can act only on users,
+// admins on anyone.
+func (s *Admin) checkTarget(ctx context.Context, staffID, id int32) error {
+	staff, err := s.userRepository.GetByID(s.db.DB, ctx, staffID, true)
+	if err != nil {
+		logger.Error.Printf("error while trying get user: %v", err.Error())
+		return errs.InternalError
+	}
+	user, err := s.userRepository.GetByID(s.db.DB, ctx, id, true)
+	if err != nil {
+		logger.Error.Printf("error while trying get user: %v", err.Error())
+		return errs.InternalError
+	}
+	if user == nil {
+		return errs.BadRequest.AddMessage("user not found")
+	}
+	if staff == nil || (staff.Role != entity.UserRoleAdmin && user.Role != entity.UserRoleUser) {
+		return errs.Forbidden.AddMessage("not enough permissions for a user with this role")
+	}
+	return nil
+}
+func (s *Admin) closeSessions(tx godb.Queryer, ctx context.Context, userID int32) error {
+	ids, err := s.sessionRepository.DeleteAllByUserID(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error deleting sessions: %v", err.Error())
+		return err
+	}
+	s.tokens.RevokeTokens(ids...)
+	return nil
+}
+
+// recordAdminEvent shows the action in the history of the affected user, the IP of the admin is not disclosed
+func (s *Admin) recordAdminEvent(ctx context.Context, tx godb.Queryer, adminID, userID int32, eventType string, details ...string) {
+	reason := fmt.Sprintf("by administrator %d", adminID)
+	if len(details) > 0 {
+		reason = strings.Join(details, ", ") + ", " + reason
+	}
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:  userID,
+		Type:    eventType,
+		Success: true,
+		Reason:  authEventReason(reason),
+	})
+}
@@ -2,14 +2,21 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/HardDie/godb/v2"
+
 	"github.com/HardDie/event_tracker/internal/config"
 	"github.com/HardDie/event_tracker/internal/db"
 	"github.com/HardDie/event_tracker/internal/dto"
 	"github.com/HardDie/event_tracker/internal/entity"
 	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/jwt"
 	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/pwdpolicy"
 	"github.com/HardDie/event_tracker/internal/repository"
 	"github.com/HardDie/event_tracker/internal/utils"
 )
@@ -17,29 +24,62 @@ import (
 type IAuth interface {
 	Register(ctx context.Context, req *dto.RegisterDTO) (*entity.User, error)
 	Login(ctx context.Context, req *dto.LoginDTO) (*entity.User, error)
-	Logout(ctx context.Context, sessionID int32) error
-	GenerateCookie(ctx context.Context, userID int32) (*entity.Session, error)
+	Logout(ctx context.Context, session *entity.Session) error
+	RestoreAccount(ctx context.Context, userID int32, ip, userAgent string) error
+	GenerateCookie(ctx context.Context, userID int32, userAgent, ip string) (*entity.Session, error)
 	ValidateCookie(ctx context.Context, session string) (*entity.Session, error)
+	ValidateJWT(ctx context.Context, token string) (*jwt.Claims, error)
+	RefreshCookie(ctx context.Context, req *dto.RefreshDTO) (*entity.Session, error)
 	GetUserInfo(ctx context.Context, userID int32) (*entity.User, error)
+
+	ListSessions(ctx context.Context, userID, currentSessionID int32) ([]*dto.SessionResponseDTO, int32, error)
+	DeleteSession(ctx context.Context, current *entity.Session, sessionID int32) error
+	LogoutOthers(ctx context.Context, current *entity.Session) error
+	History(ctx context.Context, userID int32, req *dto.ListAuthEventDTO) ([]*entity.AuthEvent, int32, error)
+
+	CreateAccessToken(ctx context.Context, userID int32, req *dto.CreateAccessTokenDTO) (*entity.AccessToken, error)
+	ListAccessTokens(ctx context.Context, userID int32) ([]*entity.AccessToken, int32, error)
+	DeleteAccessToken(ctx context.Context, userID, id int32) error
+	ValidateAccessToken(ctx context.Context, token string) (*entity.AccessToken, error)
+
+	SyncRevocations(ctx context.Context) error
+}
+
+// ITokenRevoker is used by the services which close sessions outside of Auth
+type ITokenRevoker interface {
+	RevokeTokens(sessionIDs ...int32)
 }
 
 type Auth struct {
-	userRepository     repository.IUser
-	passwordRepository repository.IPassword
-	sessionRepository  repository.ISession
+	userRepository         repository.IUser
+	passwordRepository     repository.IPassword
+	sessionRepository      repository.ISession
+	refreshTokenRepository repository.IRefreshToken
+	accessTokenRepository  repository.IAccessToken
+	authEventRepository    repository.IAuthEvent
 
-	cfg *config.Config
-	db  *db.DB
+	passwordPolicy *pwdpolicy.Policy
+	signer         *jwt.Signer
+	revocations    *jwt.RevocationList
+	cfg            *config.Config
+	db             *db.DB
 }
 
-func NewAuth(db *db.DB, cfg *config.Config, user repository.IUser, password repository.IPassword,
-	session repository.ISession) *Auth {
+func NewAuth(db *db.DB, cfg *config.Config, passwordPolicy *pwdpolicy.Policy, signer *jwt.Signer, user repository.IUser,
+	password repository.IPassword, session repository.ISession, refreshToken repository.IRefreshToken,
+	accessToken repository.IAccessToken, authEvent repository.IAuthEvent) *Auth {
 	return &Auth{
-		db:                 db,
-		cfg:                cfg,
-		userRepository:     user,
-		passwordRepository: password,
-		sessionRepository:  session,
+		db:                     db,
+		cfg:                    cfg,
+		passwordPolicy:         passwordPolicy,
+		signer:                 signer,
+		revocations:            jwt.NewRevocationList(time.Minute * time.Duration(cfg.JWTTimeout)),
+		userRepository:         user,
+		passwordRepository:     password,
+		sessionRepository:      session,
+		refreshTokenRepository: refreshToken,
+		accessTokenRepository:  accessToken,
+		authEventRepository:    authEvent,
 	}
 }
 
@@ -61,6 +101,22 @@ func (s *Auth) Register(ctx context.Context, req *dto.RegisterDTO) (*entity.User
 		return nil, errs.BadRequest.AddMessage("username already exist")
 	}
 
+	// The username of a deleted account is busy until the account is purged
+	user, err = s.userRepository.GetDeletedByName(tx, ctx, req.Username)
+	if err != nil {
+		logger.Error.Printf("error while trying get user: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if user != nil {
+		return nil, errs.BadRequest.AddMessage("username already exist")
+	}
+
+	// Check if password is strong enough
+	err = checkPasswordPolicy(ctx, s.passwordPolicy, req.Password, req.Username)
+	if err != nil {
+		return nil, err
+	}
+
 	// Hashing password
 	hashPassword, err := utils.HashBcrypt(req.Password)
 	if err != nil {
@@ -82,6 +138,13 @@ func (s *Auth) Register(ctx context.Context, req *dto.RegisterDTO) (*entity.User
 		return nil, errs.InternalError
 	}
 
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:    user.ID,
+		Type:      entity.AuthEventRegister,
+		Success:   true,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
 	return user, nil
 }
 func (s *Auth) Login(ctx context.Context, req *dto.LoginDTO) (*entity.User, error) {
@@ -99,62 +162,163 @@ func (s *Auth) Login(ctx context.Context, req *dto.LoginDTO) (*entity.User, erro
 		return nil, errs.InternalError
 	}
 	if user == nil {
-		return nil, errs.BadRequest.AddMessage("username or password is invalid")
+		// A deleted account can be restored by logging in until it's purged
+		user, err = s.userRepository.GetDeletedByName(tx, ctx, req.Username)
+		if err != nil {
+			logger.Error.Printf("error while trying get user: %v", err.Error())
+			return nil, errs.InternalError
+		}
+		if user == nil || time.Now().Sub(*user.DeletedAt) > time.Hour*24*time.Duration(s.cfg.AccountDeletionGrace) {
+			return nil, errs.BadRequest.AddMessage("username or password is invalid")
+		}
+	}
+
+	// The counter of failed attempts must be committed, so the error isn't kept in err
+	checkErr := s.checkPassword(tx, ctx, user, req)
+	if checkErr != nil {
+		return nil, checkErr
 	}
 
-	// Get password from DB
+	// A disabled account is reported only for the correct password, so it can't be used to probe usernames
+	if user.DisabledAt != nil {
+		recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+			UserID:    user.ID,
+			Type:      entity.AuthEventLogin,
+			Reason:    authEventReason("account is disabled"),
+			IP:        req.IP,
+			UserAgent: req.UserAgent,
+		})
+		return nil, errs.UserBlocked.AddMessage("account is disabled")
+	}
+
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:    user.ID,
+		Type:      entity.AuthEventLogin,
+		Success:   true,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
+	return user, nil
+}
+
+// checkPassword compares the password of the user and counts failed attempts. The block after failed
+// attempts only changes the answer to a wrong password, the correct one is always accepted, so guessing
+// the password of someone else can't keep the owner out. The guessing itself is slowed down by the rate limits.
+func (s *Auth) checkPassword(tx godb.Queryer, ctx context.Context, user *entity.User, req *dto.LoginDTO) error {
 	password, err := s.passwordRepository.GetByUserID(tx, ctx, user.ID)
 	if err != nil {
 		logger.Error.Printf("error while trying get password: %v", err.Error())
-		return nil, errs.InternalError
+		return errs.InternalError
 	}
 	if password == nil {
 		logger.Error.Printf("password for user %d not found", user.ID)
-		return nil, errs.InternalError
+		return errs.InternalError
 	}
 
-	// Check if the password is locked after failed attempts
-	if password.FailedAttempts >= int32(s.cfg.PwdMaxAttempts) {
-		// Check if the password block time has expired
-		if time.Now().Sub(password.UpdatedAt) <= time.Hour*time.Duration(s.cfg.PwdBlockTime) {
-			return nil, errs.UserBlocked.AddMessage("too many invalid requests")
-		}
-		// If the blocking time has expired, reset the counter of failed attempts
-		password, err = s.passwordRepository.ResetFailedAttempts(tx, ctx, password.ID)
-		if err != nil {
-			logger.Error.Printf("error resetting the counter of failed attempts: %v", err)
-			return nil, errs.InternalError
+	if utils.HashBcryptCompare(req.Password, password.PasswordHash) {
+		// Reset the failed attempts counter after the first successful attempt
+		if password.FailedAttempts > 0 {
+			_, err = s.passwordRepository.ResetFailedAttempts(tx, ctx, password.ID)
+			if err != nil {
+				logger.Error.Printf("Error flushing failed attempts: %v", err.Error())
+			}
 		}
+		return nil
 	}
 
-	// Check if password is correct
-	if !utils.HashBcryptCompare(req.Password, password.PasswordHash) {
-		// Increased number of failed attempts
-		_, err = s.passwordRepository.IncreaseFailedAttempts(tx, ctx, password.ID)
-		if err != nil {
-			logger.Error.Printf("Error increasing failed attempts: %v", err.Error())
-		}
-		return nil, errs.BadRequest.AddMessage("username or password is invalid")
+	// Attempts during the block aren't counted, after it the counter is kept until the correct password
+	if blockedUntil := passwordBlockedUntil(s.cfg, password); blockedUntil != nil && time.Now().Before(*blockedUntil) {
+		recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+			UserID:    user.ID,
+			Type:      entity.AuthEventLogin,
+			Reason:    authEventReason("account is blocked"),
+			IP:        req.IP,
+			UserAgent: req.UserAgent,
+		})
+		return errs.UserBlocked.AddMessage(fmt.Sprintf("too many invalid requests, try again in %d seconds",
+			int(time.Until(*blockedUntil).Seconds())+1))
 	}
 
-	// Reset the failed attempts counter after the first successful attempt
-	if password.FailedAttempts > 0 {
-		_, err = s.passwordRepository.ResetFailedAttempts(tx, ctx, password.ID)
-		if err != nil {
-			logger.Error.Printf("Error flushing failed attempts: %v", err.Error())
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:    user.ID,
+		Type:      entity.AuthEventLogin,
+		Reason:    authEventReason("invalid password"),
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
+
+	// Increased number of failed attempts
+	password, err = s.passwordRepository.IncreaseFailedAttempts(tx, ctx, password.ID)
+	if err != nil {
+		logger.Error.Printf("Error increasing failed attempts: %v", err.Error())
+		return errs.InternalError
+	}
+	if s.cfg.PwdMaxAttempts > 0 && password.FailedAttempts == int32(s.cfg.PwdMaxAttempts) {
+		recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+			UserID:    user.ID,
+			Type:      entity.AuthEventLockout,
+			Reason:    authEventReason("too many invalid passwords"),
+			IP:        req.IP,
+			UserAgent: req.UserAgent,
+		})
+	}
+	return errs.BadRequest.AddMessage("username or password is invalid")
+}
+
+// RestoreAccount brings back the deleted account after the full login, including the second factor.
+// Nothing is done if the account isn't deleted.
+func (s *Auth) RestoreAccount(ctx context.Context, userID int32, ip, userAgent string) error {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	err = s.userRepository.Restore(tx, ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+			return nil
 		}
+		logger.Error.Printf("error restoring user: %v", err.Error())
+		return errs.InternalError
 	}
-	return user, nil
+
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:    userID,
+		Type:      entity.AuthEventAccountRestore,
+		Success:   true,
+		IP:        ip,
+		UserAgent: userAgent,
+	})
+	return nil
 }
-func (s *Auth) Logout(ctx context.Context, sessionID int32) error {
-	err := s.sessionRepository.DeleteByID(s.db.DB, ctx, sessionID)
+func (s *Auth) Logout(ctx context.Context, session *entity.Session) error {
+	err := s.sessionRepository.DeleteByID(s.db.DB, ctx, session.ID)
 	if err != nil {
 		logger.Error.Printf("error deleting session: %v", err.Error())
 		return errs.InternalError
 	}
+	s.RevokeTokens(session.ID)
+
+	recordAuthEvent(s.authEventRepository, s.db.DB, ctx, &dto.CreateAuthEventDTO{
+		UserID:    session.UserID,
+		Type:      entity.AuthEventLogout,
+		Success:   true,
+		IP:        session.IP,
+		UserAgent: session.UserAgent,
+	})
 	return nil
 }
-func (s *Auth) GenerateCookie(ctx context.Context, userID int32) (*entity.Session, error) {
+func (s *Auth) GenerateCookie(ctx context.Context, userID int32, userAgent, ip string) (*entity.Session, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
 	// Generate session key
 	sessionKey, err := utils.GenerateSessionKey()
 	if err != nil {
@@ -163,13 +327,24 @@ func (s *Auth) GenerateCookie(ctx context.Context, userID int32) (*entity.Sessio
 	}
 
 	// Write session to DB
-	resp, err := s.sessionRepository.CreateOrUpdate(s.db.DB, ctx, userID, utils.HashSha256(sessionKey))
+	resp, err := s.sessionRepository.Create(tx, ctx, userID, utils.HashSha256(sessionKey), userAgent, ip)
 	if err != nil {
 		logger.Error.Printf("write session to DB: %v", err)
 		return nil, errs.InternalError
 	}
 	resp.Session = sessionKey
 
+	// Issue a refresh token for the session
+	resp.RefreshToken, err = s.createRefreshToken(tx, ctx, resp.ID)
+	if err != nil {
+		return nil, errs.InternalError
+	}
+
+	resp.AccessToken, err = s.createJWT(resp)
+	if err != nil {
+		return nil, errs.InternalError
+	}
+
 	return resp, nil
 }
 func (s *Auth) ValidateCookie(ctx context.Context, sessionToken string) (*entity.Session, error) {
@@ -185,11 +360,116 @@ func (s *Auth) ValidateCookie(ctx context.Context, sessionToken string) (*entity
 	}
 
 	// Check if session is not expired
-	if time.Now().Sub(session.UpdatedAt) > time.Hour*24 {
+	now := time.Now()
+	if now.Sub(session.CreatedAt) > time.Hour*time.Duration(s.cfg.SessionAbsoluteTimeout) {
+		return nil, errs.SessionInvalid.AddMessage("session has expired")
+	}
+	if now.Sub(session.LastSeenAt) > time.Minute*time.Duration(s.cfg.SessionIdleTimeout) {
 		return nil, errs.SessionInvalid.AddMessage("session has expired")
 	}
+
+	// Slide the idle timeout, but don't write to DB on every single request
+	if now.Sub(session.LastSeenAt) > time.Minute {
+		err = s.sessionRepository.UpdateLastSeen(s.db.DB, ctx, session.ID)
+		if err != nil {
+			logger.Error.Printf("error updating session last seen: %v", err.Error())
+		}
+	}
 	return session, nil
 }
+
+// ValidateJWT checks the signed access token without going to the database,
+// the session it was issued for is only checked against the revocation list
+func (s *Auth) ValidateJWT(ctx context.Context, token string) (*jwt.Claims, error) {
+	if s.signer == nil {
+		return nil, errs.SessionInvalid.AddMessage("signed access tokens are disabled")
+	}
+
+	claims, err := s.signer.Parse(token)
+	if err != nil {
+		return nil, errs.SessionInvalid.AddMessage("access token is invalid or expired")
+	}
+	if s.revocations.IsRevoked(claims.SessionID) {
+		return nil, errs.SessionInvalid.AddMessage("session has been closed")
+	}
+	return claims, nil
+}
+func (s *Auth) RefreshCookie(ctx context.Context, req *dto.RefreshDTO) (*entity.Session, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	// Check if refresh token exist
+	token, err := s.refreshTokenRepository.GetByHash(tx, ctx, utils.HashSha256(req.RefreshToken))
+	if err != nil {
+		logger.Error.Printf("error read refresh token from db: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if token == nil {
+		return nil, errs.SessionInvalid.AddMessage("refresh token not exist")
+	}
+
+	// A refresh token that has already been used means it was stolen, so close the whole session
+	if token.UsedAt != nil {
+		return nil, s.revokeSessionOnReuse(tx, ctx, token.SessionID, req)
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return nil, errs.SessionInvalid.AddMessage("refresh token has expired")
+	}
+
+	// Check if session is still alive
+	session, err := s.sessionRepository.GetByID(tx, ctx, token.SessionID)
+	if err != nil {
+		logger.Error.Printf("error read session from db: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if session == nil {
+		return nil, errs.SessionInvalid.AddMessage("session not exist")
+	}
+	if time.Now().Sub(session.CreatedAt) > time.Hour*time.Duration(s.cfg.SessionAbsoluteTimeout) {
+		return nil, errs.SessionInvalid.AddMessage("session has expired")
+	}
+
+	// Mark the refresh token as used, a concurrent refresh with the same token is a reuse as well
+	err = s.refreshTokenRepository.MarkUsed(tx, ctx, token.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+			return nil, s.revokeSessionOnReuse(tx, ctx, token.SessionID, req)
+		}
+		logger.Error.Printf("error marking refresh token as used: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	// Rotate session key
+	sessionKey, err := utils.GenerateSessionKey()
+	if err != nil {
+		logger.Error.Printf("error generate session key: %v", err)
+		return nil, errs.InternalError
+	}
+	resp, err := s.sessionRepository.UpdateHash(tx, ctx, session.ID, utils.HashSha256(sessionKey), req.UserAgent, req.IP)
+	if err != nil {
+		logger.Error.Printf("error updating session: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	resp.Session = sessionKey
+
+	// Issue a new refresh token
+	resp.RefreshToken, err = s.createRefreshToken(tx, ctx, resp.ID)
+	if err != nil {
+		return nil, errs.InternalError
+	}
+
+	resp.AccessToken, err = s.createJWT(resp)
+	if err != nil {
+		return nil, errs.InternalError
+	}
+
+	return resp, nil
+}
 func (s *Auth) GetUserInfo(ctx context.Context, userID int32) (*entity.User, error) {
 	user, err := s.userRepository.GetByID(s.db.DB, ctx, userID, true)
 	if err != nil {
@@ -198,3 +478,266 @@ func (s *Auth) GetUserInfo(ctx context.Context, userID int32) (*entity.User, err
 	}
 	return user, nil
 }
+
+func (s *Auth) ListSessions(ctx context.Context, userID, currentSessionID int32) ([]*dto.SessionResponseDTO, int32, error) {
+	sessions, total, err := s.sessionRepository.ListByUserID(s.db.DB, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error list sessions: %v", err.Error())
+		return nil, 0, errs.InternalError
+	}
+
+	res := make([]*dto.SessionResponseDTO, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, &dto.SessionResponseDTO{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Current:    session.ID == currentSessionID,
+			LastSeenAt: session.LastSeenAt,
+			CreatedAt:  session.CreatedAt,
+		})
+	}
+	return res, total, nil
+}
+func (s *Auth) DeleteSession(ctx context.Context, current *entity.Session, sessionID int32) error {
+	err := s.sessionRepository.DeleteByUserID(s.db.DB, ctx, current.UserID, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.BadRequest.AddMessage("session not found")
+		}
+		logger.Error.Printf("error deleting session: %v", err.Error())
+		return errs.InternalError
+	}
+	s.RevokeTokens(sessionID)
+
+	recordAuthEvent(s.authEventRepository, s.db.DB, ctx, &dto.CreateAuthEventDTO{
+		UserID:    current.UserID,
+		Type:      entity.AuthEventSessionRevoke,
+		Success:   true,
+		Reason:    authEventReason(fmt.Sprintf("session %d", sessionID)),
+		IP:        current.IP,
+		UserAgent: current.UserAgent,
+	})
+	return nil
+}
+func (s *Auth) LogoutOthers(ctx context.Context, current *entity.Session) error {
+	ids, err := s.sessionRepository.DeleteOthersByUserID(s.db.DB, ctx, current.UserID, current.ID)
+	if err != nil {
+		logger.Error.Printf("error deleting other sessions: %v", err.Error())
+		return errs.InternalError
+	}
+	s.RevokeTokens(ids...)
+
+	if len(ids) > 0 {
+		recordAuthEvent(s.authEventRepository, s.db.DB, ctx, &dto.CreateAuthEventDTO{
+			UserID:    current.UserID,
+			Type:      entity.AuthEventSessionRevoke,
+			Success:   true,
+			Reason:    authEventReason(fmt.Sprintf("%d other sessions", len(ids))),
+			IP:        current.IP,
+			UserAgent: current.UserAgent,
+		})
+	}
+	return nil
+}
+func (s *Auth) History(ctx context.Context, userID int32, req *dto.ListAuthEventDTO) ([]*entity.AuthEvent, int32, error) {
+	res, total, err := s.authEventRepository.ListByUserID(s.db.DB, ctx, userID, req)
+	if err != nil {
+		logger.Error.Printf("error list auth events: %v", err.Error())
+		return nil, 0, errs.InternalError
+	}
+	return res, total, nil
+}
+
+func (s *Auth) CreateAccessToken(ctx context.Context, userID int32, req *dto.CreateAccessTokenDTO) (*entity.AccessToken, error) {
+	// Generate token key
+	tokenKey, err := utils.GenerateSessionKey()
+	if err != nil {
+		logger.Error.Printf("error generate token key: %v", err)
+		return nil, errs.InternalError
+	}
+	tokenKey = entity.AccessTokenPrefix + tokenKey
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays != nil {
+		value := time.Now().AddDate(0, 0, int(*req.ExpiresInDays))
+		expiresAt = &value
+	}
+
+	// Write token to DB
+	resp, err := s.accessTokenRepository.Create(s.db.DB, ctx, userID, req.Name, utils.HashSha256(tokenKey), req.Scopes, expiresAt)
+	if err != nil {
+		logger.Error.Printf("write access token to DB: %v", err)
+		return nil, errs.InternalError
+	}
+	resp.Token = tokenKey
+
+	return resp, nil
+}
+func (s *Auth) ListAccessTokens(ctx context.Context, userID int32) ([]*entity.AccessToken, int32, error) {
+	res, cnt, err := s.accessTokenRepository.ListByUserID(s.db.DB, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error list access tokens: %v", err.Error())
+		return nil, 0, errs.InternalError
+	}
+	return res, cnt, nil
+}
+func (s *Auth) DeleteAccessToken(ctx context.Context, userID, id int32) error {
+	err := s.accessTokenRepository.DeleteByUserID(s.db.DB, ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.BadRequest.AddMessage("access token not found")
+		}
+		logger.Error.Printf("error deleting access token: %v", err.Error())
+		return errs.InternalError
+	}
+	return nil
+}
+func (s *Auth) ValidateAccessToken(ctx context.Context, tokenKey string) (*entity.AccessToken, error) {
+	// Check if token exist
+	token, err := s.accessTokenRepository.GetByHash(s.db.DB, ctx, utils.HashSha256(tokenKey))
+	if err != nil {
+		logger.Error.Printf("error read access token from db: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if token == nil {
+		return nil, errs.SessionInvalid.AddMessage("access token not exist")
+	}
+
+	// Check if token is not expired
+	now := time.Now()
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, errs.SessionInvalid.AddMessage("access token has expired")
+	}
+
+	// Remember when the token was used for the last time
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > time.Minute {
+		err = s.accessTokenRepository.UpdateLastUsed(s.db.DB, ctx, token.ID)
+		if err != nil {
+			logger.Error.Printf("error updating access token last used: %v", err.Error())
+		}
+	}
+	return token, nil
+}
+
+// SyncRevocations reloads the sessions closed while tokens issued for them can still be alive,
+// including the ones closed by other instances or by other services
+func (s *Auth) SyncRevocations(ctx context.Context) error {
+	if s.signer == nil {
+		return nil
+	}
+
+	since := time.Now().UTC().Add(-time.Minute * time.Duration(s.cfg.JWTTimeout))
+	ids, err := s.sessionRepository.ListDeletedSince(s.db.DB, ctx, since)
+	if err != nil {
+		logger.Error.Printf("error list closed sessions: %v", err.Error())
+		return errs.InternalError
+	}
+	s.revocations.Replace(ids)
+	return nil
+}
+
+func (s *Auth) createJWT(session *entity.Session) (string, error) {
+	if s.signer == nil {
+		return "", nil
+	}
+
+	now := time.Now()
+	token, err := s.signer.Sign(&jwt.Claims{
+		Subject:   session.UserID,
+		SessionID: session.ID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Minute * time.Duration(s.cfg.JWTTimeout)).Unix(),
+	})
+	if err != nil {
+		logger.Error.Printf("error signing access token: %v", err.Error())
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeTokens rejects signed access tokens of the closed sessions on this instance right away,
+// other instances pick the sessions up on the next sync
+func (s *Auth) RevokeTokens(sessionIDs ...int32) {
+	if s.signer == nil {
+		return
+	}
+	s.revocations.Add(sessionIDs...)
+}
+func (s *Auth) createRefreshToken(tx godb.Queryer, ctx context.Context, sessionID int32) (string, error) {
+	refreshKey, err := utils.GenerateSessionKey()
+	if err != nil {
+		logger.Error.Printf("error generate refresh key: %v", err)
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(time.Hour * time.Duration(s.cfg.RefreshTokenTimeout))
+	_, err = s.refreshTokenRepository.Create(tx, ctx, sessionID, utils.HashSha256(refreshKey), expiresAt)
+	if err != nil {
+		logger.Error.Printf("write refresh token to DB: %v", err)
+		return "", err
+	}
+	return refreshKey, nil
+}
+func (s *Auth) revokeSessionOnReuse(tx godb.Queryer, ctx context.Context, sessionID int32, req *dto.RefreshDTO) error {
+	logger.Warn.Printf("refresh token reuse detected, closing session %d", sessionID)
+
+	session, err := s.sessionRepository.GetByID(tx, ctx, sessionID)
+	if err != nil {
+		logger.Error.Printf("error read session from db: %v", err.Error())
+	}
+	if session != nil {
+		recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+			UserID:    session.UserID,
+			Type:      entity.AuthEventRefreshReuse,
+			Reason:    authEventReason("refresh token has already been used, the session is closed"),
+			IP:        req.IP,
+			UserAgent: req.UserAgent,
+		})
+	}
+
+	err = s.sessionRepository.DeleteByID(tx, ctx, sessionID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.Error.Printf("error deleting session: %v", err.Error())
+	}
+	s.RevokeTokens(sessionID)
+	return errs.SessionInvalid.AddMessage("refresh token has already been used")
+}
+
+// passwordBlockedUntil returns the end of the block after failed attempts or nil if the password isn't blocked.
+// The first block after PwdMaxAttempts failures lasts PwdBlockDelay seconds and every next failure doubles it
+// up to PwdBlockTime hours.
+func passwordBlockedUntil(cfg *config.Config, password *entity.Password) *time.Time {
+	if cfg.PwdMaxAttempts <= 0 || password.FailedAttempts < int32(cfg.PwdMaxAttempts) {
+		return nil
+	}
+
+	limit := time.Hour * time.Duration(cfg.PwdBlockTime)
+	delay := time.Second * time.Duration(cfg.PwdBlockDelay)
+	for i := int32(cfg.PwdMaxAttempts); i < password.FailedAttempts && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+
+	blockedUntil := password.UpdatedAt.Add(delay)
+	return &blockedUntil
+}
+
+// checkPasswordPolicy returns an error with the list of broken rules if the password is too weak
+func checkPasswordPolicy(ctx context.Context, policy *pwdpolicy.Policy, password, username string) error {
+	failed := policy.Check(password, username)
+	// The breach API being down must not block registrations
+	breached, err := policy.Breached(ctx, password)
+	if err != nil {
+		logger.Error.Printf("error checking password for breaches: %v", err.Error())
+	}
+	if breached {
+		failed = append(failed, "password has appeared in a data breach")
+	}
+	if len(failed) > 0 {
+		return errs.BadRequest.AddMessage("password is too weak").AddDetails(failed...)
+	}
+	return nil
+}
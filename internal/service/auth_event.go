@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+
+	"github.com/HardDie/godb/v2"
+
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/repository"
+)
+
+// recordAuthEvent writes the event to the audit log. A failure is only logged,
+// the audit log must not prevent the user from logging in. Inside a transaction the event
+// is written under a savepoint, so a failed insert doesn't abort the rest of the transaction.
+func recordAuthEvent(repo repository.IAuthEvent, tx godb.Queryer, ctx context.Context, req *dto.CreateAuthEventDTO) {
+	sqlTx, inTx := tx.(*godb.SqlTx)
+	if inTx {
+		_, err := sqlTx.ExecContext(ctx, "SAVEPOINT auth_event")
+		if err != nil {
+			logger.Error.Printf("error creating savepoint for auth event %q: %v", req.Type, err.Error())
+			return
+		}
+	}
+
+	_, err := repo.Create(tx, ctx, req)
+	if err != nil {
+		logger.Error.Printf("error writing auth event %q for user %d: %v", req.Type, req.UserID, err.Error())
+		if inTx {
+			_, err = sqlTx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT auth_event")
+			if err != nil {
+				logger.Error.Printf("error rollback to savepoint of auth event %q: %v", req.Type, err.Error())
+			}
+		}
+		return
+	}
+
+	if inTx {
+		_, err = sqlTx.ExecContext(ctx, "RELEASE SAVEPOINT auth_event")
+		if err != nil {
+			logger.Error.Printf("error releasing savepoint of auth event %q: %v", req.Type, err.Error())
+		}
+	}
+}
+
+func authEventReason(reason string) *string {
+	return &reason
+}
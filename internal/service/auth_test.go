@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+
+	"github.com/HardDie/event_tracker/internal/config"
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/repository"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+// fakePassword keeps one password in memory, methods which aren't used panic on the nil interface
+type fakePassword struct {
+	repository.IPassword
+	password *entity.Password
+}
+
+func (r *fakePassword) GetByUserID(tx godb.Queryer, ctx context.Context, userID int32) (*entity.Password, error) {
+	copied := *r.password
+	return &copied, nil
+}
+func (r *fakePassword) IncreaseFailedAttempts(tx godb.Queryer, ctx context.Context, id int32) (*entity.Password, error) {
+	r.password.FailedAttempts++
+	r.password.UpdatedAt = time.Now()
+	return r.GetByUserID(tx, ctx, r.password.UserID)
+}
+func (r *fakePassword) ResetFailedAttempts(tx godb.Queryer, ctx context.Context, id int32) (*entity.Password, error) {
+	r.password.FailedAttempts = 0
+	return r.GetByUserID(tx, ctx, r.password.UserID)
+}
+
+type fakeAuthEvent struct {
+	repository.IAuthEvent
+	events []*dto.CreateAuthEventDTO
+}
+
+func (r *fakeAuthEvent) Create(tx godb.Queryer, ctx context.Context, req *dto.CreateAuthEventDTO) (*entity.AuthEvent, error) {
+	r.events = append(r.events, req)
+	return &entity.AuthEvent{}, nil
+}
+
+func TestPasswordBlockedUntil(t *testing.T) {
+	cfg := &config.Config{
+		PwdMaxAttempts: 5,
+		PwdBlockDelay:  60,
+		PwdBlockTime:   1,
+	}
+	updatedAt := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		failed int32
+		want   time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{8, 8 * time.Minute},
+		{11, time.Hour},
+		{1000, time.Hour},
+	}
+	for _, tt := range tests {
+		got := passwordBlockedUntil(cfg, &entity.Password{FailedAttempts: tt.failed, UpdatedAt: updatedAt})
+		if tt.want == 0 {
+			if got != nil {
+				t.Errorf("%d failed attempts: blocked until %v, want not blocked", tt.failed, got)
+			}
+			continue
+		}
+		if got == nil || !got.Equal(updatedAt.Add(tt.want)) {
+			t.Errorf("%d failed attempts: blocked until %v, want %v", tt.failed, got, updatedAt.Add(tt.want))
+		}
+	}
+
+	cfg.PwdMaxAttempts = 0
+	if got := passwordBlockedUntil(cfg, &entity.Password{FailedAttempts: 100, UpdatedAt: updatedAt}); got != nil {
+		t.Errorf("blocking is disabled, but blocked until %v", got)
+	}
+}
+func TestCheckPasswordAfterFailedAttempts(t *testing.T) {
+	hash, err := utils.HashBcrypt("correct password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwords := &fakePassword{password: &entity.Password{ID: 1, UserID: 1, PasswordHash: hash}}
+	authEvents := &fakeAuthEvent{}
+	s := &Auth{
+		cfg: &config.Config{
+			PwdMaxAttempts: 3,
+			PwdBlockDelay:  60,
+			PwdBlockTime:   24,
+		},
+		passwordRepository:  passwords,
+		authEventRepository: authEvents,
+	}
+	user := &entity.User{ID: 1}
+	ctx := context.Background()
+
+	// Someone else guesses the password until the account is blocked
+	for i := 0; i < 5; i++ {
+		err = s.checkPassword(nil, ctx, user, &dto.LoginDTO{Password: "guess", IP: "192.0.2.1"})
+		if err == nil {
+			t.Fatal("wrong password is accepted")
+		}
+	}
+	if passwords.password.FailedAttempts != 3 {
+		t.Errorf("failed attempts = %d, want 3, attempts during the block aren't counted", passwords.password.FailedAttempts)
+	}
+	if !errors.Is(err, errs.UserBlocked) {
+		t.Errorf("error during the block = %v, want %v", err, errs.UserBlocked)
+	}
+
+	// The owner still logs in and the counter is reset
+	err = s.checkPassword(nil, ctx, user, &dto.LoginDTO{Password: "correct password", IP: "198.51.100.1"})
+	if err != nil {
+		t.Fatalf("correct password after failed attempts of someone else: %v", err)
+	}
+	if passwords.password.FailedAttempts != 0 {
+		t.Errorf("failed attempts = %d after the correct password, want 0", passwords.password.FailedAttempts)
+	}
+
+	var lockouts int
+	for _, event := range authEvents.events {
+		if event.Type == entity.AuthEventLockout {
+			lockouts++
+		}
+	}
+	if lockouts != 1 {
+		t.Errorf("%d lockout events, want 1", lockouts)
+	}
+}
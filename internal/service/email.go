@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/HardDie/event_tracker/internal/config"
+	"github.com/HardDie/event_tracker/internal/db"
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/mailer"
+	"github.com/HardDie/event_tracker/internal/pwdpolicy"
+	"github.com/HardDie/event_tracker/internal/repository"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+const (
+	tokenPurposeVerifyEmail   = "verify-email"
+	tokenPurposeResetPassword = "reset-password"
+
+	mailSendTimeout = 30 * time.Second
+)
+
+type IEmail interface {
+	SendVerification(ctx context.Context, userID int32) error
+	Verify(ctx context.Context, req *dto.VerifyEmailDTO) error
+	ForgotPassword(ctx context.Context, req *dto.ForgotPasswordDTO) error
+	ResetPassword(ctx context.Context, req *dto.ResetPasswordDTO) error
+}
+
+type Email struct {
+	userRepository      repository.IUser
+	passwordRepository  repository.IPassword
+	sessionRepository   repository.ISession
+	authEventRepository repository.IAuthEvent
+
+	mailer         mailer.IMailer
+	passwordPolicy *pwdpolicy.Policy
+	tokens         ITokenRevoker
+	cfg            *config.Config
+	db             *db.DB
+}
+
+func NewEmail(db *db.DB, cfg *config.Config, tokens ITokenRevoker, mailer mailer.IMailer, passwordPolicy *pwdpolicy.Policy,
+	user repository.IUser, password repository.IPassword, session repository.ISession,
+	authEvent repository.IAuthEvent) *Email {
+	return &Email{
+		db:                  db,
+		cfg:                 cfg,
+		tokens:              tokens,
+		mailer:              mailer,
+		passwordPolicy:      passwordPolicy,
+		userRepository:      user,
+		passwordRepository:  password,
+		sessionRepository:   session,
+		authEventRepository: authEvent,
+	}
+}
+
+func (s *Email) SendVerification(ctx context.Context, userID int32) error {
+	user, err := s.userRepository.GetByID(s.db.DB, ctx, userID, true)
+	if err != nil {
+		logger.Error.Printf("error get user: %v", err.Error())
+		return errs.InternalError
+	}
+	if user == nil {
+		logger.Error.Printf("user %d not found", userID)
+		return errs.InternalError
+	}
+	if user.Email == nil {
+		return errs.BadRequest.AddMessage("email is not set")
+	}
+	if user.EmailVerifiedAt != nil {
+		return errs.BadRequest.AddMessage("email is already verified")
+	}
+
+	// The token is bound to the email, so it stops working when the email is changed
+	expiresAt := time.Now().Add(time.Hour * time.Duration(s.cfg.EmailVerifyTimeout))
+	token := utils.SignToken(s.cfg.TokenSecret, tokenPurposeVerifyEmail, user.ID, expiresAt, *user.Email)
+
+	s.send(&mailer.Message{
+		To:      *user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hello, %s!\n\nTo confirm your email, follow the link:\n%s\n\n"+
+			"The link is valid until %s.\nIf you didn't request this, just ignore this email.\n",
+			user.DisplayedName, s.link("verify-email", token), expiresAt.UTC().Format(time.RFC1123)),
+	})
+	return nil
+}
+func (s *Email) Verify(ctx context.Context, req *dto.VerifyEmailDTO) error {
+	token, err := utils.ParseSignedToken(req.Token)
+	if err != nil {
+		return errs.BadRequest.AddMessage("invalid token")
+	}
+
+	user, err := s.userRepository.GetByID(s.db.DB, ctx, token.UserID, true)
+	if err != nil {
+		logger.Error.Printf("error get user: %v", err.Error())
+		return errs.InternalError
+	}
+	if user == nil || user.Email == nil {
+		return errs.BadRequest.AddMessage("invalid token")
+	}
+	if !token.Verify(s.cfg.TokenSecret, tokenPurposeVerifyEmail, *user.Email) {
+		return errs.BadRequest.AddMessage("invalid token")
+	}
+
+	err = s.userRepository.VerifyEmail(s.db.DB, ctx, user.ID, *user.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.BadRequest.AddMessage("invalid token")
+		}
+		logger.Error.Printf("error verify email: %v", err.Error())
+		return errs.InternalError
+	}
+	return nil
+}
+func (s *Email) ForgotPassword(ctx context.Context, req *dto.ForgotPasswordDTO) error {
+	// Nothing is reported back, so the endpoint can't be used to find out
+	// which usernames exist or which of them have an email
+	user, err := s.userRepository.GetByName(s.db.DB, ctx, req.Username)
+	if err != nil {
+		logger.Error.Printf("error get user: %v", err.Error())
+		return errs.InternalError
+	}
+	if user == nil || user.Email == nil || user.EmailVerifiedAt == nil {
+		return nil
+	}
+
+	password, err := s.passwordRepository.GetByUserID(s.db.DB, ctx, user.ID)
+	if err != nil {
+		logger.Error.Printf("error read password from DB: %v", err.Error())
+		return errs.InternalError
+	}
+	if password == nil {
+		logger.Error.Printf("password for user %d not found in DB", user.ID)
+		return errs.InternalError
+	}
+
+	// The token is bound to the current password hash, so it can be used only once
+	expiresAt := time.Now().Add(time.Minute * time.Duration(s.cfg.PwdResetTimeout))
+	token := utils.SignToken(s.cfg.TokenSecret, tokenPurposeResetPassword, user.ID, expiresAt, password.PasswordHash)
+
+	s.send(&mailer.Message{
+		To:      *user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello, %s!\n\nTo set a new password for %s, follow the link:\n%s\n\n"+
+			"The link is valid until %s.\nIf you didn't request this, just ignore this email.\n",
+			user.DisplayedName, user.Username, s.link("reset-password", token), expiresAt.UTC().Format(time.RFC1123)),
+	})
+	return nil
+}
+func (s *Email) ResetPassword(ctx context.Context, req *dto.ResetPasswordDTO) error {
+	token, err := utils.ParseSignedToken(req.Token)
+	if err != nil {
+		return errs.BadRequest.AddMessage("invalid token")
+	}
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	// Get password from DB
+	password, err := s.passwordRepository.GetByUserID(tx, ctx, token.UserID)
+	if err != nil {
+		logger.Error.Printf("error read password from DB: %v", err.Error())
+		return errs.InternalError
+	}
+	if password == nil {
+		return errs.BadRequest.AddMessage("invalid token")
+	}
+	if !token.Verify(s.cfg.TokenSecret, tokenPurposeResetPassword, password.PasswordHash) {
+		return errs.BadRequest.AddMessage("invalid token")
+	}
+
+	// Check if new password is strong enough
+	user, err := s.userRepository.GetByID(tx, ctx, token.UserID, true)
+	if err != nil {
+		logger.Error.Printf("error get user: %v", err.Error())
+		return errs.InternalError
+	}
+	if user == nil {
+		return errs.BadRequest.AddMessage("invalid token")
+	}
+	err = checkPasswordPolicy(ctx, s.passwordPolicy, req.Password, user.Username)
+	if err != nil {
+		return err
+	}
+
+	// Hashing password
+	hashPassword, err := utils.HashBcrypt(req.Password)
+	if err != nil {
+		logger.Error.Printf("error hashing password: %v", err.Error())
+		return errs.InternalError
+	}
+
+	// Set the new password and unblock the account
+	_, err = s.passwordRepository.Reset(tx, ctx, password.ID, hashPassword)
+	if err != nil {
+		logger.Error.Printf("error resetting password in DB: %v", err.Error())
+		return errs.InternalError
+	}
+
+	// Whoever knew the old password shouldn't stay logged in
+	ids, err := s.sessionRepository.DeleteAllByUserID(tx, ctx, token.UserID)
+	if err != nil {
+		logger.Error.Printf("error deleting sessions: %v", err.Error())
+		return errs.InternalError
+	}
+	s.tokens.RevokeTokens(ids...)
+
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:    token.UserID,
+		Type:      entity.AuthEventPasswordReset,
+		Success:   true,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
+	return nil
+}
+
+func (s *Email) link(page, token string) string {
+	return strings.TrimRight(s.cfg.AppURL, "/") + "/" + page + "?token=" + url.QueryEscape(token)
+}
+
+// send delivers the email in the background, SMTP is too slow to keep the request waiting
+func (s *Email) send(msg *mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+
+		err := s.mailer.Send(ctx, msg)
+		if err != nil {
+			logger.Error.Printf("error sending email: %v", err.Error())
+		}
+	}()
+}
@@ -2,68 +2,245 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
+	"github.com/HardDie/godb/v2"
+
+	"github.com/HardDie/event_tracker/internal/config"
 	"github.com/HardDie/event_tracker/internal/db"
 	"github.com/HardDie/event_tracker/internal/dto"
 	"github.com/HardDie/event_tracker/internal/entity"
 	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/fields"
 	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/recurrence"
 	"github.com/HardDie/event_tracker/internal/repository"
+	"github.com/HardDie/event_tracker/internal/utils"
 )
 
 type IEvent interface {
 	CreateType(ctx context.Context, userID int32, req *dto.CreateEventTypeDTO) (*entity.EventType, error)
-	DeleteType(ctx context.Context, userID int32, id int32) error
-	ListType(ctx context.Context, userID int32) ([]*entity.EventType, int32, error)
+	DeleteType(ctx context.Context, userID int32, req *dto.DeleteEventTypeDTO) error
+	RestoreType(ctx context.Context, userID int32, id int32) (*entity.EventType, error)
+	ListType(ctx context.Context, userID int32, req *dto.ListEventTypeDTO) ([]*entity.EventType, int32, error)
 	EditType(ctx context.Context, userID int32, req *dto.EditEventTypeDTO) (*entity.EventType, error)
 
 	CreateEvent(ctx context.Context, userID int32, req *dto.CreateEventDTO) (*entity.Event, error)
+	GetEvent(ctx context.Context, userID int32, id int32) (*entity.Event, error)
+	UpdateEvent(ctx context.Context, userID int32, req *dto.UpdateEventDTO) (*entity.Event, error)
 	DeleteEvent(ctx context.Context, userID int32, id int32) error
 	ListEvent(ctx context.Context, userId int32, req *dto.ListEventDTO) ([]*entity.Event, int32, error)
+	EventStats(ctx context.Context, userID int32, req *dto.ListEventDTO) ([]*dto.EventStatsResponseDTO, error)
+	EventDurations(ctx context.Context, userID int32, req *dto.ListEventDTO) ([]*dto.EventDurationResponseDTO, error)
+	ExpectedEvents(ctx context.Context, userID int32, req *dto.ListExpectedDTO) ([]*dto.ExpectedResponseDTO, error)
+
+	StartTimer(ctx context.Context, userID int32, req *dto.StartTimerDTO) (*entity.Event, error)
+	StopTimer(ctx context.Context, userID int32, id int32) (*entity.Event, error)
+	ListTimers(ctx context.Context, userID int32) ([]*entity.Event, int32, error)
 
 	FriendsFeed(ctx context.Context, userID int32) ([]*dto.FeedResponseDTO, int32, error)
 }
 
 type Event struct {
-	repository repository.IEvent
+	repository     repository.IEvent
+	userRepository repository.IUser
 
-	db *db.DB
+	cfg *config.Config
+	db  *db.DB
 }
 
-func NewEvent(db *db.DB, repository repository.IEvent) *Event {
+func NewEvent(db *db.DB, cfg *config.Config, repository repository.IEvent, user repository.IUser) *Event {
 	return &Event{
-		db:         db,
-		repository: repository,
+		db:             db,
+		cfg:            cfg,
+		repository:     repository,
+		userRepository: user,
 	}
 }
 
 func (s *Event) CreateType(ctx context.Context, userID int32, req *dto.CreateEventTypeDTO) (*entity.EventType, error) {
-	res, err := s.repository.CreateType(s.db.DB, ctx, userID, req.Name, req.IsVisible)
+	err := fields.CheckSchema(req.Fields)
+	if err != nil {
+		return nil, errs.BadRequest.AddMessage(err.Error())
+	}
+	err = s.checkSchedule(ctx, userID, req.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.repository.CreateType(s.db.DB, ctx, userID, req.Name, req.IsVisible, req.Unit, req.ShareNotes,
+		req.Fields, req.Schedule)
 	if err != nil {
 		logger.Error.Printf("error create type: %v", err.Error())
 		return nil, errs.InternalError
 	}
 	return res, nil
 }
-func (s *Event) DeleteType(ctx context.Context, userID int32, id int32) error {
-	err := s.repository.DeleteType(s.db.DB, ctx, userID, id)
+
+// DeleteType archives the type, deletes it with its events or moves its events to another type before deleting
+func (s *Event) DeleteType(ctx context.Context, userID int32, req *dto.DeleteEventTypeDTO) error {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	eventType, err := s.repository.GetType(tx, ctx, userID, req.ID)
+	if err != nil {
+		logger.Error.Printf("error get type: %v", err.Error())
+		return errs.InternalError
+	}
+	if eventType == nil || eventType.DeletedAt != nil {
+		return errs.BadRequest.AddMessage("event type not found")
+	}
+
+	switch req.Mode {
+	case dto.DeleteTypeArchive:
+		if eventType.ArchivedAt != nil {
+			return errs.BadRequest.AddMessage("event type is already archived")
+		}
+		err = s.repository.ArchiveType(tx, ctx, userID, req.ID)
+		if err != nil {
+			logger.Error.Printf("error archive type: %v", err.Error())
+			return errs.InternalError
+		}
+		return nil
+	case dto.DeleteTypeDelete:
+		_, err = s.repository.DeleteEventsByType(tx, ctx, userID, req.ID)
+		if err != nil {
+			logger.Error.Printf("error delete events of type: %v", err.Error())
+			return errs.InternalError
+		}
+	case dto.DeleteTypeReassign:
+		if req.TargetTypeID == nil {
+			return errs.BadRequest.AddMessage("target type is required")
+		}
+		if *req.TargetTypeID == req.ID {
+			return errs.BadRequest.AddMessage("target type must be another type")
+		}
+		var target *entity.EventType
+		target, err = s.repository.GetType(tx, ctx, userID, *req.TargetTypeID)
+		if err != nil {
+			logger.Error.Printf("error get type: %v", err.Error())
+			return errs.InternalError
+		}
+		if target == nil || target.DeletedAt != nil {
+			return errs.BadRequest.AddMessage("target type not found")
+		}
+		if target.ArchivedAt != nil {
+			return errs.BadRequest.AddMessage("target type is archived")
+		}
+		_, err = s.repository.ReassignEvents(tx, ctx, userID, req.ID, target.ID)
+		if err != nil {
+			logger.Error.Printf("error reassign events: %v", err.Error())
+			return errs.InternalError
+		}
+	}
+
+	err = s.repository.DeleteType(tx, ctx, userID, req.ID)
 	if err != nil {
 		logger.Error.Printf("error delete type: %v", err.Error())
 		return errs.InternalError
 	}
 	return nil
 }
-func (s *Event) ListType(ctx context.Context, userID int32) ([]*entity.EventType, int32, error) {
-	res, cnt, err := s.repository.ListType(s.db.DB, ctx, userID, false)
+
+// RestoreType brings back an archived type, or a deleted one with the events deleted together with it.
+// Events moved to another type on deletion stay there.
+func (s *Event) RestoreType(ctx context.Context, userID int32, id int32) (*entity.EventType, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	eventType, err := s.repository.GetType(tx, ctx, userID, id)
+	if err != nil {
+		logger.Error.Printf("error get type: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if eventType == nil {
+		return nil, errs.BadRequest.AddMessage("event type not found")
+	}
+	if eventType.DeletedAt == nil && eventType.ArchivedAt == nil {
+		return nil, errs.BadRequest.AddMessage("event type is neither archived nor deleted")
+	}
+
+	// Assigned to err to roll back the restored events if the type can't be restored
+	res, err := restoreEventType(s.repository, tx, ctx, s.trashRetention(), eventType)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+func (s *Event) ListType(ctx context.Context, userID int32, req *dto.ListEventTypeDTO) ([]*entity.EventType, int32, error) {
+	res, cnt, err := s.repository.ListType(s.db.DB, ctx, &dto.ListEventTypeFilter{
+		UserID:   userID,
+		Archived: &req.Archived,
+	})
 	if err != nil {
 		logger.Error.Printf("error list type: %v", err.Error())
 		return nil, 0, errs.InternalError
 	}
 	return res, cnt, nil
 }
+
+// EditType changes the type, the field schema is replaced only if it is in the request.
+// Events keep the values they were written with, see the fields package.
 func (s *Event) EditType(ctx context.Context, userID int32, req *dto.EditEventTypeDTO) (*entity.EventType, error) {
-	res, err := s.repository.EditType(s.db.DB, ctx, userID, req.ID, req.Name, req.IsVisible)
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	eventType, err := s.repository.GetType(tx, ctx, userID, req.ID)
+	if err != nil {
+		logger.Error.Printf("error get type: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if eventType == nil || eventType.DeletedAt != nil {
+		return nil, errs.BadRequest.AddMessage("event type not found")
+	}
+
+	schema := eventType.Fields
+	if req.Fields != nil {
+		err = fields.CheckSchema(req.Fields)
+		if err != nil {
+			return nil, errs.BadRequest.AddMessage(err.Error())
+		}
+		// Deleted events are checked too, they can be restored from the trash
+		var used []string
+		if added := fields.AddedKeys(eventType.Fields, req.Fields); len(added) > 0 {
+			used, err = s.repository.UsedFieldKeys(tx, ctx, userID, req.ID, added)
+			if err != nil {
+				logger.Error.Printf("error get used field keys: %v", err.Error())
+				return nil, errs.InternalError
+			}
+		}
+		err = fields.CheckEvolution(eventType.Fields, req.Fields, used)
+		if err != nil {
+			return nil, errs.BadRequest.AddMessage(err.Error())
+		}
+		schema = req.Fields
+	}
+	err = s.checkSchedule(ctx, userID, req.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.repository.EditType(tx, ctx, userID, req.ID, req.Name, req.IsVisible, req.Unit, req.ShareNotes, schema,
+		req.Schedule)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+			return nil, errs.BadRequest.AddMessage("event type not found")
+		}
 		logger.Error.Printf("error edit type: %v", err.Error())
 		return nil, errs.InternalError
 	}
@@ -71,45 +248,534 @@ func (s *Event) EditType(ctx context.Context, userID int32, req *dto.EditEventTy
 }
 
 func (s *Event) CreateEvent(ctx context.Context, userID int32, req *dto.CreateEventDTO) (*entity.Event, error) {
-	res, err := s.repository.CreateEvent(s.db.DB, ctx, userID, req.EventTypeID, req.Date)
+	eventType, err := s.checkType(ctx, userID, req.EventTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := fields.Validate(eventType.Fields, req.Fields)
+	if err != nil {
+		return nil, errs.BadRequest.AddMessage(err.Error())
+	}
+
+	if req.EndedAt != nil {
+		if req.EndedAt.Before(req.Date) {
+			return nil, errs.BadRequest.AddMessage("event can't end before it starts")
+		}
+		err = s.checkOverlap(s.db.DB, ctx, userID, req.EventTypeID, 0, req.Date, req.EndedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := s.repository.CreateEvent(s.db.DB, ctx, userID, req.EventTypeID, req.Date, req.EndedAt, req.Value,
+		req.Note, values)
 	if err != nil {
 		logger.Error.Printf("error create event: %v", err.Error())
 		return nil, errs.InternalError
 	}
 	return res, nil
 }
+func (s *Event) GetEvent(ctx context.Context, userID int32, id int32) (*entity.Event, error) {
+	event, err := s.repository.GetEvent(s.db.DB, ctx, userID, id)
+	if err != nil {
+		logger.Error.Printf("error get event: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if event == nil || event.DeletedAt != nil {
+		return nil, errs.BadRequest.AddMessage("event not found")
+	}
+	return event, nil
+}
+
+// UpdateEvent changes the date and the type of the event, fields missing in the request are left as is.
+// Custom fields are validated against the current schema of the type only if they or the type are changed,
+// so events written with an older schema can still be edited.
+func (s *Event) UpdateEvent(ctx context.Context, userID int32, req *dto.UpdateEventDTO) (*entity.Event, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	event, err := s.repository.GetEvent(tx, ctx, userID, req.ID)
+	if err != nil {
+		logger.Error.Printf("error get event: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if event == nil {
+		return nil, errs.BadRequest.AddMessage("event not found")
+	}
+	if event.DeletedAt != nil {
+		return nil, errs.BadRequest.AddMessage("event has been deleted")
+	}
+
+	typeID := event.TypeID
+	var eventType *entity.EventType
+	if req.EventTypeID != nil && *req.EventTypeID != event.TypeID {
+		// The event can only be moved to a type of the same user
+		eventType, err = s.checkType(ctx, userID, *req.EventTypeID)
+		if err != nil {
+			return nil, err
+		}
+		typeID = *req.EventTypeID
+	}
+	date := event.Date
+	if req.Date != nil {
+		date = *req.Date
+	}
+	endedAt := event.EndedAt
+	if req.EndedAt != nil || (req.Replace && !event.Running) {
+		endedAt = req.EndedAt
+	}
+	value := event.Value
+	if req.Value != nil || req.Replace {
+		value = req.Value
+	}
+	note := event.Note
+	if req.Note != nil {
+		note = *req.Note
+	} else if req.Replace {
+		note = ""
+	}
+	values := event.Fields
+	if req.Fields != nil || req.Replace || eventType != nil {
+		if req.Fields != nil || req.Replace {
+			values = req.Fields
+		}
+		if eventType == nil {
+			eventType, err = s.repository.GetType(tx, ctx, userID, typeID)
+			if err != nil {
+				logger.Error.Printf("error get type: %v", err.Error())
+				return nil, errs.InternalError
+			}
+			if eventType == nil {
+				return nil, errs.BadRequest.AddMessage("event type not found")
+			}
+		}
+		values, err = fields.Validate(eventType.Fields, values)
+		if err != nil {
+			return nil, errs.BadRequest.AddMessage(err.Error())
+		}
+	}
+
+	// A running timer has no end yet, it overlaps everything after its start
+	if endedAt != nil || event.Running {
+		if endedAt != nil && endedAt.Before(date) {
+			return nil, errs.BadRequest.AddMessage("event can't end before it starts")
+		}
+		err = s.checkOverlap(tx, ctx, userID, typeID, event.ID, date, endedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := s.repository.UpdateEvent(tx, ctx, userID, req.ID, typeID, date, endedAt, value, note, values)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+			return nil, errs.BadRequest.AddMessage("event not found")
+		}
+		logger.Error.Printf("error update event: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	return res, nil
+}
 func (s *Event) DeleteEvent(ctx context.Context, userID int32, id int32) error {
 	err := s.repository.DeleteEvent(s.db.DB, ctx, userID, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.BadRequest.AddMessage("event not found")
+		}
 		logger.Error.Printf("error delete event: %v", err.Error())
 		return errs.InternalError
 	}
 	return nil
 }
 func (s *Event) ListEvent(ctx context.Context, userID int32, req *dto.ListEventDTO) ([]*entity.Event, int32, error) {
-	reqUserID := userID
-	if req.UserID != nil {
-		reqUserID = *req.UserID
+	err := checkFieldFilters(req.Fields)
+	if err != nil {
+		return nil, 0, err
 	}
-	res, cnt, err := s.repository.ListEvent(s.db.DB, ctx, &dto.ListEventFilter{
-		UserID:      reqUserID,
-		TypeID:      req.TypeID,
-		OnlyVisible: reqUserID != userID,
-		PeriodType:  req.PeriodType,
-		Date:        req.Date,
+	loc, err := loadUserLocation(s.userRepository, s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res, cnt, err := s.repository.ListEvent(s.db.DB, ctx, eventFilter(userID, req, loc))
+	if err != nil {
+		logger.Error.Printf("error list event: %v", err.Error())
+		return nil, 0, errs.InternalError
+	}
+	return res, cnt, nil
+}
+func (s *Event) EventStats(ctx context.Context, userID int32, req *dto.ListEventDTO) ([]*dto.EventStatsResponseDTO, error) {
+	err := checkFieldFilters(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := loadUserLocation(s.userRepository, s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.repository.EventStats(s.db.DB, ctx, eventFilter(userID, req, loc))
+	if err != nil {
+		logger.Error.Printf("error get event stats: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	return res, nil
+}
+
+// EventDurations sums the length of the events in the period for each type
+func (s *Event) EventDurations(ctx context.Context, userID int32, req *dto.ListEventDTO) ([]*dto.EventDurationResponseDTO, error) {
+	err := checkFieldFilters(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := loadUserLocation(s.userRepository, s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Events started before the period are counted too, if they end inside it
+	filter := eventFilter(userID, req, loc)
+	from, to := req.PeriodType.Bounds(req.Date, loc)
+	filter.PeriodType = 0
+	filter.OverlapFrom = &from
+	filter.OverlapTo = &to
+
+	events, _, err := s.repository.ListEvent(s.db.DB, ctx, filter)
+	if err != nil {
+		logger.Error.Printf("error list event: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	now := time.Now().UTC()
+	var res []*dto.EventDurationResponseDTO
+	byType := make(map[int32]*dto.EventDurationResponseDTO)
+	for _, event := range events {
+		start, end := event.Date, now
+		if event.EndedAt != nil {
+			end = *event.EndedAt
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		duration, ok := byType[event.TypeID]
+		if !ok {
+			duration = &dto.EventDurationResponseDTO{
+				EventTypeID: event.TypeID,
+			}
+			byType[event.TypeID] = duration
+			res = append(res, duration)
+		}
+		duration.Count++
+		if end.After(start) {
+			duration.Seconds += end.Sub(start).Seconds()
+		}
+	}
+	return res, nil
+}
+
+// ExpectedEvents compares the days on which events of the types with a schedule are expected
+// with the days on which they happened
+func (s *Event) ExpectedEvents(ctx context.Context, userID int32, req *dto.ListExpectedDTO) ([]*dto.ExpectedResponseDTO, error) {
+	loc, err := loadUserLocation(s.userRepository, s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	archived := false
+	eventTypes, _, err := s.repository.ListType(s.db.DB, ctx, &dto.ListEventTypeFilter{
+		UserID:   userID,
+		Archived: &archived,
+	})
+	if err != nil {
+		logger.Error.Printf("error list type: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	events, _, err := s.repository.ListEvent(s.db.DB, ctx, &dto.ListEventFilter{
+		UserID:     userID,
+		TypeID:     req.TypeID,
+		PeriodType: req.PeriodType,
+		Date:       req.Date,
+		Location:   loc,
 	})
 	if err != nil {
 		logger.Error.Printf("error list event: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	counts := make(map[int32]map[string]int32)
+	for _, event := range events {
+		if counts[event.TypeID] == nil {
+			counts[event.TypeID] = make(map[string]int32)
+		}
+		counts[event.TypeID][event.Date.In(loc).Format(recurrence.DateLayout)]++
+	}
+
+	from, to := req.PeriodType.Bounds(req.Date, loc)
+	from, to = recurrence.Day(from), recurrence.Day(to)
+	today := recurrence.Day(time.Now().In(loc))
+
+	var res []*dto.ExpectedResponseDTO
+	for _, eventType := range eventTypes {
+		if eventType.Schedule == nil || (req.TypeID != nil && *req.TypeID != eventType.ID) {
+			continue
+		}
+		rule, err := recurrence.FromSchedule(eventType.Schedule)
+		if err != nil {
+			logger.Error.Printf("error schedule of type %d: %v", eventType.ID, err.Error())
+			continue
+		}
+
+		expected := make(map[time.Time]bool)
+		for _, day := range rule.Between(from, to) {
+			expected[day] = true
+		}
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			date := day.Format(recurrence.DateLayout)
+			count := counts[eventType.ID][date]
+			if !expected[day] && count == 0 {
+				continue
+			}
+			res = append(res, &dto.ExpectedResponseDTO{
+				Date:        date,
+				EventTypeID: eventType.ID,
+				Expected:    expected[day],
+				Count:       count,
+				Missed:      expected[day] && count == 0 && day.Before(today),
+			})
+		}
+	}
+	return res, nil
+}
+
+// StartTimer creates an event which starts now and lasts until the timer is stopped
+func (s *Event) StartTimer(ctx context.Context, userID int32, req *dto.StartTimerDTO) (*entity.Event, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	eventType, err := s.checkType(ctx, userID, req.EventTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := fields.Validate(eventType.Fields, req.Fields)
+	if err != nil {
+		return nil, errs.BadRequest.AddMessage(err.Error())
+	}
+
+	now := time.Now().UTC()
+	err = s.checkOverlap(tx, ctx, userID, req.EventTypeID, 0, now, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.repository.StartTimer(tx, ctx, userID, req.EventTypeID, now, req.Value, req.Note, values)
+	if err != nil {
+		logger.Error.Printf("error start timer: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	return res, nil
+}
+func (s *Event) StopTimer(ctx context.Context, userID int32, id int32) (*entity.Event, error) {
+	res, err := s.repository.StopTimer(s.db.DB, ctx, userID, id, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.BadRequest.AddMessage("running timer not found")
+		}
+		logger.Error.Printf("error stop timer: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	return res, nil
+}
+func (s *Event) ListTimers(ctx context.Context, userID int32) ([]*entity.Event, int32, error) {
+	res, cnt, err := s.repository.ListEvent(s.db.DB, ctx, &dto.ListEventFilter{
+		UserID:      userID,
+		OnlyRunning: true,
+	})
+	if err != nil {
+		logger.Error.Printf("error list timers: %v", err.Error())
 		return nil, 0, errs.InternalError
 	}
 	return res, cnt, nil
 }
 
 func (s *Event) FriendsFeed(ctx context.Context, userID int32) ([]*dto.FeedResponseDTO, int32, error) {
+	loc, err := loadUserLocation(s.userRepository, s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	res, cnt, err := s.repository.FriendsFeed(s.db.DB, ctx, userID)
 	if err != nil {
 		logger.Error.Printf("error list event: %v", err.Error())
 		return nil, 0, errs.InternalError
 	}
+	for _, event := range res {
+		event.Day = event.Date.In(loc).Format("2006-01-02")
+	}
 	return res, cnt, nil
 }
+
+// checkType returns the type or an error if the type doesn't belong to the user or can't get new events
+func (s *Event) checkType(ctx context.Context, userID, typeID int32) (*entity.EventType, error) {
+	eventType, err := s.repository.GetType(s.db.DB, ctx, userID, typeID)
+	if err != nil {
+		logger.Error.Printf("error get type: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if eventType == nil || eventType.DeletedAt != nil {
+		return nil, errs.BadRequest.AddMessage("event type not found")
+	}
+	if eventType.ArchivedAt != nil {
+		return nil, errs.BadRequest.AddMessage("event type is archived")
+	}
+	return eventType, nil
+}
+
+// checkSchedule returns an error if the schedule can't be expanded, a schedule without a start
+// starts today in the time zone of the user
+func (s *Event) checkSchedule(ctx context.Context, userID int32, schedule *entity.EventSchedule) error {
+	if schedule == nil {
+		return nil
+	}
+	if schedule.Start == "" {
+		loc, err := loadUserLocation(s.userRepository, s.db.DB, ctx, userID)
+		if err != nil {
+			return err
+		}
+		schedule.Start = time.Now().In(loc).Format(recurrence.DateLayout)
+	}
+	_, err := recurrence.FromSchedule(schedule)
+	if err != nil {
+		return errs.BadRequest.AddMessage(err.Error())
+	}
+	return nil
+}
+
+// checkOverlap returns an error if another event of the type with a length overlaps the interval,
+// an interval without an end is a running timer
+func (s *Event) checkOverlap(tx godb.Queryer, ctx context.Context, userID, typeID, id int32, from time.Time,
+	to *time.Time) error {
+	events, _, err := s.repository.ListEvent(tx, ctx, &dto.ListEventFilter{
+		UserID:      userID,
+		TypeID:      &typeID,
+		OverlapFrom: &from,
+		OverlapTo:   to,
+	})
+	if err != nil {
+		logger.Error.Printf("error list event: %v", err.Error())
+		return errs.InternalError
+	}
+	for _, event := range events {
+		if event.ID == id {
+			continue
+		}
+		if event.Running {
+			return errs.BadRequest.AddMessage("a timer of the event type is already running")
+		}
+		return errs.BadRequest.AddMessage("event overlaps another event of the type")
+	}
+	return nil
+}
+
+// loadUserLocation returns the time zone of the user, days of events are calculated in it
+func loadUserLocation(repository repository.IUser, tx godb.Queryer, ctx context.Context, userID int32) (*time.Location, error) {
+	user, err := repository.GetByID(tx, ctx, userID, true)
+	if err != nil {
+		logger.Error.Printf("error get user: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if user == nil {
+		return time.UTC, nil
+	}
+	loc, err := utils.LoadTimeZone(user.TimeZone)
+	if err != nil {
+		logger.Error.Printf("error load time zone of user %d: %v", userID, err.Error())
+		return time.UTC, nil
+	}
+	return loc, nil
+}
+
+// eventFilter shows only visible types of friends if the events of another user are requested.
+// Periods are calculated in the time zone of the current user, also for the events of friends.
+func eventFilter(userID int32, req *dto.ListEventDTO, loc *time.Location) *dto.ListEventFilter {
+	reqUserID := userID
+	if req.UserID != nil {
+		reqUserID = *req.UserID
+	}
+	filter := &dto.ListEventFilter{
+		UserID:      reqUserID,
+		TypeID:      req.TypeID,
+		OnlyVisible: reqUserID != userID,
+		PeriodType:  req.PeriodType,
+		Date:        req.Date,
+		MinValue:    req.MinValue,
+		MaxValue:    req.MaxValue,
+		Fields:      req.Fields,
+		Location:    loc,
+	}
+	if reqUserID != userID {
+		filter.ViewerID = &userID
+	}
+	return filter
+}
+
+// checkFieldFilters returns an error if a filter by custom fields can't be applied
+func checkFieldFilters(filters []*dto.FieldFilterDTO) error {
+	for _, filter := range filters {
+		err := fields.CheckKey(filter.Key)
+		if err != nil {
+			return errs.BadRequest.AddMessage(err.Error())
+		}
+		switch filter.Value.(type) {
+		case float64:
+		case string, bool:
+			if filter.Op != dto.FieldOpEq && filter.Op != dto.FieldOpNe {
+				return errs.BadRequest.AddMessage("field " + filter.Key + ": strings and booleans can only be compared with eq and ne")
+			}
+		default:
+			return errs.BadRequest.AddMessage("field " + filter.Key + ": value must be a number, a string or a boolean")
+		}
+	}
+	return nil
+}
+func (s *Event) trashRetention() time.Duration {
+	return time.Hour * 24 * time.Duration(s.cfg.TrashRetention)
+}
+
+// restoreEventType brings back the archived or deleted type, with the events deleted together with it
+func restoreEventType(repository repository.IEvent, tx godb.Queryer, ctx context.Context, retention time.Duration,
+	eventType *entity.EventType) (*entity.EventType, error) {
+	if eventType.DeletedAt != nil {
+		if time.Now().UTC().Sub(*eventType.DeletedAt) > retention {
+			return nil, errs.BadRequest.AddMessage("event type was deleted too long ago")
+		}
+
+		// Events of the type are deleted in the same transaction, so they have the same deletion time
+		_, err := repository.RestoreEventsByType(tx, ctx, eventType.UserID, eventType.ID, *eventType.DeletedAt)
+		if err != nil {
+			logger.Error.Printf("error restore events of type: %v", err.Error())
+			return nil, errs.InternalError
+		}
+	}
+
+	res, err := repository.RestoreType(tx, ctx, eventType.UserID, eventType.ID)
+	if err != nil {
+		logger.Error.Printf("error restore type: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	return res, nil
+}
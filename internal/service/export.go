@@ -0,0 +1,347 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HardDie/event_tracker/internal/config"
+	"github.com/HardDie/event_tracker/internal/db"
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/repository"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+const (
+	tokenPurposeDownloadExport = "download-export"
+
+	exportBuildTimeout = 10 * time.Minute
+)
+
+type IExport interface {
+	Create(ctx context.Context, userID int32) (*entity.Export, error)
+	Get(ctx context.Context, userID, id int32) (*entity.Export, error)
+	Download(ctx context.Context, id int32, token string) ([]byte, error)
+
+	PurgeExpired(ctx context.Context) error
+}
+
+type Export struct {
+	exportRepository      repository.IExport
+	userRepository        repository.IUser
+	eventRepository       repository.IEvent
+	friendRepository      repository.IFriend
+	sessionRepository     repository.ISession
+	accessTokenRepository repository.IAccessToken
+	authEventRepository   repository.IAuthEvent
+	identityRepository    repository.IIdentity
+	goalRepository        repository.IGoal
+	streakRepository      repository.IStreak
+
+	cfg *config.Config
+	db  *db.DB
+}
+
+func NewExport(db *db.DB, cfg *config.Config, export repository.IExport, user repository.IUser,
+	event repository.IEvent, friend repository.IFriend, session repository.ISession, accessToken repository.IAccessToken,
+	authEvent repository.IAuthEvent, identity repository.IIdentity, goal repository.IGoal,
+	streak repository.IStreak) *Export {
+	return &Export{
+		db:                    db,
+		cfg:                   cfg,
+		exportRepository:      export,
+		userRepository:        user,
+		eventRepository:       event,
+		friendRepository:      friend,
+		sessionRepository:     session,
+		accessTokenRepository: accessToken,
+		authEventRepository:   authEvent,
+		identityRepository:    identity,
+		goalRepository:        goal,
+		streakRepository:      streak,
+	}
+}
+
+func (s *Export) Create(ctx context.Context, userID int32) (*entity.Export, error) {
+	now := time.Now().UTC()
+
+	// Only one export at a time, the archive of a big account takes a while
+	export, err := s.exportRepository.GetPendingByUserID(s.db.DB, ctx, userID, now)
+	if err != nil {
+		logger.Error.Printf("error read export from DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if export != nil {
+		return export, nil
+	}
+
+	// A pending export which was lost on restart expires together with its row
+	export, err = s.exportRepository.Create(s.db.DB, ctx, userID, now.Add(time.Hour*time.Duration(s.cfg.ExportTimeout)))
+	if err != nil {
+		logger.Error.Printf("error writing export into DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	go s.build(export.ID, userID)
+
+	return export, nil
+}
+func (s *Export) Get(ctx context.Context, userID, id int32) (*entity.Export, error) {
+	export, err := s.exportRepository.GetByID(s.db.DB, ctx, id)
+	if err != nil {
+		logger.Error.Printf("error read export from DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if export == nil || export.UserID != userID || time.Now().UTC().After(export.ExpiresAt) {
+		return nil, errs.BadRequest.AddMessage("export not found")
+	}
+
+	// The link doesn't need authorization, so it can be opened in a browser
+	if export.Status == entity.ExportStatusReady {
+		token := utils.SignToken(s.cfg.TokenSecret, tokenPurposeDownloadExport, userID, export.ExpiresAt,
+			strconv.Itoa(int(export.ID)))
+		export.DownloadURL = fmt.Sprintf("%s/api/v1/user/export/%d/download?token=%s",
+			strings.TrimRight(s.cfg.AppURL, "/"), export.ID, url.QueryEscape(token))
+	}
+	return export, nil
+}
+func (s *Export) Download(ctx context.Context, id int32, tokenValue string) ([]byte, error) {
+	token, err := utils.ParseSignedToken(tokenValue)
+	if err != nil {
+		return nil, errs.BadRequest.AddMessage("invalid token")
+	}
+	if !token.Verify(s.cfg.TokenSecret, tokenPurposeDownloadExport, strconv.Itoa(int(id))) {
+		return nil, errs.BadRequest.AddMessage("invalid token")
+	}
+
+	archive, err := s.exportRepository.GetArchive(s.db.DB, ctx, id)
+	if err != nil {
+		logger.Error.Printf("error read export archive from DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if archive == nil {
+		return nil, errs.BadRequest.AddMessage("export not found")
+	}
+	return archive, nil
+}
+
+// PurgeExpired removes archives which can't be downloaded anymore
+func (s *Export) PurgeExpired(ctx context.Context) error {
+	ids, err := s.exportRepository.DeleteExpired(s.db.DB, ctx, time.Now().UTC())
+	if err != nil {
+		logger.Error.Printf("error purging expired exports: %v", err.Error())
+		return errs.InternalError
+	}
+	if len(ids) > 0 {
+		logger.Info.Printf("purged %d expired exports", len(ids))
+	}
+	return nil
+}
+
+// build collects the data in the background and stores the archive
+func (s *Export) build(id, userID int32) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportBuildTimeout)
+	defer cancel()
+
+	archive, err := s.buildArchive(ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error building export %d: %v", id, err.Error())
+		err = s.exportRepository.Fail(s.db.DB, ctx, id)
+		if err != nil {
+			logger.Error.Printf("error marking export %d as failed: %v", id, err.Error())
+		}
+		return
+	}
+
+	expiresAt := time.Now().UTC().Add(time.Hour * time.Duration(s.cfg.ExportTimeout))
+	err = s.exportRepository.Complete(s.db.DB, ctx, id, archive, expiresAt)
+	if err != nil {
+		logger.Error.Printf("error writing export %d into DB: %v", id, err.Error())
+	}
+}
+func (s *Export) buildArchive(ctx context.Context, userID int32) ([]byte, error) {
+	user, err := s.userRepository.GetByID(s.db.DB, ctx, userID, true)
+	if err != nil {
+		return nil, fmt.Errorf("read user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %d not found", userID)
+	}
+
+	eventTypes, _, err := s.eventRepository.ListType(s.db.DB, ctx, &dto.ListEventTypeFilter{
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read event types: %w", err)
+	}
+
+	// Without a period all events of the user are returned
+	events, _, err := s.eventRepository.ListEvent(s.db.DB, ctx, &dto.ListEventFilter{
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read events: %w", err)
+	}
+	goals, _, err := s.goalRepository.List(s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("read goals: %w", err)
+	}
+	streakFreezes, err := s.streakRepository.ListAllFreezes(s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("read streak freezes: %w", err)
+	}
+
+	friends, _, err := s.friendRepository.ListOfFriends(s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("read friends: %w", err)
+	}
+	receivedInvites, _, err := s.friendRepository.ListPendingInvitations(s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("read received invites: %w", err)
+	}
+	sentInvites, _, err := s.friendRepository.ListSentInvitations(s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("read sent invites: %w", err)
+	}
+
+	sessions, _, err := s.sessionRepository.ListByUserID(s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("read sessions: %w", err)
+	}
+	// Hashes of the tokens are not written
+	accessTokens, _, err := s.accessTokenRepository.ListByUserID(s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("read access tokens: %w", err)
+	}
+	// Without a limit the whole history is returned
+	authEvents, _, err := s.authEventRepository.ListByUserID(s.db.DB, ctx, userID, &dto.ListAuthEventDTO{})
+	if err != nil {
+		return nil, fmt.Errorf("read auth events: %w", err)
+	}
+	identities, _, err := s.identityRepository.ListByUserID(s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("read identities: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"event_types.json", eventTypes},
+		{"events.json", events},
+		{"goals.json", goals},
+		{"streak_freezes.json", streakFreezes},
+		{"friends.json", friends},
+		{"invites.json", map[string]interface{}{
+			"received": receivedInvites,
+			"sent":     sentInvites,
+		}},
+		{"sessions.json", sessions},
+		{"access_tokens.json", accessTokens},
+		{"auth_events.json", authEvents},
+		{"identities.json", identities},
+	}
+	for _, file := range files {
+		err = writeZipJSON(w, file.name, file.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Dates are written in the time zone of the user
+	loc, err := utils.LoadTimeZone(user.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	err = writeEventsCSV(w, "events.csv", events, eventTypes, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("close zip: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeZipJSON(w *zip.Writer, name string, data interface{}) error {
+	file, err := w.Create(name)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(data)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+func writeEventsCSV(w *zip.Writer, name string, events []*entity.Event, eventTypes []*entity.EventType,
+	loc *time.Location) error {
+	types := make(map[int32]*entity.EventType, len(eventTypes))
+	for _, eventType := range eventTypes {
+		types[eventType.ID] = eventType
+	}
+
+	file, err := w.Create(name)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	writer := csv.NewWriter(file)
+	err = writer.Write([]string{"id", "date", "ended_at", "event_type_id", "event_type", "value", "unit", "note", "fields", "created_at"})
+	if err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	for _, event := range events {
+		var typeName, unit, value, endedAt string
+		if eventType, ok := types[event.TypeID]; ok {
+			typeName, unit = eventType.EventType, eventType.Unit
+		}
+		if event.Value != nil {
+			value = strconv.FormatFloat(*event.Value, 'f', -1, 64)
+		}
+		if event.EndedAt != nil {
+			endedAt = event.EndedAt.In(loc).Format(time.RFC3339)
+		}
+		values, err := json.Marshal(event.Fields)
+		if err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+		err = writer.Write([]string{
+			strconv.Itoa(int(event.ID)),
+			event.Date.In(loc).Format(time.RFC3339),
+			endedAt,
+			strconv.Itoa(int(event.TypeID)),
+			typeName,
+			value,
+			unit,
+			event.Note,
+			string(values),
+			event.CreatedAt.Format(time.RFC3339),
+		})
+		if err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+
+	"github.com/HardDie/event_tracker/internal/db"
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/repository"
+)
+
+type IGoal interface {
+	Create(ctx context.Context, userID int32, req *dto.CreateGoalDTO) (*dto.GoalStatusDTO, error)
+	Get(ctx context.Context, userID int32, req *dto.GetGoalDTO) (*dto.GoalStatusDTO, error)
+	List(ctx context.Context, userID int32) ([]*dto.GoalStatusDTO, int32, error)
+	Update(ctx context.Context, userID int32, req *dto.UpdateGoalDTO) (*dto.GoalStatusDTO, error)
+	Delete(ctx context.Context, userID int32, id int32) error
+}
+
+type Goal struct {
+	goalRepository  repository.IGoal
+	eventRepository repository.IEvent
+	userRepository  repository.IUser
+
+	db *db.DB
+}
+
+func NewGoal(db *db.DB, goal repository.IGoal, event repository.IEvent, user repository.IUser) *Goal {
+	return &Goal{
+		db:              db,
+		goalRepository:  goal,
+		eventRepository: event,
+		userRepository:  user,
+	}
+}
+
+func (s *Goal) Create(ctx context.Context, userID int32, req *dto.CreateGoalDTO) (*dto.GoalStatusDTO, error) {
+	err := s.checkType(ctx, userID, req.EventTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	goal, err := s.goalRepository.Create(s.db.DB, ctx, userID, req.EventTypeID, req.Period, req.Metric, req.Comparison,
+		req.Target)
+	if err != nil {
+		logger.Error.Printf("error create goal: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	return s.status(ctx, goal, 0)
+}
+func (s *Goal) Get(ctx context.Context, userID int32, req *dto.GetGoalDTO) (*dto.GoalStatusDTO, error) {
+	goal, err := s.goalRepository.Get(s.db.DB, ctx, userID, req.ID)
+	if err != nil {
+		logger.Error.Printf("error get goal: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if goal == nil {
+		return nil, errs.BadRequest.AddMessage("goal not found")
+	}
+	return s.status(ctx, goal, int(req.Periods))
+}
+func (s *Goal) List(ctx context.Context, userID int32) ([]*dto.GoalStatusDTO, int32, error) {
+	goals, cnt, err := s.goalRepository.List(s.db.DB, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error list goals: %v", err.Error())
+		return nil, 0, errs.InternalError
+	}
+
+	var res []*dto.GoalStatusDTO
+	for _, goal := range goals {
+		status, err := s.status(ctx, goal, 0)
+		if err != nil {
+			return nil, 0, err
+		}
+		res = append(res, status)
+	}
+	return res, cnt, nil
+}
+func (s *Goal) Update(ctx context.Context, userID int32, req *dto.UpdateGoalDTO) (*dto.GoalStatusDTO, error) {
+	err := s.checkType(ctx, userID, req.EventTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	goal, err := s.goalRepository.Update(s.db.DB, ctx, userID, req.ID, req.EventTypeID, req.Period, req.Metric,
+		req.Comparison, req.Target)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.BadRequest.AddMessage("goal not found")
+		}
+		logger.Error.Printf("error update goal: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	return s.status(ctx, goal, 0)
+}
+func (s *Goal) Delete(ctx context.Context, userID int32, id int32) error {
+	err := s.goalRepository.Delete(s.db.DB, ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.BadRequest.AddMessage("goal not found")
+		}
+		logger.Error.Printf("error delete goal: %v", err.Error())
+		return errs.InternalError
+	}
+	return nil
+}
+
+// checkType returns an error if the type doesn't belong to the user
+func (s *Goal) checkType(ctx context.Context, userID, typeID int32) error {
+	eventType, err := s.eventRepository.GetType(s.db.DB, ctx, userID, typeID)
+	if err != nil {
+		logger.Error.Printf("error get type: %v", err.Error())
+		return errs.InternalError
+	}
+	if eventType == nil || eventType.DeletedAt != nil {
+		return errs.BadRequest.AddMessage("event type not found")
+	}
+	return nil
+}
+
+// status evaluates the goal in the current period and in up to the number of past periods,
+// periods before the one in which the goal was created are skipped
+func (s *Goal) status(ctx context.Context, goal *entity.Goal, periods int) (*dto.GoalStatusDTO, error) {
+	loc, err := loadUserLocation(s.userRepository, s.db.DB, ctx, goal.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, end := goalPeriod(goal.Period, time.Now(), loc)
+	created, _ := goalPeriod(goal.Period, goal.CreatedAt, loc)
+	first := current
+	for i := 0; i < periods && first.After(created); i++ {
+		first, _ = goalPeriod(goal.Period, first.Add(-time.Nanosecond), loc)
+	}
+
+	totals, err := goalTotals(s.goalRepository, s.db.DB, ctx, goal, first, end, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dto.GoalStatusDTO{
+		Goal: goal,
+	}
+	completed := 0
+	for start := first; start.Before(end); {
+		_, next := goalPeriod(goal.Period, start, loc)
+
+		var value float64
+		if total, ok := totals[start.Format("2006-01-02")]; ok {
+			value = float64(total.Count)
+			if goal.Metric == entity.GoalMetricSum {
+				value = total.Sum
+			}
+		}
+		period := goalProgress(goal, value, !next.Before(end))
+		period.Start, period.End = start, next
+
+		if next.Before(end) {
+			res.History = append(res.History, period)
+			if period.Status == entity.GoalStatusCompleted {
+				completed++
+			}
+		} else {
+			res.Current = period
+		}
+		start = next
+	}
+	if len(res.History) > 0 {
+		rate := float64(completed) / float64(len(res.History)) * 100
+		res.CompletionRate = &rate
+	}
+	return res, nil
+}
+
+func goalTotals(repository repository.IGoal, tx godb.Queryer, ctx context.Context, goal *entity.Goal, from, to time.Time,
+	loc *time.Location) (map[string]*dto.GoalTotalDTO, error) {
+	totals, err := repository.Totals(tx, ctx, goal, from, to, loc)
+	if err != nil {
+		logger.Error.Printf("error get goal totals: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	res := make(map[string]*dto.GoalTotalDTO, len(totals))
+	for _, total := range totals {
+		res[total.Day] = total
+	}
+	return res, nil
+}
+
+// goalPeriod returns the start of the period which contains the time and the start of the next one
+func goalPeriod(period string, t time.Time, loc *time.Location) (time.Time, time.Time) {
+	year, month, day := t.In(loc).Date()
+	switch period {
+	case entity.GoalPeriodWeek:
+		start := time.Date(year, month, day, 0, 0, 0, 0, loc)
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7)
+	case entity.GoalPeriodMonth:
+		start := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 1, 0)
+	case entity.GoalPeriodYear:
+		start := time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(1, 0, 0)
+	}
+	start := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// goalProgress compares the value of a period with the target. A current period is in progress
+// until an at_least goal is reached or an at_most goal is exceeded.
+func goalProgress(goal *entity.Goal, value float64, current bool) *dto.GoalPeriodDTO {
+	res := &dto.GoalPeriodDTO{
+		Value: value,
+	}
+
+	switch {
+	case goal.Target > 0:
+		res.Percent = value / goal.Target * 100
+	case goal.Comparison == entity.GoalAtLeast || value > 0:
+		res.Percent = 100
+	}
+
+	switch goal.Comparison {
+	case entity.GoalAtLeast:
+		if res.Percent > 100 {
+			res.Percent = 100
+		}
+		switch {
+		case value >= goal.Target:
+			res.Status = entity.GoalStatusCompleted
+		case current:
+			res.Status = entity.GoalStatusInProgress
+		default:
+			res.Status = entity.GoalStatusFailed
+		}
+	case entity.GoalAtMost:
+		switch {
+		case value > goal.Target:
+			res.Status = entity.GoalStatusFailed
+		case current:
+			res.Status = entity.GoalStatusInProgress
+		default:
+			res.Status = entity.GoalStatusCompleted
+		}
+	}
+	return res
+}
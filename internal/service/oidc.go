@@ -0,0 +1,448 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/HardDie/godb/v2"
+
+	"github.com/HardDie/event_tracker/internal/config"
+	"github.com/HardDie/event_tracker/internal/db"
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/oidc"
+	"github.com/HardDie/event_tracker/internal/repository"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+const (
+	// Usernames of provisioned users are cut to this length
+	oidcUsernameMaxLength = 32
+	// How many numeric suffixes are tried if the username is busy
+	oidcUsernameAttempts = 100
+)
+
+type IOIDC interface {
+	Authorize(ctx context.Context, userID *int32, req *dto.OIDCAuthorizeDTO) (*dto.OIDCAuthorizeResponseDTO, error)
+	Login(ctx context.Context, req *dto.OIDCCallbackDTO) (*entity.User, bool, error)
+	Link(ctx context.Context, userID int32, req *dto.OIDCCallbackDTO) (*entity.Identity, error)
+	ListIdentities(ctx context.Context, userID int32) ([]*entity.Identity, int32, error)
+	DeleteIdentity(ctx context.Context, userID, id int32) error
+
+	PurgeExpired(ctx context.Context) error
+}
+
+type OIDC struct {
+	identityRepository  repository.IIdentity
+	userRepository      repository.IUser
+	passwordRepository  repository.IPassword
+	authEventRepository repository.IAuthEvent
+
+	// nil if OIDC login is disabled
+	provider *oidc.Provider
+	cfg      *config.Config
+	db       *db.DB
+}
+
+func NewOIDC(db *db.DB, cfg *config.Config, provider *oidc.Provider, identity repository.IIdentity,
+	user repository.IUser, password repository.IPassword, authEvent repository.IAuthEvent) *OIDC {
+	return &OIDC{
+		db:                  db,
+		cfg:                 cfg,
+		provider:            provider,
+		identityRepository:  identity,
+		userRepository:      user,
+		passwordRepository:  password,
+		authEventRepository: authEvent,
+	}
+}
+
+func (s *OIDC) Authorize(ctx context.Context, userID *int32, req *dto.OIDCAuthorizeDTO) (*dto.OIDCAuthorizeResponseDTO, error) {
+	if s.provider == nil {
+		return nil, errs.BadRequest.AddMessage("OIDC login is disabled")
+	}
+	if req.UseCookie && !s.cfg.AuthCookie {
+		return nil, errs.BadRequest.AddMessage("cookie authentication is disabled")
+	}
+
+	// The state protects the callback from CSRF, the nonce binds the ID token to this request,
+	// the verifier proves that the code is exchanged by the one who started the login
+	var secrets [3]string
+	for i := range secrets {
+		secret, err := utils.GenerateSessionKey()
+		if err != nil {
+			logger.Error.Printf("error generate oidc secret: %v", err)
+			return nil, errs.InternalError
+		}
+		secrets[i] = strings.TrimRight(secret, "=")
+	}
+	state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+
+	_, err := s.identityRepository.CreateState(s.db.DB, ctx, &entity.OIDCState{
+		StateHash:    utils.HashSha256(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		UserID:       userID,
+		UseCookie:    req.UseCookie,
+		ExpiresAt:    time.Now().UTC().Add(time.Minute * time.Duration(s.cfg.OIDCStateTimeout)),
+	})
+	if err != nil {
+		logger.Error.Printf("error writing oidc state into DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	url, err := s.provider.AuthURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		logger.Error.Printf("error building oidc auth url: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	return &dto.OIDCAuthorizeResponseDTO{
+		URL: url,
+	}, nil
+}
+
+// Login returns the user of the external identity and whether the session has to be put in cookies.
+// A deleted account is returned until it's purged, it's restored only after the full login like with a password.
+func (s *OIDC) Login(ctx context.Context, req *dto.OIDCCallbackDTO) (*entity.User, bool, error) {
+	state, claims, err := s.finishAuthorization(ctx, req, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return nil, false, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	var user *entity.User
+	identity, err := s.identityRepository.GetBySubject(tx, ctx, s.provider.Issuer(), claims.Subject)
+	if err != nil {
+		logger.Error.Printf("error read identity from DB: %v", err.Error())
+		return nil, false, errs.InternalError
+	}
+	if identity != nil {
+		user, err = s.userRepository.GetByID(tx, ctx, identity.UserID, true)
+		if err != nil {
+			logger.Error.Printf("error while trying get user: %v", err.Error())
+			return nil, false, errs.InternalError
+		}
+		if user == nil {
+			user, err = s.userRepository.GetDeletedByID(tx, ctx, identity.UserID)
+			if err != nil {
+				logger.Error.Printf("error while trying get user: %v", err.Error())
+				return nil, false, errs.InternalError
+			}
+			if user == nil || time.Now().Sub(*user.DeletedAt) > time.Hour*24*time.Duration(s.cfg.AccountDeletionGrace) {
+				return nil, false, errs.BadRequest.AddMessage("account is deleted")
+			}
+		}
+
+		// Keep the email of the identity up to date
+		email := claimsEmail(claims)
+		if !equalStringPtr(identity.Email, email) {
+			err = s.identityRepository.UpdateEmail(tx, ctx, identity.ID, email)
+			if err != nil {
+				logger.Error.Printf("error updating identity: %v", err.Error())
+				return nil, false, errs.InternalError
+			}
+		}
+	} else {
+		if !s.cfg.OIDCAutoProvision {
+			return nil, false, errs.BadRequest.AddMessage("no account is linked to this identity")
+		}
+		user, err = s.provision(tx, ctx, claims, req)
+		if err != nil {
+			return nil, false, errs.InternalError
+		}
+	}
+
+	if user.DisabledAt != nil {
+		recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+			UserID:    user.ID,
+			Type:      entity.AuthEventLogin,
+			Reason:    authEventReason("account is disabled"),
+			IP:        req.IP,
+			UserAgent: req.UserAgent,
+		})
+		return nil, false, errs.UserBlocked.AddMessage("account is disabled")
+	}
+
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:    user.ID,
+		Type:      entity.AuthEventLogin,
+		Success:   true,
+		Reason:    authEventReason("oidc"),
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
+	return user, state.UseCookie, nil
+}
+func (s *OIDC) Link(ctx context.Context, userID int32, req *dto.OIDCCallbackDTO) (*entity.Identity, error) {
+	_, claims, err := s.finishAuthorization(ctx, req, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	identity, err := s.identityRepository.GetBySubject(tx, ctx, s.provider.Issuer(), claims.Subject)
+	if err != nil {
+		logger.Error.Printf("error read identity from DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if identity != nil {
+		if identity.UserID != userID {
+			return nil, errs.BadRequest.AddMessage("identity is already linked to another account")
+		}
+		return identity, nil
+	}
+
+	identity, err = s.identityRepository.Create(tx, ctx, userID, s.provider.Issuer(), claims.Subject, claimsEmail(claims))
+	if err != nil {
+		logger.Error.Printf("error writing identity into DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:    userID,
+		Type:      entity.AuthEventIdentityLink,
+		Success:   true,
+		Reason:    authEventReason(identity.Provider),
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
+	return identity, nil
+}
+func (s *OIDC) ListIdentities(ctx context.Context, userID int32) ([]*entity.Identity, int32, error) {
+	res, total, err := s.identityRepository.ListByUserID(s.db.DB, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error list identities: %v", err.Error())
+		return nil, 0, errs.InternalError
+	}
+	return res, total, nil
+}
+func (s *OIDC) DeleteIdentity(ctx context.Context, userID, id int32) error {
+	err := s.identityRepository.DeleteByUserID(s.db.DB, ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.BadRequest.AddMessage("identity not found")
+		}
+		logger.Error.Printf("error deleting identity: %v", err.Error())
+		return errs.InternalError
+	}
+
+	recordAuthEvent(s.authEventRepository, s.db.DB, ctx, &dto.CreateAuthEventDTO{
+		UserID:  userID,
+		Type:    entity.AuthEventIdentityUnlink,
+		Success: true,
+		Reason:  authEventReason(fmt.Sprintf("identity %d", id)),
+	})
+	return nil
+}
+
+// PurgeExpired removes states of logins which were never finished
+func (s *OIDC) PurgeExpired(ctx context.Context) error {
+	_, err := s.identityRepository.DeleteExpiredStates(s.db.DB, ctx, time.Now().UTC())
+	if err != nil {
+		logger.Error.Printf("error purging expired oidc states: %v", err.Error())
+		return errs.InternalError
+	}
+	return nil
+}
+
+// finishAuthorization checks the state, exchanges the code and verifies the ID token.
+// The state is removed before the code is exchanged, so it can be used only once.
+func (s *OIDC) finishAuthorization(ctx context.Context, req *dto.OIDCCallbackDTO, userID *int32) (*entity.OIDCState, *oidc.Claims, error) {
+	if s.provider == nil {
+		return nil, nil, errs.BadRequest.AddMessage("OIDC login is disabled")
+	}
+
+	state, err := s.identityRepository.GetStateByHash(s.db.DB, ctx, utils.HashSha256(req.State))
+	if err != nil {
+		logger.Error.Printf("error read oidc state from DB: %v", err.Error())
+		return nil, nil, errs.InternalError
+	}
+	if state == nil || time.Now().UTC().After(state.ExpiresAt) {
+		return nil, nil, errs.BadRequest.AddMessage("invalid state")
+	}
+	// A state for linking can't be used for login and the other way around
+	if !equalInt32Ptr(state.UserID, userID) {
+		return nil, nil, errs.BadRequest.AddMessage("invalid state")
+	}
+	err = s.identityRepository.DeleteState(s.db.DB, ctx, state.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, errs.BadRequest.AddMessage("invalid state")
+		}
+		logger.Error.Printf("error deleting oidc state: %v", err.Error())
+		return nil, nil, errs.InternalError
+	}
+
+	token, err := s.provider.Exchange(ctx, req.Code, state.CodeVerifier)
+	if err != nil {
+		logger.Error.Printf("error exchanging oidc code: %v", err.Error())
+		return nil, nil, errs.BadRequest.AddMessage("invalid code")
+	}
+	claims, err := s.provider.Verify(ctx, token.IDToken, state.Nonce)
+	if err != nil {
+		logger.Error.Printf("error verifying oidc id token: %v", err.Error())
+		return nil, nil, errs.BadRequest.AddMessage("invalid id token")
+	}
+	return state, claims, nil
+}
+
+// provision creates a local user for the external identity. The user gets a random password,
+// a local password can be set later with the password reset.
+func (s *OIDC) provision(tx godb.Queryer, ctx context.Context, claims *oidc.Claims, req *dto.OIDCCallbackDTO) (*entity.User, error) {
+	username, err := s.pickUsername(tx, ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	displayedName := claims.Name
+	if displayedName == "" {
+		displayedName = username
+	}
+	user, err := s.userRepository.Create(tx, ctx, username, displayedName)
+	if err != nil {
+		logger.Error.Printf("error writing user into DB: %v", err.Error())
+		return nil, err
+	}
+
+	randomPassword, err := utils.GenerateSessionKey()
+	if err != nil {
+		logger.Error.Printf("error generate password: %v", err)
+		return nil, err
+	}
+	hashPassword, err := utils.HashBcrypt(randomPassword)
+	if err != nil {
+		logger.Error.Printf("error hash bcrypt: %v", err.Error())
+		return nil, err
+	}
+	_, err = s.passwordRepository.Create(tx, ctx, user.ID, hashPassword)
+	if err != nil {
+		logger.Error.Printf("error writing password into DB: %v", err.Error())
+		return nil, err
+	}
+
+	// The provider has already verified the email
+	email := claimsEmail(claims)
+	if email != nil && claims.EmailVerified {
+		user, err = s.userRepository.UpdateProfile(tx, ctx, &dto.UpdateProfileDTO{
+			ID:            user.ID,
+			DisplayedName: displayedName,
+			Email:         email,
+		})
+		if err != nil {
+			logger.Error.Printf("error updating user profile: %v", err.Error())
+			return nil, err
+		}
+		err = s.userRepository.VerifyEmail(tx, ctx, user.ID, *email)
+		if err != nil {
+			logger.Error.Printf("error verifying email: %v", err.Error())
+			return nil, err
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	_, err = s.identityRepository.Create(tx, ctx, user.ID, s.provider.Issuer(), claims.Subject, email)
+	if err != nil {
+		logger.Error.Printf("error writing identity into DB: %v", err.Error())
+		return nil, err
+	}
+
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:    user.ID,
+		Type:      entity.AuthEventRegister,
+		Success:   true,
+		Reason:    authEventReason("oidc"),
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
+	return user, nil
+}
+
+// pickUsername takes the preferred username or the email of the identity and adds a number if it's busy
+func (s *OIDC) pickUsername(tx godb.Queryer, ctx context.Context, claims *oidc.Claims) (string, error) {
+	base := sanitizeUsername(claims.PreferredUsername)
+	if base == "" {
+		base = sanitizeUsername(strings.Split(claims.Email, "@")[0])
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	for i := 1; i <= oidcUsernameAttempts; i++ {
+		username := base
+		if i > 1 {
+			username = fmt.Sprintf("%s%d", base, i)
+		}
+
+		user, err := s.userRepository.GetByName(tx, ctx, username)
+		if err != nil {
+			logger.Error.Printf("error while trying get user: %v", err.Error())
+			return "", err
+		}
+		if user != nil {
+			continue
+		}
+		user, err = s.userRepository.GetDeletedByName(tx, ctx, username)
+		if err != nil {
+			logger.Error.Printf("error while trying get user: %v", err.Error())
+			return "", err
+		}
+		if user == nil {
+			return username, nil
+		}
+	}
+
+	logger.Error.Printf("can't find a free username for %q", base)
+	return "", fmt.Errorf("no free username")
+}
+
+func sanitizeUsername(name string) string {
+	var res strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-' {
+			res.WriteRune(r)
+		}
+	}
+	username := res.String()
+	if len([]rune(username)) > oidcUsernameMaxLength {
+		username = string([]rune(username)[:oidcUsernameMaxLength])
+	}
+	return username
+}
+func claimsEmail(claims *oidc.Claims) *string {
+	if claims.Email == "" {
+		return nil
+	}
+	return &claims.Email
+}
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+func equalInt32Ptr(a, b *int32) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
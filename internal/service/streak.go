@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HardDie/event_tracker/internal/db"
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/recurrence"
+	"github.com/HardDie/event_tracker/internal/repository"
+)
+
+type IStreak interface {
+	Get(ctx context.Context, userID, typeID int32) (*dto.StreakResponseDTO, error)
+	CreateFreeze(ctx context.Context, userID int32, req *dto.StreakFreezeDTO) (*entity.StreakFreeze, error)
+	DeleteFreeze(ctx context.Context, userID int32, req *dto.StreakFreezeDTO) error
+}
+
+type Streak struct {
+	streakRepository repository.IStreak
+	eventRepository  repository.IEvent
+	userRepository   repository.IUser
+
+	db *db.DB
+}
+
+func NewStreak(db *db.DB, streak repository.IStreak, event repository.IEvent, user repository.IUser) *Streak {
+	return &Streak{
+		db:               db,
+		streakRepository: streak,
+		eventRepository:  event,
+		userRepository:   user,
+	}
+}
+
+// Get walks the days on which events of the type are expected, from the first event up to today.
+// Without a schedule every day is expected, days before the start of the schedule are expected too.
+func (s *Streak) Get(ctx context.Context, userID, typeID int32) (*dto.StreakResponseDTO, error) {
+	eventType, err := s.checkType(ctx, userID, typeID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := loadUserLocation(s.userRepository, s.db.DB, ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	eventDays, err := s.streakRepository.EventDays(s.db.DB, ctx, userID, typeID, loc)
+	if err != nil {
+		logger.Error.Printf("error get event days: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	freezes, err := s.streakRepository.ListFreezes(s.db.DB, ctx, userID, typeID)
+	if err != nil {
+		logger.Error.Printf("error list streak freezes: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	res := &dto.StreakResponseDTO{
+		EventTypeID: typeID,
+		Runs:        make([]*dto.StreakRunDTO, 0),
+		Freezes:     make([]string, 0),
+	}
+	frozen := make(map[string]bool, len(freezes))
+	for _, freeze := range freezes {
+		frozen[freeze.Date] = true
+		res.Freezes = append(res.Freezes, freeze.Date)
+	}
+	if len(eventDays) == 0 {
+		return res, nil
+	}
+	done := make(map[string]bool, len(eventDays))
+	for _, day := range eventDays {
+		done[day] = true
+	}
+
+	first, err := recurrence.ParseDay(eventDays[0])
+	if err != nil {
+		logger.Error.Printf("error parse event day: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	today := recurrence.Day(time.Now().In(loc))
+	expected, err := streakDays(eventType.Schedule, first, today.AddDate(0, 0, 1))
+	if err != nil {
+		logger.Error.Printf("error schedule of type %d: %v", eventType.ID, err.Error())
+		return nil, errs.InternalError
+	}
+
+	var current *dto.StreakRunDTO
+	var frozenInRun int32
+	for _, day := range expected {
+		date := day.Format(recurrence.DateLayout)
+		switch {
+		case done[date]:
+			if current == nil {
+				current = &dto.StreakRunDTO{
+					Start: date,
+				}
+				res.Runs = append(res.Runs, current)
+			}
+			current.End = date
+			current.Length++
+			current.Frozen += frozenInRun
+			frozenInRun = 0
+		case frozen[date]:
+			if current != nil {
+				frozenInRun++
+			}
+		case day.Equal(today):
+			// Today isn't over yet
+		default:
+			current = nil
+			frozenInRun = 0
+		}
+	}
+
+	for _, run := range res.Runs {
+		if run.Length > res.Longest {
+			res.Longest = run.Length
+		}
+	}
+	if current != nil {
+		res.Current = current.Length
+	}
+	return res, nil
+}
+func (s *Streak) CreateFreeze(ctx context.Context, userID int32, req *dto.StreakFreezeDTO) (*entity.StreakFreeze, error) {
+	_, err := s.checkType(ctx, userID, req.EventTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	freeze, err := s.streakRepository.GetFreeze(s.db.DB, ctx, userID, req.EventTypeID, req.Date)
+	if err != nil {
+		logger.Error.Printf("error get streak freeze: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if freeze != nil {
+		return nil, errs.BadRequest.AddMessage("the day is already frozen")
+	}
+
+	freeze, err = s.streakRepository.CreateFreeze(s.db.DB, ctx, userID, req.EventTypeID, req.Date)
+	if err != nil {
+		logger.Error.Printf("error create streak freeze: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	return freeze, nil
+}
+func (s *Streak) DeleteFreeze(ctx context.Context, userID int32, req *dto.StreakFreezeDTO) error {
+	err := s.streakRepository.DeleteFreeze(s.db.DB, ctx, userID, req.EventTypeID, req.Date)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.BadRequest.AddMessage("streak freeze not found")
+		}
+		logger.Error.Printf("error delete streak freeze: %v", err.Error())
+		return errs.InternalError
+	}
+	return nil
+}
+
+// checkType returns the type or an error if the type doesn't belong to the user
+func (s *Streak) checkType(ctx context.Context, userID, typeID int32) (*entity.EventType, error) {
+	eventType, err := s.eventRepository.GetType(s.db.DB, ctx, userID, typeID)
+	if err != nil {
+		logger.Error.Printf("error get type: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if eventType == nil || eventType.DeletedAt != nil {
+		return nil, errs.BadRequest.AddMessage("event type not found")
+	}
+	return eventType, nil
+}
+
+// streakDays returns the days from the day of from to the day before to on which events are expected
+func streakDays(schedule *entity.EventSchedule, from, to time.Time) ([]time.Time, error) {
+	start := to
+	var rule *recurrence.Rule
+	if schedule != nil {
+		var err error
+		rule, err = recurrence.FromSchedule(schedule)
+		if err != nil {
+			return nil, err
+		}
+		if rule.Start.Before(start) {
+			start = rule.Start
+		}
+	}
+
+	var res []time.Time
+	for day := from; day.Before(start); day = day.AddDate(0, 0, 1) {
+		res = append(res, day)
+	}
+	if rule != nil {
+		if from.After(start) {
+			start = from
+		}
+		res = append(res, rule.Between(start, to)...)
+	}
+	return res, nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+
+	"github.com/HardDie/event_tracker/internal/config"
+	"github.com/HardDie/event_tracker/internal/db"
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/repository"
+)
+
+type ITrash interface {
+	List(ctx context.Context, userID int32, req *dto.ListTrashDTO) ([]*dto.TrashItemResponseDTO, int32, error)
+	Restore(ctx context.Context, userID int32, req *dto.TrashItemDTO) error
+	Delete(ctx context.Context, userID int32, req *dto.TrashItemDTO) error
+	Purge(ctx context.Context) error
+}
+
+type Trash struct {
+	trashRepository repository.ITrash
+	eventRepository repository.IEvent
+
+	cfg *config.Config
+	db  *db.DB
+}
+
+func NewTrash(db *db.DB, cfg *config.Config, trash repository.ITrash, event repository.IEvent) *Trash {
+	return &Trash{
+		db:              db,
+		cfg:             cfg,
+		trashRepository: trash,
+		eventRepository: event,
+	}
+}
+
+func (s *Trash) List(ctx context.Context, userID int32, req *dto.ListTrashDTO) ([]*dto.TrashItemResponseDTO, int32, error) {
+	res, total, err := s.trashRepository.List(s.db.DB, ctx, userID, req)
+	if err != nil {
+		logger.Error.Printf("error list trash: %v", err.Error())
+		return nil, 0, errs.InternalError
+	}
+	for _, item := range res {
+		item.PurgeAt = item.DeletedAt.Add(s.retention())
+	}
+	return res, total, nil
+}
+func (s *Trash) Restore(ctx context.Context, userID int32, req *dto.TrashItemDTO) error {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	// Assigned to err to roll back the restored events if the type can't be restored
+	switch req.Kind {
+	case dto.TrashKindEvents:
+		err = s.restoreEvent(tx, ctx, userID, req.ID)
+	case dto.TrashKindTypes:
+		err = s.restoreType(tx, ctx, userID, req.ID)
+	}
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Delete removes the item permanently, a type is removed with all its events
+func (s *Trash) Delete(ctx context.Context, userID int32, req *dto.TrashItemDTO) error {
+	var err error
+	switch req.Kind {
+	case dto.TrashKindEvents:
+		err = s.trashRepository.DeleteEvent(s.db.DB, ctx, userID, req.ID)
+	case dto.TrashKindTypes:
+		err = s.trashRepository.DeleteType(s.db.DB, ctx, userID, req.ID)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.BadRequest.AddMessage("item not found in the trash")
+		}
+		logger.Error.Printf("error delete from trash: %v", err.Error())
+		return errs.InternalError
+	}
+	return nil
+}
+
+// Purge permanently removes events and types deleted before the retention period
+func (s *Trash) Purge(ctx context.Context) error {
+	deletedBefore := time.Now().UTC().Add(-s.retention())
+
+	events, err := s.trashRepository.PurgeEvents(s.db.DB, ctx, deletedBefore)
+	if err != nil {
+		logger.Error.Printf("error purging deleted events: %v", err.Error())
+		return errs.InternalError
+	}
+	types, err := s.trashRepository.PurgeTypes(s.db.DB, ctx, deletedBefore)
+	if err != nil {
+		logger.Error.Printf("error purging deleted event types: %v", err.Error())
+		return errs.InternalError
+	}
+	if len(events) > 0 || len(types) > 0 {
+		logger.Info.Printf("purged %d deleted events and %d deleted event types", len(events), len(types))
+	}
+	return nil
+}
+
+func (s *Trash) retention() time.Duration {
+	return time.Hour * 24 * time.Duration(s.cfg.TrashRetention)
+}
+func (s *Trash) restoreEvent(tx godb.Queryer, ctx context.Context, userID, id int32) error {
+	event, err := s.eventRepository.GetEvent(tx, ctx, userID, id)
+	if err != nil {
+		logger.Error.Printf("error get event: %v", err.Error())
+		return errs.InternalError
+	}
+	if event == nil || event.DeletedAt == nil {
+		return errs.BadRequest.AddMessage("event not found in the trash")
+	}
+	if time.Now().UTC().Sub(*event.DeletedAt) > s.retention() {
+		return errs.BadRequest.AddMessage("event was deleted too long ago")
+	}
+
+	eventType, err := s.eventRepository.GetType(tx, ctx, userID, event.TypeID)
+	if err != nil {
+		logger.Error.Printf("error get type: %v", err.Error())
+		return errs.InternalError
+	}
+	if eventType == nil || eventType.DeletedAt != nil {
+		return errs.BadRequest.AddMessage("event type of the event is deleted, restore it first")
+	}
+
+	err = s.eventRepository.RestoreEvent(tx, ctx, userID, id)
+	if err != nil {
+		logger.Error.Printf("error restore event: %v", err.Error())
+		return errs.InternalError
+	}
+	return nil
+}
+func (s *Trash) restoreType(tx godb.Queryer, ctx context.Context, userID, id int32) error {
+	eventType, err := s.eventRepository.GetType(tx, ctx, userID, id)
+	if err != nil {
+		logger.Error.Printf("error get type: %v", err.Error())
+		return errs.InternalError
+	}
+	if eventType == nil || eventType.DeletedAt == nil {
+		return errs.BadRequest.AddMessage("event type not found in the trash")
+	}
+
+	_, err = restoreEventType(s.eventRepository, tx, ctx, s.retention(), eventType)
+	return err
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/HardDie/godb/v2"
+
+	"github.com/HardDie/event_tracker/internal/config"
+	"github.com/HardDie/event_tracker/internal/db"
+	"github.com/HardDie/event_tracker/internal/dto"
+	"github.com/HardDie/event_tracker/internal/entity"
+	"github.com/HardDie/event_tracker/internal/errs"
+	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/repository"
+	"github.com/HardDie/event_tracker/internal/utils"
+)
+
+const (
+	recoveryCodesCount        = 10
+	loginChallengeMaxAttempts = 5
+)
+
+type ITwoFactor interface {
+	Status(ctx context.Context, userID int32) (*dto.TwoFactorStatusResponseDTO, error)
+	Enroll(ctx context.Context, userID int32) (*dto.TwoFactorEnrollResponseDTO, error)
+	Confirm(ctx context.Context, userID int32, req *dto.TwoFactorConfirmDTO) (*dto.TwoFactorConfirmResponseDTO, error)
+	Disable(ctx context.Context, userID int32, req *dto.TwoFactorDisableDTO) error
+
+	CreateChallenge(ctx context.Context, userID int32) (*dto.LoginChallengeResponseDTO, error)
+	VerifyChallenge(ctx context.Context, req *dto.LoginTwoFactorDTO) (int32, error)
+}
+
+type TwoFactor struct {
+	totpRepository           repository.ITOTP
+	recoveryCodeRepository   repository.IRecoveryCode
+	loginChallengeRepository repository.ILoginChallenge
+	userRepository           repository.IUser
+	passwordRepository       repository.IPassword
+	authEventRepository      repository.IAuthEvent
+
+	cfg *config.Config
+	db  *db.DB
+}
+
+func NewTwoFactor(db *db.DB, cfg *config.Config, totp repository.ITOTP, recoveryCode repository.IRecoveryCode,
+	loginChallenge repository.ILoginChallenge, user repository.IUser, password repository.IPassword,
+	authEvent repository.IAuthEvent) *TwoFactor {
+	return &TwoFactor{
+		db:                       db,
+		cfg:                      cfg,
+		totpRepository:           totp,
+		recoveryCodeRepository:   recoveryCode,
+		loginChallengeRepository: loginChallenge,
+		userRepository:           user,
+		passwordRepository:       password,
+		authEventRepository:      authEvent,
+	}
+}
+
+func (s *TwoFactor) Status(ctx context.Context, userID int32) (*dto.TwoFactorStatusResponseDTO, error) {
+	totp, err := s.totpRepository.GetByUserID(s.db.DB, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error read totp from DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	return &dto.TwoFactorStatusResponseDTO{
+		Enabled: totp != nil && totp.EnabledAt != nil,
+	}, nil
+}
+func (s *TwoFactor) Enroll(ctx context.Context, userID int32) (*dto.TwoFactorEnrollResponseDTO, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	// Check if two-factor authentication is not enabled yet
+	totp, err := s.totpRepository.GetByUserID(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error read totp from DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if totp != nil && totp.EnabledAt != nil {
+		return nil, errs.BadRequest.AddMessage("two-factor authentication is already enabled")
+	}
+
+	user, err := s.userRepository.GetByID(tx, ctx, userID, true)
+	if err != nil {
+		logger.Error.Printf("error while trying get user: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if user == nil {
+		logger.Error.Printf("user %d not found", userID)
+		return nil, errs.InternalError
+	}
+
+	// Generate a new secret, it becomes active only after confirmation
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		logger.Error.Printf("error generate totp secret: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	_, err = s.totpRepository.CreateOrUpdate(tx, ctx, userID, secret)
+	if err != nil {
+		logger.Error.Printf("error writing totp into DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	return &dto.TwoFactorEnrollResponseDTO{
+		Secret: secret,
+		URI:    utils.TOTPURI(s.cfg.TOTPIssuer, user.Username, secret),
+	}, nil
+}
+func (s *TwoFactor) Confirm(ctx context.Context, userID int32, req *dto.TwoFactorConfirmDTO) (*dto.TwoFactorConfirmResponseDTO, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	// Check if enrollment was started
+	totp, err := s.totpRepository.GetByUserID(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error read totp from DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if totp == nil {
+		return nil, errs.BadRequest.AddMessage("two-factor authentication enrollment was not started")
+	}
+	if totp.EnabledAt != nil {
+		return nil, errs.BadRequest.AddMessage("two-factor authentication is already enabled")
+	}
+
+	// Check if the first code is correct
+	step, ok := utils.ValidateTOTP(totp.Secret, req.Code, time.Now())
+	if !ok {
+		return nil, errs.BadRequest.AddMessage("invalid code")
+	}
+
+	err = s.totpRepository.Enable(tx, ctx, totp.ID, step)
+	if err != nil {
+		logger.Error.Printf("error enabling totp: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	// Generate recovery codes, only their hashes are stored
+	codes, err := s.replaceRecoveryCodes(tx, ctx, userID)
+	if err != nil {
+		return nil, errs.InternalError
+	}
+
+	return &dto.TwoFactorConfirmResponseDTO{
+		RecoveryCodes: codes,
+	}, nil
+}
+func (s *TwoFactor) Disable(ctx context.Context, userID int32, req *dto.TwoFactorDisableDTO) error {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	totp, err := s.totpRepository.GetByUserID(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error read totp from DB: %v", err.Error())
+		return errs.InternalError
+	}
+	if totp == nil || totp.EnabledAt == nil {
+		return errs.BadRequest.AddMessage("two-factor authentication is not enabled")
+	}
+
+	// Check if password is correct
+	password, err := s.passwordRepository.GetByUserID(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error read password from DB: %v", err.Error())
+		return errs.InternalError
+	}
+	if password == nil || !utils.HashBcryptCompare(req.Password, password.PasswordHash) {
+		return errs.BadRequest.AddMessage("invalid password or code")
+	}
+
+	// Check if code is correct, either from the app or one of the recovery codes
+	ok, err := s.checkCode(tx, ctx, userID, req.Code, req.Code)
+	if err != nil {
+		return errs.InternalError
+	}
+	if !ok {
+		return errs.BadRequest.AddMessage("invalid password or code")
+	}
+
+	err = s.totpRepository.DeleteByID(tx, ctx, totp.ID)
+	if err != nil {
+		logger.Error.Printf("error deleting totp: %v", err.Error())
+		return errs.InternalError
+	}
+	err = s.recoveryCodeRepository.DeleteByUserID(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error deleting recovery codes: %v", err.Error())
+		return errs.InternalError
+	}
+	return nil
+}
+
+func (s *TwoFactor) CreateChallenge(ctx context.Context, userID int32) (*dto.LoginChallengeResponseDTO, error) {
+	// If two-factor authentication is disabled, no challenge is needed
+	totp, err := s.totpRepository.GetByUserID(s.db.DB, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error read totp from DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if totp == nil || totp.EnabledAt == nil {
+		return nil, nil
+	}
+
+	challengeKey, err := utils.GenerateSessionKey()
+	if err != nil {
+		logger.Error.Printf("error generate challenge key: %v", err)
+		return nil, errs.InternalError
+	}
+
+	expiresAt := time.Now().Add(time.Minute * time.Duration(s.cfg.LoginChallengeTimeout))
+	_, err = s.loginChallengeRepository.Create(s.db.DB, ctx, userID, utils.HashSha256(challengeKey), expiresAt)
+	if err != nil {
+		logger.Error.Printf("write login challenge to DB: %v", err)
+		return nil, errs.InternalError
+	}
+
+	return &dto.LoginChallengeResponseDTO{
+		TwoFactorRequired: true,
+		Challenge:         challengeKey,
+		ExpiresAt:         expiresAt,
+	}, nil
+}
+func (s *TwoFactor) VerifyChallenge(ctx context.Context, req *dto.LoginTwoFactorDTO) (int32, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return 0, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	// Check if challenge exist
+	challenge, err := s.loginChallengeRepository.GetByHash(tx, ctx, utils.HashSha256(req.Challenge))
+	if err != nil {
+		logger.Error.Printf("error read login challenge from DB: %v", err.Error())
+		return 0, errs.InternalError
+	}
+	if challenge == nil || time.Now().After(challenge.ExpiresAt) {
+		return 0, errs.SessionInvalid.AddMessage("challenge is invalid")
+	}
+
+	ok, err := s.checkCode(tx, ctx, challenge.UserID, req.Code, req.RecoveryCode)
+	if err != nil {
+		return 0, errs.InternalError
+	}
+	if !ok {
+		recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+			UserID:    challenge.UserID,
+			Type:      entity.AuthEventTwoFactor,
+			Reason:    authEventReason("invalid code"),
+			IP:        req.IP,
+			UserAgent: req.UserAgent,
+		})
+
+		// Too many invalid codes, the user has to enter the password again
+		challenge, err = s.loginChallengeRepository.IncreaseFailedAttempts(tx, ctx, challenge.ID)
+		if err != nil {
+			logger.Error.Printf("error increasing failed attempts: %v", err.Error())
+			return 0, errs.InternalError
+		}
+		if challenge.FailedAttempts >= loginChallengeMaxAttempts {
+			err = s.loginChallengeRepository.DeleteByID(tx, ctx, challenge.ID)
+			if err != nil {
+				logger.Error.Printf("error deleting login challenge: %v", err.Error())
+				return 0, errs.InternalError
+			}
+		}
+		return 0, errs.BadRequest.AddMessage("invalid code")
+	}
+
+	// The challenge can be used only once
+	err = s.loginChallengeRepository.DeleteByID(tx, ctx, challenge.ID)
+	if err != nil {
+		logger.Error.Printf("error deleting login challenge: %v", err.Error())
+		return 0, errs.InternalError
+	}
+
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:    challenge.UserID,
+		Type:      entity.AuthEventTwoFactor,
+		Success:   true,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
+	return challenge.UserID, nil
+}
+
+// checkCode validates either TOTP code or recovery code, a used recovery code is burned
+func (s *TwoFactor) checkCode(tx godb.Queryer, ctx context.Context, userID int32, code, recoveryCode string) (bool, error) {
+	totp, err := s.totpRepository.GetByUserID(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error read totp from DB: %v", err.Error())
+		return false, err
+	}
+	if totp == nil || totp.EnabledAt == nil {
+		return false, nil
+	}
+
+	if code != "" {
+		step, ok := utils.ValidateTOTP(totp.Secret, code, time.Now())
+		// Reject a code which was already used, otherwise it can be replayed
+		if ok && step > totp.LastUsedStep {
+			err = s.totpRepository.UpdateLastUsedStep(tx, ctx, totp.ID, step)
+			if err != nil {
+				logger.Error.Printf("error updating totp last used step: %v", err.Error())
+				return false, err
+			}
+			return true, nil
+		}
+	}
+
+	if recoveryCode != "" {
+		ok, err := s.recoveryCodeRepository.Use(tx, ctx, userID, utils.HashSha256(utils.NormalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			logger.Error.Printf("error using recovery code: %v", err.Error())
+			return false, err
+		}
+		return ok, nil
+	}
+	return false, nil
+}
+func (s *TwoFactor) replaceRecoveryCodes(tx godb.Queryer, ctx context.Context, userID int32) ([]string, error) {
+	err := s.recoveryCodeRepository.DeleteByUserID(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error deleting recovery codes: %v", err.Error())
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			logger.Error.Printf("error generate recovery code: %v", err.Error())
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashSha256(utils.NormalizeRecoveryCode(code)))
+	}
+
+	err = s.recoveryCodeRepository.Create(tx, ctx, userID, hashes)
+	if err != nil {
+		logger.Error.Printf("error writing recovery codes into DB: %v", err.Error())
+		return nil, err
+	}
+	return codes, nil
+}
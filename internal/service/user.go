@@ -2,12 +2,15 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/HardDie/event_tracker/internal/config"
 	"github.com/HardDie/event_tracker/internal/db"
 	"github.com/HardDie/event_tracker/internal/dto"
 	"github.com/HardDie/event_tracker/internal/entity"
 	"github.com/HardDie/event_tracker/internal/errs"
 	"github.com/HardDie/event_tracker/internal/logger"
+	"github.com/HardDie/event_tracker/internal/pwdpolicy"
 	"github.com/HardDie/event_tracker/internal/repository"
 	"github.com/HardDie/event_tracker/internal/utils"
 )
@@ -18,20 +21,37 @@ type IUser interface {
 	Password(ctx context.Context, req *dto.UpdatePasswordDTO, userID int32) error
 	UpdateProfile(ctx context.Context, req *dto.UpdateProfileDTO) (*entity.User, error)
 	UpdateImage(ctx context.Context, req *dto.UpdateProfileImageDTO) (*entity.User, error)
+	Delete(ctx context.Context, userID int32, req *dto.DeleteAccountDTO) (*dto.DeleteAccountResponseDTO, error)
+
+	PurgeDeleted(ctx context.Context) error
 }
 
 type User struct {
-	userRepository     repository.IUser
-	passwordRepository repository.IPassword
+	userRepository        repository.IUser
+	passwordRepository    repository.IPassword
+	sessionRepository     repository.ISession
+	accessTokenRepository repository.IAccessToken
+	authEventRepository   repository.IAuthEvent
 
-	db *db.DB
+	passwordPolicy *pwdpolicy.Policy
+	tokens         ITokenRevoker
+	cfg            *config.Config
+	db             *db.DB
 }
 
-func NewUser(db *db.DB, repository repository.IUser, password repository.IPassword) *User {
+func NewUser(db *db.DB, cfg *config.Config, tokens ITokenRevoker, passwordPolicy *pwdpolicy.Policy, repository repository.IUser,
+	password repository.IPassword, session repository.ISession, accessToken repository.IAccessToken,
+	authEvent repository.IAuthEvent) *User {
 	return &User{
-		db:                 db,
-		userRepository:     repository,
-		passwordRepository: password,
+		db:                    db,
+		cfg:                   cfg,
+		tokens:                tokens,
+		passwordPolicy:        passwordPolicy,
+		userRepository:        repository,
+		passwordRepository:    password,
+		sessionRepository:     session,
+		accessTokenRepository: accessToken,
+		authEventRepository:   authEvent,
 	}
 }
 
@@ -65,9 +85,31 @@ func (s *User) Password(ctx context.Context, req *dto.UpdatePasswordDTO, userID
 
 	// Check if password is correct
 	if !utils.HashBcryptCompare(req.OldPassword, password.PasswordHash) {
+		recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+			UserID:    userID,
+			Type:      entity.AuthEventPasswordChange,
+			Reason:    authEventReason("invalid old password"),
+			IP:        req.IP,
+			UserAgent: req.UserAgent,
+		})
 		return errs.BadRequest.AddMessage("invalid old password")
 	}
 
+	// Check if new password is strong enough
+	user, err := s.userRepository.GetByID(tx, ctx, userID, true)
+	if err != nil {
+		logger.Error.Printf("error read user from DB: %v", err.Error())
+		return errs.InternalError
+	}
+	if user == nil {
+		logger.Error.Printf("user %d not found in DB", userID)
+		return errs.InternalError
+	}
+	err = checkPasswordPolicy(ctx, s.passwordPolicy, req.NewPassword, user.Username)
+	if err != nil {
+		return err
+	}
+
 	// Hashing password
 	hashPassword, err := utils.HashBcrypt(req.NewPassword)
 	if err != nil {
@@ -76,14 +118,29 @@ func (s *User) Password(ctx context.Context, req *dto.UpdatePasswordDTO, userID
 	}
 
 	// Update password
-	password, err = s.passwordRepository.Update(tx, ctx, userID, hashPassword)
+	password, err = s.passwordRepository.Update(tx, ctx, password.ID, hashPassword)
 	if err != nil {
 		logger.Error.Printf("error updating password in DB: %v", err.Error())
 		return errs.InternalError
 	}
+
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:    userID,
+		Type:      entity.AuthEventPasswordChange,
+		Success:   true,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
 	return nil
 }
 func (s *User) UpdateProfile(ctx context.Context, req *dto.UpdateProfileDTO) (*entity.User, error) {
+	if req.TimeZone != nil {
+		_, err := utils.LoadTimeZone(*req.TimeZone)
+		if err != nil {
+			return nil, errs.BadRequest.AddMessage("unknown time zone")
+		}
+	}
+
 	user, err := s.userRepository.UpdateProfile(s.db.DB, ctx, req)
 	if err != nil {
 		logger.Error.Printf("error update user profile: %v", err.Error())
@@ -99,3 +156,79 @@ func (s *User) UpdateImage(ctx context.Context, req *dto.UpdateProfileImageDTO)
 	}
 	return user, nil
 }
+func (s *User) Delete(ctx context.Context, userID int32, req *dto.DeleteAccountDTO) (*dto.DeleteAccountResponseDTO, error) {
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		logger.Error.Printf("error starting transaction: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	defer func() { s.db.EndTx(tx, err) }()
+
+	// Get password from DB
+	password, err := s.passwordRepository.GetByUserID(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error read password from DB: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	if password == nil {
+		logger.Error.Printf("password for user %d not found in DB", userID)
+		return nil, errs.InternalError
+	}
+
+	// Check if password is correct
+	if !utils.HashBcryptCompare(req.Password, password.PasswordHash) {
+		recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+			UserID:    userID,
+			Type:      entity.AuthEventAccountDelete,
+			Reason:    authEventReason("invalid password"),
+			IP:        req.IP,
+			UserAgent: req.UserAgent,
+		})
+		return nil, errs.BadRequest.AddMessage("invalid password")
+	}
+
+	// The data stays in DB until the grace period is over, so the account can be restored
+	err = s.userRepository.Delete(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error deleting user: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	// Close all the ways to access the account
+	ids, err := s.sessionRepository.DeleteAllByUserID(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error deleting sessions: %v", err.Error())
+		return nil, errs.InternalError
+	}
+	s.tokens.RevokeTokens(ids...)
+	_, err = s.accessTokenRepository.DeleteAllByUserID(tx, ctx, userID)
+	if err != nil {
+		logger.Error.Printf("error deleting access tokens: %v", err.Error())
+		return nil, errs.InternalError
+	}
+
+	recordAuthEvent(s.authEventRepository, tx, ctx, &dto.CreateAuthEventDTO{
+		UserID:    userID,
+		Type:      entity.AuthEventAccountDelete,
+		Success:   true,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+	})
+	return &dto.DeleteAccountResponseDTO{
+		PurgeAt: time.Now().AddDate(0, 0, s.cfg.AccountDeletionGrace),
+	}, nil
+}
+
+// PurgeDeleted permanently removes accounts whose grace period is over together with all their data
+func (s *User) PurgeDeleted(ctx context.Context) error {
+	deletedBefore := time.Now().UTC().AddDate(0, 0, -s.cfg.AccountDeletionGrace)
+	ids, err := s.userRepository.PurgeDeleted(s.db.DB, ctx, deletedBefore)
+	if err != nil {
+		logger.Error.Printf("error purging deleted users: %v", err.Error())
+		return errs.InternalError
+	}
+	if len(ids) > 0 {
+		logger.Info.Printf("purged %d deleted users", len(ids))
+	}
+	return nil
+}
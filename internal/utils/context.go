@@ -12,3 +12,24 @@ func GetUserIDFromContext(ctx context.Context) int32 {
 func GetSessionFromContext(ctx context.Context) *entity.Session {
 	return ctx.Value("session").(*entity.Session)
 }
+
+// HasScopeInContext reports whether the request is allowed to use the scope. Requests
+// made with a login session have no scopes in the context and are allowed everything.
+func HasScopeInContext(ctx context.Context, scope string) bool {
+	scopes, ok := ctx.Value("scopes").([]string)
+	if !ok {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRoleInContext reports whether the role middleware has granted the role to the request
+func HasRoleInContext(ctx context.Context, role string) bool {
+	value, ok := ctx.Value("role").(string)
+	return ok && value == role
+}
@@ -2,12 +2,20 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
 	"net/http"
 	"strings"
 )
 
+const (
+	SessionCookieName = "session"
+	RefreshCookieName = "refresh_token"
+	CSRFCookieName    = "csrf_token"
+	CSRFHeaderName    = "X-CSRF-Token"
+)
+
 func GetBearer(r *http.Request) string {
 	header := r.Header.Get("Authorization")
 	return strings.ReplaceAll(header, "Bearer ", "")
@@ -25,3 +33,22 @@ func GenerateSessionKey() (string, error) {
 	}
 	return base64.URLEncoding.EncodeToString(b), nil
 }
+
+func GetCookie(r *http.Request, name string) string {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// CheckCSRF compares the CSRF token from the header with the one from the cookie,
+// a foreign site can send the cookie but can't read it to set the header
+func CheckCSRF(r *http.Request) bool {
+	cookie := GetCookie(r, CSRFCookieName)
+	header := r.Header.Get(CSRFHeaderName)
+	if cookie == "" || header == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
@@ -3,8 +3,10 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -67,6 +69,14 @@ func GetInt32FromQuery(r *http.Request, key string, defaultValue int32) int32 {
 	}
 	return int32(value)
 }
+func GetBoolFromQuery(r *http.Request, key string, defaultValue bool) bool {
+	strValue := r.URL.Query().Get(key)
+	value, err := strconv.ParseBool(strValue)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
 func GetInt32FromPath(r *http.Request, key string) (int32, error) {
 	m := mux.Vars(r)
 	if m == nil {
@@ -82,3 +92,70 @@ func GetInt32FromPath(r *http.Request, key string) (int32, error) {
 	}
 	return int32(res), nil
 }
+
+// trustedProxies are the networks of the reverse proxies whose forwarding headers are trusted
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies sets the networks of the reverse proxies in front of the service,
+// a single address is a network of one host
+func SetTrustedProxies(cidrs []string) error {
+	var res []*net.IPNet
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return fmt.Errorf("bad trusted proxy address %q", cidr)
+			}
+			res = append(res, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("bad trusted proxy network %q: %w", cidr, err)
+		}
+		res = append(res, network)
+	}
+	trustedProxies = res
+	return nil
+}
+
+// GetClientIP returns the address of the client. Forwarding headers are read only if the request
+// came from a trusted proxy. Proxies append the address of their peer to the end of X-Forwarded-For,
+// so the entries are walked from the end and the first one which isn't a trusted proxy is used;
+// the preceding ones can be forged by the client.
+func GetClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrustedProxy(host) {
+		return host
+	}
+
+	if header := r.Header.Get("X-Forwarded-For"); header != "" {
+		list := strings.Split(header, ",")
+		for i := len(list) - 1; i >= 0; i-- {
+			host = strings.TrimSpace(list[i])
+			if !isTrustedProxy(host) {
+				break
+			}
+		}
+		return host
+	}
+	if header := r.Header.Get("X-Real-IP"); header != "" {
+		return strings.TrimSpace(header)
+	}
+	return host
+}
+func isTrustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
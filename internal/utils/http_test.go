@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetClientIP(t *testing.T) {
+	err := SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { trustedProxies = nil }()
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"direct", "203.0.113.5:1234", "", "", "203.0.113.5"},
+		{"untrusted peer with forged header", "203.0.113.5:1234", "198.51.100.1", "198.51.100.2", "203.0.113.5"},
+		{"trusted proxy", "10.1.2.3:1234", "198.51.100.1", "", "198.51.100.1"},
+		{"forged entries before the proxy", "10.1.2.3:1234", "1.1.1.1, 198.51.100.1", "", "198.51.100.1"},
+		{"chain of proxies", "10.1.2.3:1234", "198.51.100.1, 192.168.1.1", "", "198.51.100.1"},
+		{"real ip header", "192.168.1.1:1234", "", "198.51.100.3", "198.51.100.3"},
+		{"trusted proxy without headers", "10.1.2.3:1234", "", "", "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := GetClientIP(r); got != tt.want {
+				t.Errorf("GetClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+func TestSetTrustedProxiesError(t *testing.T) {
+	for _, value := range []string{"proxy", "10.0.0.0/40"} {
+		if err := SetTrustedProxies([]string{value}); err == nil {
+			t.Errorf("SetTrustedProxies(%q) expected an error", value)
+		}
+	}
+	trustedProxies = nil
+}
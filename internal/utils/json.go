@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 )
 
 func ParseJsonFromHTTPRequest(r io.ReadCloser, data interface{}) error {
@@ -19,3 +20,21 @@ func ParseJsonFromHTTPRequest(r io.ReadCloser, data interface{}) error {
 	}
 	return nil
 }
+
+// ParseOptionalJsonFromHTTPRequest works like ParseJsonFromHTTPRequest, but an empty body leaves the data as is
+func ParseOptionalJsonFromHTTPRequest(r io.ReadCloser, data interface{}) error {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read data from HTTP request: %w", err)
+	}
+	if err = r.Close(); err != nil {
+		return fmt.Errorf("closing request body: %w", err)
+	}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return nil
+	}
+	if err = json.Unmarshal(body, data); err != nil {
+		return fmt.Errorf("parse json HTTP request: %w", err)
+	}
+	return nil
+}
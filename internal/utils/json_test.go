@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseOptionalJsonFromHTTPRequest(t *testing.T) {
+	type request struct {
+		Token string `json:"token"`
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{"empty body", "", "", false},
+		{"whitespace", " \n", "", false},
+		{"empty object", "{}", "", false},
+		{"token", `{"token":"abc"}`, "abc", false},
+		{"broken json", "{", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &request{}
+			err := ParseOptionalJsonFromHTTPRequest(io.NopCloser(strings.NewReader(tt.body)), req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if req.Token != tt.want {
+				t.Errorf("token = %q, want %q", req.Token, tt.want)
+			}
+		})
+	}
+}
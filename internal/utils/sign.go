@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignedToken is a stateless token for links sent by email. Besides the user and expiration time,
+// the signature covers the purpose and a binding value, e.g. the current password hash,
+// so the token stops working as soon as the bound value changes.
+type SignedToken struct {
+	UserID    int32
+	ExpiresAt time.Time
+	signature []byte
+}
+
+func SignToken(secret, purpose string, userID int32, expiresAt time.Time, binding string) string {
+	payload := fmt.Sprintf("%d.%d", userID, expiresAt.Unix())
+	signature := signPayload(secret, purpose, payload, binding)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func ParseSignedToken(token string) (*SignedToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, errors.New("bad token format")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("bad token payload: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("bad token signature: %w", err)
+	}
+
+	fields := strings.Split(string(payload), ".")
+	if len(fields) != 2 {
+		return nil, errors.New("bad token payload")
+	}
+	userID, err := strconv.ParseInt(fields[0], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("bad user in token: %w", err)
+	}
+	expiresAt, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("bad expiration in token: %w", err)
+	}
+
+	return &SignedToken{
+		UserID:    int32(userID),
+		ExpiresAt: time.Unix(expiresAt, 0),
+		signature: signature,
+	}, nil
+}
+
+// Verify checks the signature and the expiration time
+func (t *SignedToken) Verify(secret, purpose, binding string) bool {
+	if time.Now().After(t.ExpiresAt) {
+		return false
+	}
+	payload := fmt.Sprintf("%d.%d", t.UserID, t.ExpiresAt.Unix())
+	return hmac.Equal(t.signature, signPayload(secret, purpose, payload, binding))
+}
+
+func signPayload(secret, purpose, payload, binding string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose + "\n" + payload + "\n" + binding))
+	return mac.Sum(nil)
+}
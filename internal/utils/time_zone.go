@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	// Zones are embedded, so they don't depend on the system
+	_ "time/tzdata"
+)
+
+// LoadTimeZone returns the location of an IANA time zone, e.g. Europe/Berlin
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, errors.New("unknown time zone " + name)
+	}
+	return time.LoadLocation(name)
+}
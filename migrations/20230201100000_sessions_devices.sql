@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions DROP CONSTRAINT sessions_user_id_key;
+ALTER TABLE sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT ('');
+ALTER TABLE sessions ADD COLUMN ip TEXT NOT NULL DEFAULT ('');
+ALTER TABLE sessions ADD COLUMN last_seen_at TIMESTAMP NOT NULL DEFAULT (now());
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM sessions s USING sessions n WHERE s.user_id = n.user_id AND s.id < n.id;
+DROP INDEX sessions_user_id_idx;
+ALTER TABLE sessions DROP COLUMN last_seen_at;
+ALTER TABLE sessions DROP COLUMN ip;
+ALTER TABLE sessions DROP COLUMN user_agent;
+ALTER TABLE sessions ADD CONSTRAINT sessions_user_id_key UNIQUE (user_id);
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id         SERIAL    PRIMARY KEY,
+    session_id INT       NOT NULL REFERENCES sessions(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    token_hash TEXT      NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    deleted_at TIMESTAMP
+);
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens (session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE refresh_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS access_tokens (
+    id           SERIAL    PRIMARY KEY,
+    user_id      INT       NOT NULL REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    name         TEXT      NOT NULL,
+    token_hash   TEXT      NOT NULL UNIQUE,
+    scopes       TEXT[]    NOT NULL,
+    last_used_at TIMESTAMP,
+    expires_at   TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at   TIMESTAMP NOT NULL DEFAULT (now()),
+    deleted_at   TIMESTAMP
+);
+CREATE INDEX access_tokens_user_id_idx ON access_tokens (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE access_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS totp (
+    id             SERIAL    PRIMARY KEY,
+    user_id        INT       NOT NULL UNIQUE REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    secret         TEXT      NOT NULL,
+    last_used_step BIGINT    NOT NULL DEFAULT (0),
+    enabled_at     TIMESTAMP,
+    created_at     TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at     TIMESTAMP NOT NULL DEFAULT (now()),
+    deleted_at     TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id         SERIAL    PRIMARY KEY,
+    user_id    INT       NOT NULL REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    code_hash  TEXT      NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    deleted_at TIMESTAMP
+);
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes (user_id);
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id              SERIAL    PRIMARY KEY,
+    user_id         INT       NOT NULL REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    challenge_hash  TEXT      NOT NULL UNIQUE,
+    failed_attempts INT       NOT NULL DEFAULT (0),
+    expires_at      TIMESTAMP NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at      TIMESTAMP NOT NULL DEFAULT (now()),
+    deleted_at      TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE login_challenges;
+DROP TABLE recovery_codes;
+DROP TABLE totp;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key          TEXT      NOT NULL,
+    window_start TIMESTAMP NOT NULL,
+    count        INT       NOT NULL DEFAULT (1),
+    expires_at   TIMESTAMP NOT NULL,
+    PRIMARY KEY (key, window_start)
+);
+CREATE INDEX rate_limits_expires_at_idx ON rate_limits (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE rate_limits;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS auth_events (
+    id         SERIAL    PRIMARY KEY,
+    user_id    INT       NOT NULL REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    type       TEXT      NOT NULL,
+    success    BOOLEAN   NOT NULL,
+    reason     TEXT,
+    ip         TEXT      NOT NULL DEFAULT (''),
+    user_agent TEXT      NOT NULL DEFAULT (''),
+    created_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+CREATE INDEX auth_events_user_id_idx ON auth_events (user_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE auth_events;
+-- +goose StatementEnd
@@ -0,0 +1,67 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE passwords DROP CONSTRAINT passwords_user_id_fkey,
+    ADD CONSTRAINT passwords_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE sessions DROP CONSTRAINT sessions_user_id_fkey,
+    ADD CONSTRAINT sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE event_types DROP CONSTRAINT event_types_user_id_fkey,
+    ADD CONSTRAINT event_types_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE events DROP CONSTRAINT events_user_id_fkey,
+    ADD CONSTRAINT events_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE events DROP CONSTRAINT events_type_id_fkey,
+    ADD CONSTRAINT events_type_id_fkey FOREIGN KEY (type_id) REFERENCES event_types(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE friend_invites DROP CONSTRAINT friend_invites_user_id_fkey,
+    ADD CONSTRAINT friend_invites_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE friend_invites DROP CONSTRAINT friend_invites_with_user_id_fkey,
+    ADD CONSTRAINT friend_invites_with_user_id_fkey FOREIGN KEY (with_user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE friends DROP CONSTRAINT friends_user_id_fkey,
+    ADD CONSTRAINT friends_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE friends DROP CONSTRAINT friends_with_user_id_fkey,
+    ADD CONSTRAINT friends_with_user_id_fkey FOREIGN KEY (with_user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE refresh_tokens DROP CONSTRAINT refresh_tokens_session_id_fkey,
+    ADD CONSTRAINT refresh_tokens_session_id_fkey FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE access_tokens DROP CONSTRAINT access_tokens_user_id_fkey,
+    ADD CONSTRAINT access_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE totp DROP CONSTRAINT totp_user_id_fkey,
+    ADD CONSTRAINT totp_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE recovery_codes DROP CONSTRAINT recovery_codes_user_id_fkey,
+    ADD CONSTRAINT recovery_codes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE login_challenges DROP CONSTRAINT login_challenges_user_id_fkey,
+    ADD CONSTRAINT login_challenges_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE auth_events DROP CONSTRAINT auth_events_user_id_fkey,
+    ADD CONSTRAINT auth_events_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE passwords DROP CONSTRAINT passwords_user_id_fkey,
+    ADD CONSTRAINT passwords_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE sessions DROP CONSTRAINT sessions_user_id_fkey,
+    ADD CONSTRAINT sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE event_types DROP CONSTRAINT event_types_user_id_fkey,
+    ADD CONSTRAINT event_types_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE events DROP CONSTRAINT events_user_id_fkey,
+    ADD CONSTRAINT events_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE events DROP CONSTRAINT events_type_id_fkey,
+    ADD CONSTRAINT events_type_id_fkey FOREIGN KEY (type_id) REFERENCES event_types(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE friend_invites DROP CONSTRAINT friend_invites_user_id_fkey,
+    ADD CONSTRAINT friend_invites_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE friend_invites DROP CONSTRAINT friend_invites_with_user_id_fkey,
+    ADD CONSTRAINT friend_invites_with_user_id_fkey FOREIGN KEY (with_user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE friends DROP CONSTRAINT friends_user_id_fkey,
+    ADD CONSTRAINT friends_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE friends DROP CONSTRAINT friends_with_user_id_fkey,
+    ADD CONSTRAINT friends_with_user_id_fkey FOREIGN KEY (with_user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE refresh_tokens DROP CONSTRAINT refresh_tokens_session_id_fkey,
+    ADD CONSTRAINT refresh_tokens_session_id_fkey FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE access_tokens DROP CONSTRAINT access_tokens_user_id_fkey,
+    ADD CONSTRAINT access_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE totp DROP CONSTRAINT totp_user_id_fkey,
+    ADD CONSTRAINT totp_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE recovery_codes DROP CONSTRAINT recovery_codes_user_id_fkey,
+    ADD CONSTRAINT recovery_codes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE login_challenges DROP CONSTRAINT login_challenges_user_id_fkey,
+    ADD CONSTRAINT login_challenges_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+ALTER TABLE auth_events DROP CONSTRAINT auth_events_user_id_fkey,
+    ADD CONSTRAINT auth_events_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS exports (
+    id           SERIAL    PRIMARY KEY,
+    user_id      INT       NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    status       TEXT      NOT NULL,
+    archive      BYTEA,
+    size         BIGINT,
+    expires_at   TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at   TIMESTAMP NOT NULL DEFAULT (now())
+);
+CREATE INDEX exports_user_id_idx ON exports (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE exports;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT ('user') CHECK (role IN ('user', 'moderator', 'admin'));
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN disabled_at;
+ALTER TABLE users DROP COLUMN role;
+-- +goose StatementEnd
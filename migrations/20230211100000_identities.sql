@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS identities (
+    id         SERIAL    PRIMARY KEY,
+    user_id    INT       NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    provider   TEXT      NOT NULL,
+    subject    TEXT      NOT NULL,
+    email      TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    UNIQUE (provider, subject)
+);
+CREATE INDEX identities_user_id_idx ON identities (user_id);
+CREATE TABLE IF NOT EXISTS oidc_states (
+    id            SERIAL    PRIMARY KEY,
+    state_hash    TEXT      NOT NULL UNIQUE,
+    nonce         TEXT      NOT NULL,
+    code_verifier TEXT      NOT NULL,
+    user_id       INT       REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    use_cookie    BOOLEAN   NOT NULL DEFAULT (false),
+    expires_at    TIMESTAMP NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at    TIMESTAMP NOT NULL DEFAULT (now()),
+    deleted_at    TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE oidc_states;
+DROP TABLE identities;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE event_types ADD COLUMN unit TEXT NOT NULL DEFAULT ('');
+ALTER TABLE events ADD COLUMN value NUMERIC;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN value;
+ALTER TABLE event_types DROP COLUMN unit;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE event_types ADD COLUMN share_notes BOOLEAN NOT NULL DEFAULT (false);
+ALTER TABLE events ADD COLUMN note TEXT NOT NULL DEFAULT ('') CHECK (char_length(note) <= 4000);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN note;
+ALTER TABLE event_types DROP COLUMN share_notes;
+-- +goose StatementEnd
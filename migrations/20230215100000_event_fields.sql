@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE event_types ADD COLUMN fields JSONB NOT NULL DEFAULT ('[]');
+ALTER TABLE events ADD COLUMN fields JSONB NOT NULL DEFAULT ('{}');
+CREATE INDEX events_fields_idx ON events USING GIN (fields);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX events_fields_idx;
+ALTER TABLE events DROP COLUMN fields;
+ALTER TABLE event_types DROP COLUMN fields;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN time_zone TEXT NOT NULL DEFAULT ('UTC');
+-- Old events only have a day stored as midnight UTC, noon of that day stays on the same day in zones from UTC-12 to UTC+11
+ALTER TABLE events ALTER COLUMN date TYPE TIMESTAMPTZ USING (date + INTERVAL '12 hours') AT TIME ZONE 'UTC';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events ALTER COLUMN date TYPE TIMESTAMP USING date_trunc('day', date AT TIME ZONE 'UTC');
+ALTER TABLE users DROP COLUMN time_zone;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN ended_at TIMESTAMPTZ;
+ALTER TABLE events ADD COLUMN running BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE events ADD CONSTRAINT events_ended_at_check CHECK (ended_at IS NULL OR ended_at >= date);
+ALTER TABLE events ADD CONSTRAINT events_running_check CHECK (NOT running OR ended_at IS NULL);
+CREATE UNIQUE INDEX events_running_type_id_idx ON events (type_id) WHERE running AND deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX events_running_type_id_idx;
+ALTER TABLE events DROP COLUMN running;
+ALTER TABLE events DROP COLUMN ended_at;
+-- +goose StatementEnd
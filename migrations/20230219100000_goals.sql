@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS goals (
+    id         SERIAL    PRIMARY KEY,
+    user_id    INT       NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    type_id    INT       NOT NULL REFERENCES event_types(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    period     TEXT      NOT NULL,
+    metric     TEXT      NOT NULL,
+    comparison TEXT      NOT NULL,
+    target     NUMERIC   NOT NULL CHECK (target >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+CREATE INDEX goals_user_id_idx ON goals (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE goals;
+-- +goose StatementEnd
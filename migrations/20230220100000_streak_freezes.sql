@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS streak_freezes (
+    id         SERIAL    PRIMARY KEY,
+    user_id    INT       NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    type_id    INT       NOT NULL REFERENCES event_types(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    date       DATE      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    UNIQUE (type_id, date)
+);
+CREATE INDEX streak_freezes_user_id_idx ON streak_freezes (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE streak_freezes;
+-- +goose StatementEnd